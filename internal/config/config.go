@@ -0,0 +1,527 @@
+// Package config holds the server settings. Values are layered: built-in
+// defaults, then an optional JSON config file, then CLIFOLIO_* environment
+// variables, then command-line flags (applied by main).
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes as "90s", "5m", "1h30m".
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Set and the String method above let a Duration be used as a flag.Value.
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type SSH struct {
+	// Address is the host:port the SSH server listens on.
+	Address string `json:"address"`
+	// HostKeyPaths are private key files. Missing files are generated.
+	HostKeyPaths []string `json:"host_keys"`
+	// IdleTimeout disconnects connections without any traffic, including
+	// output. See Limits.InputIdleTimeout for visitors who stopped typing.
+	// Zero disables it.
+	IdleTimeout Duration `json:"idle_timeout"`
+	// MaxSessionDuration is an absolute cap on a connection. Zero disables it.
+	MaxSessionDuration Duration `json:"max_session_duration"`
+	// Banner is shown to clients before authentication.
+	Banner string `json:"banner"`
+	// ShutdownGrace is how long visitors get to finish up, with a countdown
+	// on screen, when the server is stopped. Browser sessions get the same.
+	// Zero ends sessions at once.
+	ShutdownGrace Duration `json:"shutdown_grace"`
+}
+
+// Web is the gateway that serves the TUI to browsers over a WebSocket.
+type Web struct {
+	// Address is the host:port the gateway listens on.
+	Address string `json:"address"`
+	// AllowedOrigins lists other sites, like "https://example.com", whose
+	// pages may open a terminal. The gateway's own page always may.
+	AllowedOrigins []string `json:"allowed_origins"`
+	// APIOrigins lists the sites whose pages may read the JSON API at
+	// /api/, or "*" for any site.
+	APIOrigins []string `json:"api_origins"`
+}
+
+// Finger is an RFC 1288 finger server answering with the profile.
+type Finger struct {
+	// Address is the host:port to listen on, empty disables the server.
+	// Finger's own port is 79, which needs privileges to bind.
+	Address string `json:"address"`
+	// User is the login that fingers the profile, as in `finger janpol@host`.
+	// It defaults to the profile's first name in lower case, or "me" without one.
+	User string `json:"user"`
+	// Availability is a short note for the profile, like "Open to work".
+	Availability string `json:"availability"`
+}
+
+// Gopher is an RFC 1436 server publishing the portfolio as menus.
+type Gopher struct {
+	// Address is the host:port to listen on. Gopher's own port is 70.
+	Address string `json:"address"`
+	// Hostname is the name menus link back to, the machine's by default.
+	Hostname string `json:"hostname"`
+}
+
+// Gemini serves the portfolio as gemtext over TLS.
+type Gemini struct {
+	// Address is the host:port to listen on. Gemini's own port is 1965.
+	Address string `json:"address"`
+	// Hostname is who the certificate is for and the only host requests
+	// are answered for. Unset, requests for any host are answered and a
+	// generated certificate is for the machine's name.
+	Hostname string `json:"hostname"`
+	// CertFile and KeyFile are the certificate and its key in PEM. Missing
+	// files are generated as a self-signed pair.
+	CertFile string `json:"cert"`
+	KeyFile  string `json:"key"`
+}
+
+// Metrics is a local HTTP listener for monitoring, with Prometheus metrics
+// and health checks.
+type Metrics struct {
+	// Address is the host:port to listen on, empty disables it. Keep it off
+	// public interfaces, like 127.0.0.1:9100.
+	Address string `json:"address"`
+}
+
+// Proxy lets a load balancer in front of the servers pass on each
+// client's address with a PROXY protocol header. It applies to every
+// listener but the metrics one.
+type Proxy struct {
+	// TrustedProxies are the addresses or CIDRs, like "10.0.0.0/8", whose
+	// connections may carry a header. Empty turns the protocol off, and
+	// headers from anywhere else are never read.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Access decides who may reach the SSH server at all, before the limits
+// are counted.
+type Access struct {
+	// Allow lists the addresses or CIDRs that may connect. Empty lets in
+	// everyone who isn't denied.
+	Allow []string `json:"allow"`
+	// Deny lists addresses or CIDRs that are always turned away.
+	Deny []string `json:"deny"`
+	// BannedKeys are key fingerprints, like "SHA256:...", that are always
+	// turned away.
+	BannedKeys []string `json:"banned_keys"`
+	// Path is the JSON file holding bans, both automatic ones and those made
+	// from the admin console, defaults to bans.json in the data directory.
+	Path string `json:"path"`
+	// Strikes bans an address for BanDuration once it has failed the
+	// handshake or gone over the per-address limits this many times within
+	// StrikeWindow. Zero disables automatic bans.
+	Strikes      int      `json:"strikes"`
+	StrikeWindow Duration `json:"strike_window"`
+	BanDuration  Duration `json:"ban_duration"`
+}
+
+// Limits protect the server from being overwhelmed. Zero disables a limit.
+type Limits struct {
+	// MaxSessions is the number of sessions allowed at the same time.
+	MaxSessions int `json:"max_sessions"`
+	// MaxSessionsPerIP caps concurrent sessions from one address.
+	MaxSessionsPerIP int `json:"max_sessions_per_ip"`
+	// RatePerIP is how many new sessions one address may open per RateWindow.
+	RatePerIP  int      `json:"rate_per_ip"`
+	RateWindow Duration `json:"rate_window"`
+	// InputIdleTimeout ends sessions that haven't sent a keystroke in this
+	// long, even while a screen is still animating.
+	InputIdleTimeout Duration `json:"input_idle_timeout"`
+}
+
+type GitHub struct {
+	// CacheTTL is how long GitHub responses are reused. Zero disables the
+	// cache.
+	CacheTTL Duration `json:"cache_ttl"`
+}
+
+type Analytics struct {
+	// Enabled records every SSH session to Path.
+	Enabled bool `json:"enabled"`
+	// Path is an append-only JSONL file, defaults to analytics.jsonl in the
+	// data directory.
+	Path string `json:"path"`
+}
+
+type Guestbook struct {
+	// Enabled shows the guestbook screen.
+	Enabled bool `json:"enabled"`
+	// Path is the JSON file holding entries, defaults to guestbook.json in
+	// the data directory.
+	Path string `json:"path"`
+}
+
+type Contact struct {
+	// Enabled adds a message form to the contact screen.
+	Enabled bool `json:"enabled"`
+	// Inbox is a JSONL file messages are appended to, defaults to
+	// inbox.jsonl in the data directory.
+	Inbox string `json:"inbox"`
+	// PerKey and PerIP are how many messages one SSH key or one address may
+	// send per Window. Zero disables a limit.
+	PerKey int      `json:"per_key"`
+	PerIP  int      `json:"per_ip"`
+	Window Duration `json:"window"`
+	// SMTP relays messages to the owner by email as well.
+	SMTP SMTP `json:"smtp"`
+}
+
+type SMTP struct {
+	// Address is the relay's host:port. Empty keeps messages in the inbox
+	// only.
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// From is the envelope and header sender, To is the owner's address.
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Recording struct {
+	// Enabled saves what each visitor's terminal was sent as an asciicast
+	// v2 file the owner can replay. The owner's own sessions aren't saved.
+	Enabled bool `json:"enabled"`
+	// Dir holds the recordings, defaults to recordings in the data
+	// directory.
+	Dir string `json:"dir"`
+	// MaxSize stops a recording once it reaches this many bytes. MaxTotal
+	// deletes the oldest recordings once together they pass it. Zero
+	// disables a cap.
+	MaxSize  int `json:"max_size"`
+	MaxTotal int `json:"max_total"`
+	// Retention deletes recordings older than this. Zero keeps them.
+	Retention Duration `json:"retention"`
+}
+
+type Owner struct {
+	// AuthorizedKeys lists the owner's public keys in authorized_keys
+	// format. Sessions with one of these keys see owner-only screens.
+	AuthorizedKeys string `json:"authorized_keys"`
+}
+
+type Config struct {
+	// DataDir holds generated state such as host keys.
+	DataDir string `json:"data_dir"`
+
+	SSH       SSH       `json:"ssh"`
+	Web       Web       `json:"web"`
+	Finger    Finger    `json:"finger"`
+	Gopher    Gopher    `json:"gopher"`
+	Gemini    Gemini    `json:"gemini"`
+	Metrics   Metrics   `json:"metrics"`
+	Proxy     Proxy     `json:"proxy"`
+	Access    Access    `json:"access"`
+	Limits    Limits    `json:"limits"`
+	GitHub    GitHub    `json:"github"`
+	Analytics Analytics `json:"analytics"`
+	Guestbook Guestbook `json:"guestbook"`
+	Contact   Contact   `json:"contact"`
+	Recording Recording `json:"recording"`
+	Owner     Owner     `json:"owner"`
+}
+
+// DefaultDataDir is ~/.config/clifolio, or ./.clifolio if there's no home.
+func DefaultDataDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".clifolio"
+	}
+	return filepath.Join(dir, "clifolio")
+}
+
+func Default() Config {
+	return Config{
+		DataDir: DefaultDataDir(),
+		SSH: SSH{
+			Address:            "0.0.0.0:23234",
+			IdleTimeout:        Duration(10 * time.Minute),
+			MaxSessionDuration: Duration(time.Hour),
+			ShutdownGrace:      Duration(30 * time.Second),
+		},
+		Web: Web{
+			Address: "0.0.0.0:8080",
+		},
+		Gopher: Gopher{
+			Address: "0.0.0.0:70",
+		},
+		Gemini: Gemini{
+			Address: "0.0.0.0:1965",
+		},
+		Access: Access{
+			Strikes:      10,
+			StrikeWindow: Duration(10 * time.Minute),
+			BanDuration:  Duration(time.Hour),
+		},
+		Limits: Limits{
+			MaxSessions:      50,
+			MaxSessionsPerIP: 3,
+			RatePerIP:        10,
+			RateWindow:       Duration(time.Minute),
+			InputIdleTimeout: Duration(10 * time.Minute),
+		},
+		GitHub: GitHub{
+			CacheTTL: Duration(15 * time.Minute),
+		},
+		Analytics: Analytics{
+			Enabled: true,
+		},
+		Guestbook: Guestbook{
+			Enabled: true,
+		},
+		Contact: Contact{
+			Enabled: true,
+			PerKey:  3,
+			PerIP:   5,
+			Window:  Duration(time.Hour),
+		},
+		Recording: Recording{
+			MaxSize:   2 << 20,
+			MaxTotal:  200 << 20,
+			Retention: Duration(7 * 24 * time.Hour),
+		},
+	}
+}
+
+// Load returns the defaults overlaid with the JSON file at path, if any, and
+// then with the environment.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("reading config: %w", err)
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.Getenv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv overrides settings from CLIFOLIO_* environment variables.
+func (c *Config) ApplyEnv(getenv func(string) string) error {
+	var errs []error
+
+	str := func(key string, dst *string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v := getenv(key); v != "" {
+			*dst = SplitList(v)
+		}
+	}
+	num := func(key string, dst *int) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				return
+			}
+			*dst = n
+		}
+	}
+	dur := func(key string, dst *Duration) {
+		if v := getenv(key); v != "" {
+			if err := dst.Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+
+	str("CLIFOLIO_DATA_DIR", &c.DataDir)
+	str("CLIFOLIO_SSH_ADDRESS", &c.SSH.Address)
+	list("CLIFOLIO_SSH_HOST_KEYS", &c.SSH.HostKeyPaths)
+	dur("CLIFOLIO_SSH_IDLE_TIMEOUT", &c.SSH.IdleTimeout)
+	dur("CLIFOLIO_SSH_MAX_SESSION", &c.SSH.MaxSessionDuration)
+	str("CLIFOLIO_SSH_BANNER", &c.SSH.Banner)
+	dur("CLIFOLIO_SHUTDOWN_GRACE", &c.SSH.ShutdownGrace)
+	str("CLIFOLIO_WEB_ADDRESS", &c.Web.Address)
+	list("CLIFOLIO_WEB_ALLOWED_ORIGINS", &c.Web.AllowedOrigins)
+	list("CLIFOLIO_WEB_API_ORIGINS", &c.Web.APIOrigins)
+	list("CLIFOLIO_TRUSTED_PROXIES", &c.Proxy.TrustedProxies)
+	list("CLIFOLIO_ACCESS_ALLOW", &c.Access.Allow)
+	list("CLIFOLIO_ACCESS_DENY", &c.Access.Deny)
+	list("CLIFOLIO_BANNED_KEYS", &c.Access.BannedKeys)
+	str("CLIFOLIO_BANS_PATH", &c.Access.Path)
+	num("CLIFOLIO_BAN_STRIKES", &c.Access.Strikes)
+	dur("CLIFOLIO_BAN_STRIKE_WINDOW", &c.Access.StrikeWindow)
+	dur("CLIFOLIO_BAN_DURATION", &c.Access.BanDuration)
+	str("CLIFOLIO_FINGER_ADDRESS", &c.Finger.Address)
+	str("CLIFOLIO_FINGER_USER", &c.Finger.User)
+	str("CLIFOLIO_FINGER_AVAILABILITY", &c.Finger.Availability)
+	str("CLIFOLIO_METRICS_ADDRESS", &c.Metrics.Address)
+	str("CLIFOLIO_GOPHER_ADDRESS", &c.Gopher.Address)
+	str("CLIFOLIO_GOPHER_HOSTNAME", &c.Gopher.Hostname)
+	str("CLIFOLIO_GEMINI_ADDRESS", &c.Gemini.Address)
+	str("CLIFOLIO_GEMINI_HOSTNAME", &c.Gemini.Hostname)
+	str("CLIFOLIO_GEMINI_CERT", &c.Gemini.CertFile)
+	str("CLIFOLIO_GEMINI_KEY", &c.Gemini.KeyFile)
+	num("CLIFOLIO_MAX_SESSIONS", &c.Limits.MaxSessions)
+	num("CLIFOLIO_MAX_SESSIONS_PER_IP", &c.Limits.MaxSessionsPerIP)
+	num("CLIFOLIO_RATE_PER_IP", &c.Limits.RatePerIP)
+	dur("CLIFOLIO_RATE_WINDOW", &c.Limits.RateWindow)
+	dur("CLIFOLIO_INPUT_IDLE_TIMEOUT", &c.Limits.InputIdleTimeout)
+	dur("CLIFOLIO_GITHUB_CACHE_TTL", &c.GitHub.CacheTTL)
+	str("CLIFOLIO_ANALYTICS_PATH", &c.Analytics.Path)
+	str("CLIFOLIO_OWNER_KEYS", &c.Owner.AuthorizedKeys)
+	str("CLIFOLIO_GUESTBOOK_PATH", &c.Guestbook.Path)
+	str("CLIFOLIO_CONTACT_INBOX", &c.Contact.Inbox)
+	num("CLIFOLIO_CONTACT_PER_KEY", &c.Contact.PerKey)
+	num("CLIFOLIO_CONTACT_PER_IP", &c.Contact.PerIP)
+	dur("CLIFOLIO_CONTACT_WINDOW", &c.Contact.Window)
+	str("CLIFOLIO_SMTP_ADDRESS", &c.Contact.SMTP.Address)
+	str("CLIFOLIO_SMTP_USERNAME", &c.Contact.SMTP.Username)
+	str("CLIFOLIO_SMTP_PASSWORD", &c.Contact.SMTP.Password)
+	str("CLIFOLIO_SMTP_FROM", &c.Contact.SMTP.From)
+	str("CLIFOLIO_SMTP_TO", &c.Contact.SMTP.To)
+	str("CLIFOLIO_RECORDING_DIR", &c.Recording.Dir)
+	num("CLIFOLIO_RECORDING_MAX_SIZE", &c.Recording.MaxSize)
+	num("CLIFOLIO_RECORDING_MAX_TOTAL", &c.Recording.MaxTotal)
+	dur("CLIFOLIO_RECORDING_RETENTION", &c.Recording.Retention)
+	if v := getenv("CLIFOLIO_ANALYTICS"); v != "" {
+		c.Analytics.Enabled = v != "0" && v != "false"
+	}
+	if v := getenv("CLIFOLIO_GUESTBOOK"); v != "" {
+		c.Guestbook.Enabled = v != "0" && v != "false"
+	}
+	if v := getenv("CLIFOLIO_CONTACT"); v != "" {
+		c.Contact.Enabled = v != "0" && v != "false"
+	}
+	if v := getenv("CLIFOLIO_RECORDING"); v != "" {
+		c.Recording.Enabled = v != "0" && v != "false"
+	}
+
+	return errors.Join(errs...)
+}
+
+// HostKeys returns the configured host key paths, or the default key inside
+// the data directory.
+func (c Config) HostKeys() []string {
+	if len(c.SSH.HostKeyPaths) > 0 {
+		return c.SSH.HostKeyPaths
+	}
+	return []string{filepath.Join(c.DataDir, "ssh_host_ed25519_key")}
+}
+
+// AnalyticsPath is where session records are appended.
+func (c Config) AnalyticsPath() string {
+	if c.Analytics.Path != "" {
+		return c.Analytics.Path
+	}
+	return filepath.Join(c.DataDir, "analytics.jsonl")
+}
+
+// GuestbookPath is where guestbook entries are kept.
+func (c Config) GuestbookPath() string {
+	if c.Guestbook.Path != "" {
+		return c.Guestbook.Path
+	}
+	return filepath.Join(c.DataDir, "guestbook.json")
+}
+
+// BansPath is where bans are kept.
+func (c Config) BansPath() string {
+	if c.Access.Path != "" {
+		return c.Access.Path
+	}
+	return filepath.Join(c.DataDir, "bans.json")
+}
+
+// ContactInboxPath is where contact form messages are appended.
+func (c Config) ContactInboxPath() string {
+	if c.Contact.Inbox != "" {
+		return c.Contact.Inbox
+	}
+	return filepath.Join(c.DataDir, "inbox.jsonl")
+}
+
+// RecordingDir is where session recordings are kept.
+func (c Config) RecordingDir() string {
+	if c.Recording.Dir != "" {
+		return c.Recording.Dir
+	}
+	return filepath.Join(c.DataDir, "recordings")
+}
+
+// GeminiCert returns the Gemini certificate and key paths, by default
+// inside the data directory.
+func (c Config) GeminiCert() (cert, key string) {
+	cert, key = c.Gemini.CertFile, c.Gemini.KeyFile
+	if cert == "" {
+		cert = filepath.Join(c.DataDir, "gemini_cert.pem")
+	}
+	if key == "" {
+		key = filepath.Join(c.DataDir, "gemini_key.pem")
+	}
+	return cert, key
+}
+
+// OwnerKeysPath is the authorized_keys file that identifies the owner.
+func (c Config) OwnerKeysPath() string {
+	if c.Owner.AuthorizedKeys != "" {
+		return c.Owner.AuthorizedKeys
+	}
+	return filepath.Join(c.DataDir, "owner_keys")
+}
+
+// SplitList reads a comma-separated list, trimming the entries and dropping
+// empty ones, for environment variables and flags alike.
+func SplitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clifolio/internal/config"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// maxStrikeAddrs caps how many addresses strikes are counted for at once.
+const maxStrikeAddrs = 10000
+
+var (
+	ErrAddressDenied = errors.New("connections from your address aren't accepted here")
+	ErrKeyBanned     = errors.New("this key has been banned from the realm")
+	ErrBanNotFound   = errors.New("ban not found")
+
+	errAccessDisabled = errors.New("access control isn't enabled")
+)
+
+// Ban turns away an address, a network or a key.
+type Ban struct {
+	// Target is an address, a CIDR or a key fingerprint like "SHA256:...".
+	Target  string    `json:"target"`
+	Reason  string    `json:"reason"`
+	Created time.Time `json:"created"`
+	// Expires is when the ban lifts, zero for one that lasts until the
+	// owner lifts it.
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// IsKey reports whether the ban is on a key rather than an address.
+func (b Ban) IsKey() bool {
+	return strings.HasPrefix(b.Target, "SHA256:")
+}
+
+func (b Ban) expired(now time.Time) bool {
+	return !b.Expires.IsZero() && !now.Before(b.Expires)
+}
+
+// matches reports whether the ban covers a client at ip with the key
+// fingerprint, which is empty for keyless clients.
+func (b Ban) matches(ip, fingerprint string) bool {
+	if b.IsKey() {
+		return fingerprint != "" && b.Target == fingerprint
+	}
+	nets, err := parseCIDRs([]string{b.Target})
+	if err != nil {
+		return false
+	}
+	return inNets(nets, ip)
+}
+
+// Access keeps the allow and deny lists and the bans, which it persists to
+// a JSON file so they survive restarts. Strikes towards automatic bans are
+// kept in memory only. A nil Access lets everyone in.
+type Access struct {
+	mu      sync.Mutex
+	path    string
+	cfg     config.Access
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	trusted []*net.IPNet
+	bans    []Ban
+	strikes map[string][]time.Time
+}
+
+// OpenAccess reads the bans at cfg.BansPath(), treating a missing file as
+// none.
+func OpenAccess(cfg config.Config) (*Access, error) {
+	path := cfg.BansPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	a := &Access{path: path, cfg: cfg.Access, strikes: map[string][]time.Time{}}
+	var err error
+	if a.allow, err = parseCIDRs(cfg.Access.Allow); err != nil {
+		return nil, fmt.Errorf("access allow list: %w", err)
+	}
+	if a.deny, err = parseCIDRs(cfg.Access.Deny); err != nil {
+		return nil, fmt.Errorf("access deny list: %w", err)
+	}
+	// A proxy's failed health checks mustn't ban everyone behind it
+	if a.trusted, err = parseCIDRs(cfg.Proxy.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &a.bans); err != nil {
+		return nil, fmt.Errorf("parsing bans %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// save writes the bans through a temporary file, dropping expired ones.
+// Callers hold the lock.
+func (a *Access) save() error {
+	now := time.Now()
+	a.bans = slices.DeleteFunc(a.bans, func(b Ban) bool { return b.expired(now) })
+	b, err := json.MarshalIndent(a.bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.path)
+}
+
+// Check says why a client at ip with the key fingerprint, empty for none,
+// may not connect, or returns nil if it may. The error is meant for them.
+func (a *Access) Check(ip, fingerprint string) error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if inNets(a.deny, ip) || (len(a.allow) > 0 && !inNets(a.allow, ip)) {
+		return ErrAddressDenied
+	}
+	if fingerprint != "" && slices.Contains(a.cfg.BannedKeys, fingerprint) {
+		return ErrKeyBanned
+	}
+	now := time.Now()
+	for _, b := range a.bans {
+		if b.expired(now) || !b.matches(ip, fingerprint) {
+			continue
+		}
+		switch {
+		case b.IsKey():
+			return ErrKeyBanned
+		case b.Expires.IsZero():
+			return errors.New("your address has been banned")
+		default:
+			left := b.Expires.Sub(now).Round(time.Minute)
+			return fmt.Errorf("your address is banned for another %s", shortDuration(max(left, time.Minute)))
+		}
+	}
+	return nil
+}
+
+// Strike counts an offence by ip towards an automatic ban, which it gets
+// once it has cfg.Strikes of them within the strike window.
+func (a *Access) Strike(ip, reason string) {
+	if a == nil || a.cfg.Strikes <= 0 || ip == "" || inNets(a.trusted, ip) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-a.cfg.StrikeWindow.Std())
+	a.pruneStrikes(cutoff)
+	times := slices.DeleteFunc(a.strikes[ip], func(t time.Time) bool { return t.Before(cutoff) })
+	times = append(times, now)
+	if len(times) < a.cfg.Strikes {
+		a.strikes[ip] = times
+		return
+	}
+	delete(a.strikes, ip)
+
+	d := a.cfg.BanDuration.Std()
+	log.Printf("Banning %s for %s: %s", ip, shortDuration(d), reason)
+	a.ban(Ban{Target: ip, Reason: reason, Created: now, Expires: expiry(now, d)})
+}
+
+// pruneStrikes forgets addresses whose last strike is older than cutoff, so
+// a scan from many addresses can't grow the map. At maxStrikeAddrs the ones
+// that struck longest ago go too. Callers hold the lock.
+func (a *Access) pruneStrikes(cutoff time.Time) {
+	for ip, ts := range a.strikes {
+		if len(ts) == 0 || ts[len(ts)-1].Before(cutoff) {
+			delete(a.strikes, ip)
+		}
+	}
+	if len(a.strikes) < maxStrikeAddrs {
+		return
+	}
+	// Make room for a tenth more, so a scan doesn't sort on every strike
+	ips := make([]string, 0, len(a.strikes))
+	for ip := range a.strikes {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		ti, tj := a.strikes[ips[i]], a.strikes[ips[j]]
+		return ti[len(ti)-1].Before(tj[len(tj)-1])
+	})
+	for _, ip := range ips[:len(ips)-maxStrikeAddrs*9/10] {
+		delete(a.strikes, ip)
+	}
+}
+
+// Refused strikes ip when the limiter turned it away for something it did
+// itself, rather than the server being full.
+func (a *Access) Refused(ip string, err error) {
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTooManyFromIP) {
+		a.Strike(ip, "over the connection limits")
+	}
+}
+
+// Ban turns away target, an address, a CIDR or a key fingerprint, for d, or
+// until it's lifted if d is zero.
+func (a *Access) Ban(target, reason string, d time.Duration) error {
+	if a == nil {
+		return errAccessDisabled
+	}
+	target = strings.TrimSpace(target)
+	if !strings.HasPrefix(target, "SHA256:") {
+		if _, err := parseCIDRs([]string{target}); err != nil {
+			return fmt.Errorf("%q isn't an address, CIDR or key fingerprint", target)
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	return a.ban(Ban{Target: target, Reason: reason, Created: now, Expires: expiry(now, d)})
+}
+
+// ban adds or replaces the ban on b.Target. Callers hold the lock.
+func (a *Access) ban(b Ban) error {
+	a.bans = slices.DeleteFunc(a.bans, func(old Ban) bool { return old.Target == b.Target })
+	a.bans = append(a.bans, b)
+	if err := a.save(); err != nil {
+		log.Printf("Saving bans: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Unban lifts the ban on target.
+func (a *Access) Unban(target string) error {
+	if a == nil {
+		return ErrBanNotFound
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := len(a.bans)
+	a.bans = slices.DeleteFunc(a.bans, func(b Ban) bool { return b.Target == target })
+	if len(a.bans) == n {
+		return ErrBanNotFound
+	}
+	delete(a.strikes, target)
+	return a.save()
+}
+
+// Bans returns the bans in force, newest first.
+func (a *Access) Bans() []Ban {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	var out []Ban
+	for _, b := range a.bans {
+		if !b.expired(now) {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Created.After(out[j].Created) })
+	return out
+}
+
+func expiry(now time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return now.Add(d)
+}
+
+func inNets(nets []*net.IPNet, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessMiddleware turns away sessions from denied or banned addresses and
+// keys with a word on why, before they count towards the limits. The
+// owner's keys are always let in, so a ban can't lock them out.
+func accessMiddleware(access *Access, ownerKeysPath string) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			ip := RemoteIP(s.RemoteAddr())
+			if owners, err := LoadKeySet(ownerKeysPath); err == nil && owners.Contains(s.PublicKey()) {
+				next(s)
+				return
+			}
+			if err := access.Check(ip, Fingerprint(s.PublicKey())); err != nil {
+				log.Printf("Refused session from %s: %v", ip, err)
+				wish.Fatalln(s, "Sorry, "+err.Error()+".")
+				return
+			}
+			next(s)
+		}
+	}
+}
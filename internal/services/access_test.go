@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+func openTestAccess(t *testing.T, access config.Access, trusted ...string) *Access {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+	cfg.Access = access
+	cfg.Proxy.TrustedProxies = trusted
+	a, err := OpenAccess(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestBanMatches(t *testing.T) {
+	tests := []struct {
+		target      string
+		ip          string
+		fingerprint string
+		want        bool
+	}{
+		{"192.0.2.0/24", "192.0.2.7", "", true},
+		{"192.0.2.0/24", "192.0.3.7", "", false},
+		{"192.0.2.1", "192.0.2.1", "", true},
+		{"192.0.2.1", "192.0.2.2", "", false},
+		{"2001:db8::/32", "2001:db8::1", "", true},
+		{"2001:db8::/32", "2001:db9::1", "", false},
+		{"192.0.2.0/24", "not an address", "", false},
+		{"not a target", "192.0.2.1", "", false},
+		{"SHA256:abc", "192.0.2.1", "SHA256:abc", true},
+		{"SHA256:abc", "192.0.2.1", "SHA256:abd", false},
+		{"SHA256:abc", "192.0.2.1", "", false},
+	}
+	for _, tt := range tests {
+		if got := (Ban{Target: tt.target}).matches(tt.ip, tt.fingerprint); got != tt.want {
+			t.Errorf("Ban{%q}.matches(%q, %q) = %v, want %v", tt.target, tt.ip, tt.fingerprint, got, tt.want)
+		}
+	}
+}
+
+func TestAccessCheck(t *testing.T) {
+	a := openTestAccess(t, config.Access{
+		Allow:      []string{"192.0.2.0/24", "198.51.100.0/24"},
+		Deny:       []string{"192.0.2.66"},
+		BannedKeys: []string{"SHA256:listed"},
+	})
+	for _, target := range []string{"198.51.100.0/28", "SHA256:banned"} {
+		if err := a.Ban(target, "test", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Ban("198.51.100.200", "test", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Ban("198.51.100.201", "test", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	a.bans[len(a.bans)-1].Expires = time.Now().Add(-time.Second)
+
+	tests := []struct {
+		name        string
+		ip          string
+		fingerprint string
+		want        error
+		banned      bool
+	}{
+		{"allowed", "192.0.2.1", "SHA256:ok", nil, false},
+		{"outside the allow list", "203.0.113.1", "", ErrAddressDenied, false},
+		{"denied inside the allow list", "192.0.2.66", "", ErrAddressDenied, false},
+		{"key in the config", "192.0.2.1", "SHA256:listed", ErrKeyBanned, false},
+		{"banned key", "192.0.2.1", "SHA256:banned", ErrKeyBanned, false},
+		{"banned key, keyless client", "192.0.2.1", "", nil, false},
+		{"banned network", "198.51.100.7", "", nil, true},
+		{"next to the banned network", "198.51.100.16", "", nil, false},
+		{"ban with time left", "198.51.100.200", "", nil, true},
+		{"expired ban", "198.51.100.201", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.Check(tt.ip, tt.fingerprint)
+			switch {
+			case tt.banned && err == nil:
+				t.Errorf("Check(%q) let a banned address in", tt.ip)
+			case !tt.banned && !errors.Is(err, tt.want):
+				t.Errorf("Check(%q, %q) = %v, want %v", tt.ip, tt.fingerprint, err, tt.want)
+			}
+		})
+	}
+
+	if err := (*Access)(nil).Check("203.0.113.1", ""); err != nil {
+		t.Errorf("a nil Access refused a client: %v", err)
+	}
+}
+
+func TestAccessStrikes(t *testing.T) {
+	a := openTestAccess(t, config.Access{
+		Strikes:      3,
+		StrikeWindow: config.Duration(time.Minute),
+		BanDuration:  config.Duration(time.Hour),
+	}, "10.0.0.0/8")
+
+	strike := func(ip string, n int) {
+		for range n {
+			a.Strike(ip, "test")
+		}
+	}
+
+	strike("192.0.2.1", 3)
+	if err := a.Check("192.0.2.1", ""); err == nil {
+		t.Error("three strikes didn't ban the address")
+	}
+	if _, ok := a.strikes["192.0.2.1"]; ok {
+		t.Error("the banned address still has strikes counted")
+	}
+
+	strike("10.1.2.3", 5)
+	if err := a.Check("10.1.2.3", ""); err != nil {
+		t.Errorf("a trusted proxy was banned: %v", err)
+	}
+
+	// Strikes older than the window don't count and are forgotten
+	strike("192.0.2.2", 2)
+	for i := range a.strikes["192.0.2.2"] {
+		a.strikes["192.0.2.2"][i] = a.strikes["192.0.2.2"][i].Add(-2 * time.Minute)
+	}
+	strike("192.0.2.3", 1)
+	if _, ok := a.strikes["192.0.2.2"]; ok {
+		t.Error("an address whose strikes aged out is still counted")
+	}
+	strike("192.0.2.2", 2)
+	if err := a.Check("192.0.2.2", ""); err != nil {
+		t.Errorf("strikes outside the window counted towards a ban: %v", err)
+	}
+
+	// Refused only strikes for what the client did itself
+	a.Refused("192.0.2.4", ErrServerFull)
+	a.Refused("192.0.2.4", ErrRestarting)
+	if n := len(a.strikes["192.0.2.4"]); n != 0 {
+		t.Errorf("a full server struck the client %d times", n)
+	}
+	a.Refused("192.0.2.4", ErrRateLimited)
+	if n := len(a.strikes["192.0.2.4"]); n != 1 {
+		t.Errorf("going over the rate struck %d times, want 1", n)
+	}
+}
+
+func TestAccessStrikeCap(t *testing.T) {
+	a := openTestAccess(t, config.Access{
+		Strikes:      3,
+		StrikeWindow: config.Duration(time.Hour),
+		BanDuration:  config.Duration(time.Hour),
+	})
+	// A scan from many addresses, the first of which struck longest ago
+	start := time.Now().Add(-time.Minute)
+	for i := range maxStrikeAddrs {
+		a.strikes[fmt.Sprint("scan", i)] = []time.Time{start.Add(time.Duration(i) * time.Millisecond)}
+	}
+
+	a.Strike("192.0.2.1", "test")
+	if n, want := len(a.strikes), maxStrikeAddrs*9/10+1; n != want {
+		t.Errorf("%d addresses counted after the cap, want %d", n, want)
+	}
+	if _, ok := a.strikes["scan0"]; ok {
+		t.Error("the address that struck longest ago is still counted")
+	}
+	if _, ok := a.strikes[fmt.Sprint("scan", maxStrikeAddrs-1)]; !ok {
+		t.Error("the address that struck last was dropped")
+	}
+	if _, ok := a.strikes["192.0.2.1"]; !ok {
+		t.Error("the new strike wasn't counted")
+	}
+}
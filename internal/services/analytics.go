@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clifolio/internal/state"
+)
+
+// ScreenVisit is one stop on a visitor's path through the app.
+type ScreenVisit struct {
+	Screen   string        `json:"screen"`
+	Duration time.Duration `json:"duration"`
+}
+
+// SessionRecord is what gets stored for every SSH session. Client IPs are
+// only kept as a salted hash.
+type SessionRecord struct {
+	Start          time.Time     `json:"start"`
+	IPHash         string        `json:"ip_hash"`
+	KeyFingerprint string        `json:"key_fingerprint,omitempty"`
+	Width          int           `json:"width"`
+	Height         int           `json:"height"`
+	Term           string        `json:"term"`
+	Duration       time.Duration `json:"duration"`
+	Screens        []ScreenVisit `json:"screens"`
+	Projects       []string      `json:"projects,omitempty"`
+	// Recording is the name of the session's asciicast, if it was recorded.
+	Recording string `json:"recording,omitempty"`
+}
+
+// Visitor identifies the person behind a session, preferring their key.
+func (r SessionRecord) Visitor() string {
+	if r.KeyFingerprint != "" {
+		return r.KeyFingerprint
+	}
+	return r.IPHash
+}
+
+// Analytics appends session records to a local JSONL file.
+type Analytics struct {
+	mu     sync.Mutex
+	path   string
+	salt   []byte
+	closed bool
+}
+
+// OpenAnalytics prepares the store at path. The salt used to hash client IPs
+// lives next to it and is created on first use.
+func OpenAnalytics(path string) (*Analytics, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	saltPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".salt"
+	salt, err := os.ReadFile(saltPath)
+	if errors.Is(err, os.ErrNotExist) {
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(saltPath, salt, 0o600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Analytics{path: path, salt: salt}, nil
+}
+
+// HashIP returns a stable, non-reversible identifier for a client address.
+func (a *Analytics) HashIP(ip string) string {
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+func (a *Analytics) Record(rec SessionRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return os.ErrClosed
+	}
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+// Close waits for a record being written to finish and turns away any after
+// it, so exiting can't leave a line cut short.
+func (a *Analytics) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	return nil
+}
+
+// Load reads every stored record. Lines that fail to parse, such as a line
+// cut short by a crash, are skipped.
+func (a *Analytics) Load() ([]SessionRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f, err := os.Open(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []SessionRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var rec SessionRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err == nil {
+			out = append(out, rec)
+		}
+	}
+	return out, sc.Err()
+}
+
+// SessionTracker follows a single session and writes its record when the
+// session ends. A nil tracker ignores every call, so local runs and tests
+// don't need one.
+type SessionTracker struct {
+	mu        sync.Mutex
+	store     *Analytics
+	rec       SessionRecord
+	current   state.Screen
+	enteredAt time.Time
+	finished  bool
+}
+
+func (a *Analytics) NewTracker(ip, fingerprint, term string, width, height int) *SessionTracker {
+	now := time.Now()
+	return &SessionTracker{
+		store: a,
+		rec: SessionRecord{
+			Start:          now,
+			IPHash:         a.HashIP(ip),
+			KeyFingerprint: fingerprint,
+			Width:          width,
+			Height:         height,
+			Term:           term,
+		},
+		current:   state.ScreenIntro,
+		enteredAt: now,
+	}
+}
+
+func (t *SessionTracker) closeVisit(now time.Time) {
+	t.rec.Screens = append(t.rec.Screens, ScreenVisit{
+		Screen:   t.current.String(),
+		Duration: now.Sub(t.enteredAt).Round(time.Millisecond),
+	})
+}
+
+// Visit records that the visitor moved to screen.
+func (t *SessionTracker) Visit(screen state.Screen) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished || screen == t.current {
+		return
+	}
+	now := time.Now()
+	t.closeVisit(now)
+	t.current = screen
+	t.enteredAt = now
+}
+
+// OpenProject records that the visitor opened a project's details.
+func (t *SessionTracker) OpenProject(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rec.Projects = append(t.rec.Projects, name)
+}
+
+// SetRecording links the session's recording to its record.
+func (t *SessionTracker) SetRecording(rec *Recording) {
+	if t == nil || rec == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rec.Recording = rec.Name
+}
+
+// Finish closes the record and stores it. Calling it twice is harmless.
+func (t *SessionTracker) Finish() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	if t.finished {
+		t.mu.Unlock()
+		return nil
+	}
+	t.finished = true
+	now := time.Now()
+	t.closeVisit(now)
+	t.rec.Duration = now.Sub(t.rec.Start).Round(time.Millisecond)
+	rec := t.rec
+	t.mu.Unlock()
+
+	return t.store.Record(rec)
+}
+
+// NameCount is a label with a tally, used for the dashboard's top lists.
+type NameCount struct {
+	Name  string
+	Count int
+}
+
+type DayCount struct {
+	Day      time.Time
+	Sessions int
+	Visitors int
+}
+
+type AnalyticsSummary struct {
+	Sessions       int
+	Visitors       int
+	AverageSession time.Duration
+	PerDay         []DayCount
+	TopScreens     []NameCount
+	TopProjects    []NameCount
+}
+
+// Summarize aggregates records into the numbers the owner dashboard shows.
+// PerDay covers the last days days, oldest first, including empty days.
+func Summarize(records []SessionRecord, days int, now time.Time) AnalyticsSummary {
+	var sum AnalyticsSummary
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := today.AddDate(0, 0, -(days - 1))
+
+	perDay := make([]DayCount, days)
+	dayVisitors := make([]map[string]bool, days)
+	for i := range perDay {
+		perDay[i].Day = first.AddDate(0, 0, i)
+		dayVisitors[i] = map[string]bool{}
+	}
+
+	visitors := map[string]bool{}
+	screens := map[string]int{}
+	projects := map[string]int{}
+	var total time.Duration
+
+	for _, rec := range records {
+		sum.Sessions++
+		total += rec.Duration
+		visitors[rec.Visitor()] = true
+
+		if i := calendarDay(rec.Start.In(now.Location())) - calendarDay(first); i >= 0 && i < days {
+			perDay[i].Sessions++
+			dayVisitors[i][rec.Visitor()] = true
+		}
+
+		for _, v := range rec.Screens {
+			screens[v.Screen]++
+		}
+		for _, p := range rec.Projects {
+			projects[p]++
+		}
+	}
+
+	for i := range perDay {
+		perDay[i].Visitors = len(dayVisitors[i])
+	}
+
+	sum.Visitors = len(visitors)
+	sum.PerDay = perDay
+	sum.TopScreens = topCounts(screens)
+	sum.TopProjects = topCounts(projects)
+	if sum.Sessions > 0 {
+		sum.AverageSession = (total / time.Duration(sum.Sessions)).Round(time.Second)
+	}
+
+	return sum
+}
+
+// calendarDay numbers t's date in its own location, counting whole days
+// whether or not a DST change made one of them 23 or 25 hours long.
+func calendarDay(t time.Time) int {
+	return int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func topCounts(m map[string]int) []NameCount {
+	out := make([]NameCount, 0, len(m))
+	for name, n := range m {
+		out = append(out, NameCount{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestCalendarDay(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2024, month, day, hour, min, 0, 0, ny)
+	}
+
+	tests := []struct {
+		name string
+		a, b time.Time
+		days int
+	}{
+		{"same day", at(time.June, 1, 0, 0), at(time.June, 1, 23, 59), 0},
+		{"a minute apart across midnight", at(time.June, 1, 23, 59), at(time.June, 2, 0, 0), 1},
+		{"within the 23 hour day", at(time.March, 10, 0, 30), at(time.March, 10, 23, 30), 0},
+		{"across the 23 hour day", at(time.March, 10, 0, 0), at(time.March, 11, 0, 0), 1},
+		{"within the 25 hour day", at(time.November, 3, 0, 0), at(time.November, 3, 23, 30), 0},
+		{"across the 25 hour day", at(time.November, 3, 0, 0), at(time.November, 4, 0, 0), 1},
+		{"a week over a DST change", at(time.March, 5, 23, 0), at(time.March, 12, 23, 0), 7},
+		{"across a year", time.Date(2023, time.December, 31, 23, 0, 0, 0, ny), at(time.January, 1, 1, 0), 1},
+	}
+	for _, tt := range tests {
+		if got := calendarDay(tt.b) - calendarDay(tt.a); got != tt.days {
+			t.Errorf("%s: %v to %v is %d days, want %d", tt.name, tt.a, tt.b, got, tt.days)
+		}
+	}
+}
+
+func TestSummarizePerDayAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The week leading up to now has the spring change in it
+	now := time.Date(2024, time.March, 12, 12, 0, 0, 0, ny)
+	var records []SessionRecord
+	for day := 6; day <= 12; day++ {
+		// Late in the evening, where a day of 24 fixed hours goes wrong
+		start := time.Date(2024, time.March, day, 23, 30, 0, 0, ny).UTC()
+		if day == 12 {
+			start = time.Date(2024, time.March, day, 0, 30, 0, 0, ny).UTC()
+		}
+		records = append(records, SessionRecord{Start: start, IPHash: "visitor"})
+	}
+
+	sum := Summarize(records, 7, now)
+	for i, d := range sum.PerDay {
+		if want := time.Date(2024, time.March, 6+i, 0, 0, 0, 0, ny); !d.Day.Equal(want) {
+			t.Errorf("PerDay[%d].Day = %v, want %v", i, d.Day, want)
+		}
+		if d.Sessions != 1 || d.Visitors != 1 {
+			t.Errorf("PerDay[%d] (%s) = %d sessions, %d visitors, want 1 each", i, d.Day.Format("Jan 2"), d.Sessions, d.Visitors)
+		}
+	}
+	if sum.Sessions != 7 || sum.Visitors != 1 {
+		t.Errorf("Summarize() = %d sessions, %d visitors", sum.Sessions, sum.Visitors)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// serveAPI answers /api/<name> with the JSON the SSH portfolio is built
+// from. GitHub data comes through the same cache the sessions use, so the
+// API adds no calls of its own: at most one per cache TTL, however many
+// sites ask.
+func (o WebServerOptions) serveAPI(w http.ResponseWriter, r *http.Request, name string, origins []string) {
+	allowAPIOrigin(w, r, origins)
+	if o.Data == nil || !slices.Contains(o.APIs, name) {
+		writeAPIError(w, http.StatusNotFound, "no such resource")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	data, err := o.Data(ctx, name)
+	if err != nil {
+		log.Printf("API /%s: %v", name, err)
+		writeAPIError(w, http.StatusBadGateway, "couldn't fetch this right now, please try again later")
+		return
+	}
+	writeAPIJSON(w, r, data)
+}
+
+// serveAPIIndex lists the resources, as links a client can follow.
+func (o WebServerOptions) serveAPIIndex(w http.ResponseWriter, r *http.Request, origins []string) {
+	allowAPIOrigin(w, r, origins)
+	index := map[string]string{}
+	for _, name := range o.APIs {
+		index[name] = "/api/" + name
+	}
+	writeAPIJSON(w, r, index)
+}
+
+// writeAPIJSON sends v with an ETag, answering 304 when the client already
+// has it. Clients are asked to revalidate every time, which costs them a
+// round trip but never stale data.
+func writeAPIJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. The
+// comparison is weak, as RFC 9110 asks for GET.
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// allowAPIOrigin lets the pages of the configured sites read the response.
+// Requests from anywhere else still get it, but their browsers hide it.
+func allowAPIOrigin(w http.ResponseWriter, r *http.Request, origins []string) {
+	w.Header().Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	switch {
+	case origin == "":
+		return
+	case slices.Contains(origins, "*"):
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	case slices.Contains(origins, origin):
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	default:
+		return
+	}
+	w.Header().Set("Access-Control-Expose-Headers", "ETag")
+}
+
+// serveAPIPreflight answers browsers asking whether a site may send
+// If-None-Match, which fetch() does when revalidating.
+func serveAPIPreflight(w http.ResponseWriter, r *http.Request, origins []string) {
+	allowAPIOrigin(w, r, origins)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "If-None-Match")
+		w.Header().Set("Access-Control-Max-Age", "86400")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
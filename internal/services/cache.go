@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Every repository file a visitor opens is cached, so the cache is bounded:
+// entries are kept for staleFor past their TTL to fall back on when GitHub
+// fails, and beyond maxCacheEntries or maxCacheBytes the least recently used
+// go first.
+const (
+	staleFor        = 24 * time.Hour
+	maxCacheEntries = 2000
+	maxCacheBytes   = 32 << 20
+)
+
+// fetchTimeout bounds a shared fetch, which doesn't end with the caller that
+// started it.
+const fetchTimeout = 30 * time.Second
+
+// GitHubCache remembers GitHub responses so a busy server doesn't spend its
+// API rate limit fetching the same repositories for every visitor.
+type GitHubCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[string]cacheEntry
+	inflight map[string]*cacheCall
+	// bytes is the estimated size of the cached values
+	bytes int
+}
+
+type cacheEntry struct {
+	value   any
+	size    int
+	fetched time.Time
+	used    time.Time
+	// expired entries are refetched, and only served if that fails
+	expired bool
+}
+
+// cacheCall lets concurrent sessions asking for the same key share one
+// request instead of each hitting the API.
+type cacheCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// CacheStats describes what the cache currently holds.
+type CacheStats struct {
+	Entries int
+	Oldest  time.Time
+	Newest  time.Time
+	TTL     time.Duration
+}
+
+func NewGitHubCache(ttl time.Duration) *GitHubCache {
+	return &GitHubCache{
+		ttl:      ttl,
+		entries:  map[string]cacheEntry{},
+		inflight: map[string]*cacheCall{},
+	}
+}
+
+// githubCache backs the Fetch functions. Its TTL is set from the config at
+// startup with SetGitHubCacheTTL.
+var githubCache = NewGitHubCache(15 * time.Minute)
+
+// SetGitHubCacheTTL changes how long GitHub responses are reused. Zero
+// disables caching.
+func SetGitHubCacheTTL(ttl time.Duration) {
+	githubCache.mu.Lock()
+	defer githubCache.mu.Unlock()
+	githubCache.ttl = ttl
+}
+
+// RefreshGitHubCache expires every cached response, so the next visitor sees
+// fresh data from GitHub. The old responses are still there to fall back on
+// if GitHub fails.
+func RefreshGitHubCache() {
+	githubCache.Expire("")
+}
+
+func GitHubCacheStats() CacheStats {
+	return githubCache.Stats()
+}
+
+// Invalidate drops entries whose key starts with prefix, all of them for "".
+func (c *GitHubCache) Invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.drop(key)
+		}
+	}
+}
+
+// Expire marks entries whose key starts with prefix, all of them for "", as
+// out of date. Unlike Invalidate, they're kept as the fallback for a failed
+// refetch.
+func (c *GitHubCache) Expire(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			e.expired = true
+			c.entries[key] = e
+		}
+	}
+}
+
+// drop removes key and its size from the total. Callers hold the lock.
+func (c *GitHubCache) drop(key string) {
+	c.bytes -= c.entries[key].size
+	delete(c.entries, key)
+}
+
+func (c *GitHubCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{Entries: len(c.entries), TTL: c.ttl}
+	for _, e := range c.entries {
+		if stats.Oldest.IsZero() || e.fetched.Before(stats.Oldest) {
+			stats.Oldest = e.fetched
+		}
+		if e.fetched.After(stats.Newest) {
+			stats.Newest = e.fetched
+		}
+	}
+	return stats
+}
+
+// get returns the cached value for key, and whether it is still fresh.
+func (c *GitHubCache) get(key string) (any, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	e.used = time.Now()
+	c.entries[key] = e
+	return e.value, true, !e.expired && time.Since(e.fetched) < c.ttl
+}
+
+// evict drops entries too old to fall back on, then the least recently used
+// ones while there are more than maxCacheEntries or they take more than
+// maxCacheBytes. Callers hold the lock.
+func (c *GitHubCache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if now.Sub(e.fetched) > c.ttl+staleFor {
+			c.drop(key)
+		}
+	}
+	if len(c.entries) <= maxCacheEntries && c.bytes <= maxCacheBytes {
+		return
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].used.Before(c.entries[keys[j]].used) })
+	for _, key := range keys {
+		if len(c.entries) <= maxCacheEntries && c.bytes <= maxCacheBytes {
+			break
+		}
+		c.drop(key)
+	}
+}
+
+// cacheSize estimates how much memory a cached value holds. Only the text
+// really matters, a file or README is far bigger than the structs around it.
+func cacheSize(key string, value any) int {
+	size := len(key) + 64
+	switch v := value.(type) {
+	case string:
+		size += len(v)
+	case RepoFile:
+		size += len(v.Path) + len(v.Content)
+	case []RepoEntry:
+		for _, e := range v {
+			size += len(e.Name) + len(e.Path) + len(e.Type) + 64
+		}
+	case []Repo:
+		for _, r := range v {
+			size += len(r.Owner) + len(r.Name) + len(r.Description) + len(r.Language) + len(r.HTMLURL) + 64
+		}
+	default:
+		size += 1024
+	}
+	return size
+}
+
+func (c *GitHubCache) do(ctx context.Context, key string, fetch func(context.Context) (any, error)) (any, error) {
+	value, found, fresh := c.get(key)
+	if fresh {
+		cacheHits.Add(1)
+		return value, nil
+	}
+	cacheMisses.Add(1)
+
+	c.mu.Lock()
+	call, waiting := c.inflight[key]
+	if !waiting {
+		call = &cacheCall{done: make(chan struct{})}
+		c.inflight[key] = call
+	}
+	c.mu.Unlock()
+
+	if !waiting {
+		// Whoever asked first may give up, the others still want the answer
+		go func() {
+			fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+			defer cancel()
+			call.value, call.err = fetch(fetchCtx)
+
+			c.mu.Lock()
+			delete(c.inflight, key)
+			if call.err == nil && c.ttl > 0 {
+				now := time.Now()
+				c.drop(key)
+				size := cacheSize(key, call.value)
+				c.entries[key] = cacheEntry{value: call.value, size: size, fetched: now, used: now}
+				c.bytes += size
+				c.evict(now)
+			}
+			c.mu.Unlock()
+			close(call.done)
+		}()
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		if found {
+			return value, nil
+		}
+		return nil, ctx.Err()
+	}
+
+	// A stale answer beats an error page when GitHub is down or the rate
+	// limit has run out
+	if call.err != nil && found {
+		return value, nil
+	}
+	return call.value, call.err
+}
+
+// cached runs fetch through the GitHub cache under key.
+func cached[T any](ctx context.Context, key string, fetch func(context.Context) (T, error)) (T, error) {
+	v, err := githubCache.do(ctx, key, func(ctx context.Context) (any, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	t, _ := v.(T)
+	return t, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fetchValue(v any) func(context.Context) (any, error) {
+	return func(context.Context) (any, error) { return v, nil }
+}
+
+// age moves key's fetch time back by d.
+func age(c *GitHubCache, key string, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[key]
+	e.fetched = e.fetched.Add(-d)
+	c.entries[key] = e
+}
+
+func TestCacheSharesFetch(t *testing.T) {
+	c := NewGitHubCache(time.Minute)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) (any, error) {
+		calls.Add(1)
+		<-release
+		return "repos", nil
+	}
+
+	var wg sync.WaitGroup
+	got := make([]any, 10)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = c.do(context.Background(), "repos", fetch)
+		}()
+	}
+	// Let every caller find the fetch in flight before it finishes
+	for {
+		c.mu.Lock()
+		_, inflight := c.inflight["repos"]
+		c.mu.Unlock()
+		if inflight {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("%d fetches for one key, want 1", n)
+	}
+	for i, v := range got {
+		if v != "repos" {
+			t.Errorf("caller %d got %v", i, v)
+		}
+	}
+	// And the answer is cached for whoever comes next
+	if v, _ := c.do(context.Background(), "repos", func(context.Context) (any, error) { return nil, errors.New("refetched") }); v != "repos" {
+		t.Errorf("cached value = %v", v)
+	}
+}
+
+func TestCacheStaleFallback(t *testing.T) {
+	down := func(context.Context) (any, error) { return nil, errors.New("github is down") }
+	slow := func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name    string
+		expire  func(c *GitHubCache)
+		fetch   func(context.Context) (any, error)
+		timeout time.Duration
+		want    any
+		err     bool
+	}{
+		{"fresh", func(*GitHubCache) {}, down, 0, "old", false},
+		{"past the TTL, refetched", func(c *GitHubCache) { age(c, "k", 2*time.Minute) }, fetchValue("new"), 0, "new", false},
+		{"past the TTL, GitHub down", func(c *GitHubCache) { age(c, "k", 2*time.Minute) }, down, 0, "old", false},
+		{"past the TTL, caller gives up", func(c *GitHubCache) { age(c, "k", 2*time.Minute) }, slow, 20 * time.Millisecond, "old", false},
+		{"expired, refetched", func(c *GitHubCache) { c.Expire("") }, fetchValue("new"), 0, "new", false},
+		{"expired, GitHub down", func(c *GitHubCache) { c.Expire("") }, down, 0, "old", false},
+		{"expired by prefix", func(c *GitHubCache) { c.Expire("k") }, fetchValue("new"), 0, "new", false},
+		{"other prefix expired", func(c *GitHubCache) { c.Expire("other") }, fetchValue("new"), 0, "old", false},
+		{"invalidated, GitHub down", func(c *GitHubCache) { c.Invalidate("") }, down, 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewGitHubCache(time.Minute)
+			if _, err := c.do(context.Background(), "k", fetchValue("old")); err != nil {
+				t.Fatal(err)
+			}
+			tt.expire(c)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			got, err := c.do(ctx, "k", tt.fetch)
+			if (err != nil) != tt.err || got != tt.want {
+				t.Errorf("do() = %v, %v, want %v, error %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := NewGitHubCache(time.Minute)
+	ctx := context.Background()
+	checkBytes := func() {
+		t.Helper()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		total := 0
+		for _, e := range c.entries {
+			total += e.size
+		}
+		if c.bytes != total {
+			t.Errorf("the cache counts %d bytes, its entries hold %d", c.bytes, total)
+		}
+		if c.bytes > maxCacheBytes {
+			t.Errorf("the cache holds %d bytes, over %d", c.bytes, maxCacheBytes)
+		}
+	}
+
+	// Big files are evicted by size, least recently used first
+	file := strings.Repeat("x", 1<<20)
+	_, _ = c.do(ctx, "kept", fetchValue(file))
+	for i := range (2 * maxCacheBytes) >> 20 {
+		_, _ = c.do(ctx, fmt.Sprint("file", i), fetchValue(file))
+		if _, found, _ := c.get("kept"); !found {
+			t.Fatalf("the most recently used entry was evicted after %d files", i)
+		}
+	}
+	checkBytes()
+	if _, found, _ := c.get("file0"); found {
+		t.Error("the least recently used file is still cached")
+	}
+
+	// Many small entries are evicted by count
+	c.Invalidate("")
+	for i := range maxCacheEntries + 10 {
+		_, _ = c.do(ctx, fmt.Sprint("small", i), fetchValue("v"))
+	}
+	if n := c.Stats().Entries; n != maxCacheEntries {
+		t.Errorf("%d entries cached, want %d", n, maxCacheEntries)
+	}
+	checkBytes()
+
+	// Entries too old to fall back on go whatever the room
+	c.Invalidate("")
+	_, _ = c.do(ctx, "ancient", fetchValue("v"))
+	age(c, "ancient", time.Minute+staleFor+time.Second)
+	_, _ = c.do(ctx, "new", fetchValue("v"))
+	if _, found, _ := c.get("ancient"); found {
+		t.Error("an entry past the stale window is still cached")
+	}
+	checkBytes()
+}
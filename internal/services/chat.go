@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ChatHistorySize is how many lines late joiners get to scroll back.
+	ChatHistorySize = 100
+
+	// MaxChatMessage is the longest line a visitor may say, in runes.
+	MaxChatMessage = 200
+
+	// A session may send chatBurst messages per chatBurstWindow.
+	chatBurst       = 5
+	chatBurstWindow = 10 * time.Second
+
+	// DefaultMute is how long /mute silences someone without a duration.
+	DefaultMute = 10 * time.Minute
+)
+
+var (
+	ErrNickInvalid  = errors.New("nicknames are 2 to 16 letters, digits, - or _")
+	ErrNickTaken    = errors.New("that nickname is already taken")
+	ErrNotInChat    = errors.New("join the chat first")
+	ErrChatFlood    = errors.New("you're sending messages too fast, slow down")
+	ErrChatTooLong  = fmt.Errorf("chat messages are limited to %d characters", MaxChatMessage)
+	ErrMuted        = errors.New("you have been muted")
+	ErrNickNotFound = errors.New("no one in the chat goes by that name")
+	ErrNotOwner     = errors.New("only the owner can do that")
+)
+
+var nickPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{2,16}$`)
+
+// ChatMessage is a line in the chat lobby. System lines announce joins,
+// kicks and mutes and have no Nick.
+type ChatMessage struct {
+	Time   time.Time
+	Nick   string
+	Text   string
+	Owner  bool
+	System bool
+}
+
+// ChatMsg delivers a new chat line to every session's program.
+type ChatMsg ChatMessage
+
+var (
+	nickAdjectives = []string{"swift", "silent", "brave", "clever", "lucky", "iron", "amber", "frost",
+		"wild", "quiet", "bold", "sly", "stormy", "gentle", "crimson", "misty"}
+	nickNouns = []string{"falcon", "fox", "wolf", "otter", "raven", "tiger", "badger", "heron",
+		"lynx", "owl", "panda", "viper", "bison", "koala", "gecko", "crane"}
+)
+
+// DefaultNick derives a stable nickname from a key fingerprint, so returning
+// visitors keep their name. Keyless visitors pass their session ID instead.
+func DefaultNick(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return fmt.Sprintf("%s-%s%d", nickAdjectives[sum[0]%16], nickNouns[sum[1]%16], sum[2]%100)
+}
+
+// Nick returns the session's chat nickname, empty until it joins the chat.
+func (l *LiveSession) Nick() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.nick
+}
+
+// muteKey identifies a visitor across reconnects, by key when they have one.
+func (l *LiveSession) muteKey() string {
+	if l.Fingerprint != "" {
+		return l.Fingerprint
+	}
+	return "ip:" + l.IP
+}
+
+// allowMessage records a message attempt and reports whether it fits in the
+// flood limit.
+func (l *LiveSession) allowMessage(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.sent[:0]
+	for _, t := range l.sent {
+		if now.Sub(t) < chatBurstWindow {
+			recent = append(recent, t)
+		}
+	}
+	l.sent = recent
+	if len(l.sent) >= chatBurst {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
+
+// JoinChat gives l a nickname, or changes it, and tells the lobby.
+func (h *Hub) JoinChat(l *LiveSession, nick string) error {
+	nick = strings.TrimSpace(nick)
+	if !nickPattern.MatchString(nick) {
+		return ErrNickInvalid
+	}
+	// A rename is announced to everyone, so it's held to the same limits
+	// as talking
+	if old := l.Nick(); old != "" && old != nick {
+		if until, ok := h.mutedUntil(l); ok {
+			return mutedError(until)
+		}
+		if !l.allowMessage(time.Now()) {
+			return ErrChatFlood
+		}
+	}
+
+	// Checked and taken under the hub's lock, so two sessions can't both
+	// claim a free nickname
+	h.mu.Lock()
+	for _, other := range h.sessions {
+		if other != l && strings.EqualFold(other.Nick(), nick) {
+			h.mu.Unlock()
+			return ErrNickTaken
+		}
+	}
+	l.mu.Lock()
+	old := l.nick
+	l.nick = nick
+	l.mu.Unlock()
+	h.mu.Unlock()
+
+	switch {
+	case old == "":
+		h.post(ChatMessage{Text: nick + " joined the chat", System: true})
+	case old != nick:
+		h.post(ChatMessage{Text: old + " is now known as " + nick, System: true})
+	}
+	return nil
+}
+
+// leaveChat tells the lobby that l disconnected, if it had joined.
+func (h *Hub) leaveChat(l *LiveSession) {
+	if nick := l.Nick(); nick != "" {
+		h.post(ChatMessage{Text: nick + " left the chat", System: true})
+	}
+}
+
+// Say posts text to the lobby as l.
+func (h *Hub) Say(l *LiveSession, text string) error {
+	nick := l.Nick()
+	if nick == "" {
+		return ErrNotInChat
+	}
+	if until, ok := h.mutedUntil(l); ok {
+		return mutedError(until)
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(text)) > MaxChatMessage {
+		return ErrChatTooLong
+	}
+	text, err := FilterMessage(text)
+	if err != nil {
+		return err
+	}
+	if !l.allowMessage(time.Now()) {
+		return ErrChatFlood
+	}
+
+	h.post(ChatMessage{Nick: nick, Text: text, Owner: l.Owner})
+	return nil
+}
+
+// ChatHistory returns the most recent lines, oldest first.
+func (h *Hub) ChatHistory() []ChatMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]ChatMessage(nil), h.chat...)
+}
+
+// ChatNicks lists who has joined the chat.
+func (h *Hub) ChatNicks() []string {
+	var nicks []string
+	for _, l := range h.Sessions() {
+		if nick := l.Nick(); nick != "" {
+			nicks = append(nicks, nick)
+		}
+	}
+	return nicks
+}
+
+// Mute stops nick from talking for d. Only the owner may mute, and a mute
+// outlives reconnecting.
+func (h *Hub) Mute(by *LiveSession, nick string, d time.Duration) error {
+	target, err := h.moderationTarget(by, nick)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	if d <= 0 {
+		delete(h.muted, target.muteKey())
+	} else {
+		h.muted[target.muteKey()] = time.Now().Add(d)
+	}
+	h.mu.Unlock()
+
+	if d <= 0 {
+		h.post(ChatMessage{Text: target.Nick() + " may speak again", System: true})
+	} else {
+		h.post(ChatMessage{Text: target.Nick() + " was muted for " + shortDuration(d), System: true})
+	}
+	return nil
+}
+
+// Kick disconnects nick. Only the owner may kick.
+func (h *Hub) Kick(by *LiveSession, nick string) error {
+	target, err := h.moderationTarget(by, nick)
+	if err != nil {
+		return err
+	}
+	h.post(ChatMessage{Text: target.Nick() + " was kicked by the owner", System: true})
+	h.Disconnect(target.ID)
+	return nil
+}
+
+func (h *Hub) moderationTarget(by *LiveSession, nick string) (*LiveSession, error) {
+	if by == nil || !by.Owner {
+		return nil, ErrNotOwner
+	}
+	target := h.findNick(nick)
+	if target == nil {
+		return nil, ErrNickNotFound
+	}
+	return target, nil
+}
+
+// findNick returns the session using nick, ignoring case.
+func (h *Hub) findNick(nick string) *LiveSession {
+	for _, l := range h.Sessions() {
+		if strings.EqualFold(l.Nick(), nick) {
+			return l
+		}
+	}
+	return nil
+}
+
+func (h *Hub) mutedUntil(l *LiveSession) (time.Time, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	until, ok := h.muted[l.muteKey()]
+	if ok && time.Now().After(until) {
+		delete(h.muted, l.muteKey())
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+// mutedError tells someone muted until then how long they have left.
+func mutedError(until time.Time) error {
+	return fmt.Errorf("%w for another %s", ErrMuted, shortDuration(time.Until(until).Round(time.Second)))
+}
+
+// post adds a line to the history and sends it to everyone. It's queued
+// under the lock, so every session gets the lines in the history's order.
+func (h *Hub) post(msg ChatMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msg.Time = time.Now()
+	h.chat = append(h.chat, msg)
+	if len(h.chat) > ChatHistorySize {
+		h.chat = append([]ChatMessage(nil), h.chat[len(h.chat)-ChatHistorySize:]...)
+	}
+	for _, l := range h.sessions {
+		l.send(ChatMsg(msg))
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+var ErrNoClipboard = errors.New("no clipboard available")
+
+// Clipboard puts text on the clipboard of whoever is at the keyboard.
+type Clipboard interface {
+	Copy(text string) error
+	// Remote reports whether the copy is handed to the visitor's terminal,
+	// which doesn't say whether it arrived.
+	Remote() bool
+}
+
+// NativeClipboard is the clipboard of the machine running clifolio, which
+// is only the visitor's when they run it locally.
+type NativeClipboard struct{}
+
+func (NativeClipboard) Copy(text string) error {
+	if clipboard.Unsupported {
+		return ErrNoClipboard
+	}
+	return clipboard.WriteAll(text)
+}
+
+func (NativeClipboard) Remote() bool { return false }
+
+// SyncWriter passes each Write on to the writer it wraps whole, one at a
+// time. A program's frames and escape sequences sent from elsewhere, like
+// OSC 52, go through the same one so they can't interleave.
+type SyncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewSyncWriter(w io.Writer) *SyncWriter {
+	return &SyncWriter{w: w}
+}
+
+func (s *SyncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// OSC52Clipboard asks the terminal on the other end of W to copy text with
+// an OSC 52 escape sequence. Term is that terminal's TERM, used to wrap the
+// sequence so tmux and screen pass it on. When a program is drawing to the
+// same terminal, W must be the SyncWriter it draws through.
+type OSC52Clipboard struct {
+	W    io.Writer
+	Term string
+}
+
+func (c OSC52Clipboard) Copy(text string) error {
+	seq := osc52.New(text)
+	switch {
+	case strings.HasPrefix(c.Term, "tmux"):
+		seq = seq.Tmux()
+	case strings.HasPrefix(c.Term, "screen"):
+		seq = seq.Screen()
+	}
+	// In one Write, so it stays in one piece
+	_, err := io.WriteString(c.W, seq.String())
+	return err
+}
+
+func (OSC52Clipboard) Remote() bool { return true }
+
+// LocalClipboard picks the clipboard for a local run. Inside an SSH login
+// the native clipboard belongs to the server, so the copy goes through the
+// terminal instead. It returns nil when there's no clipboard to use.
+func LocalClipboard() Clipboard {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return OSC52Clipboard{W: os.Stdout, Term: os.Getenv("TERM")}
+	}
+	if clipboard.Unsupported {
+		return nil
+	}
+	return NativeClipboard{}
+}
@@ -1,14 +1,14 @@
 package services
 
 type ProfileData struct {
-	Name 		string
-	Title		string
-	Bio 		string
-	Location	string
-	Website		string
-	Email		string
-	GitHub 		string
-	LinkedIn 	string
+	Name 		string	`json:"name"`
+	Title		string	`json:"title"`
+	Bio 		string	`json:"bio"`
+	Location	string	`json:"location"`
+	Website		string	`json:"website"`
+	Email		string	`json:"email"`
+	GitHub 		string	`json:"github"`
+	LinkedIn 	string	`json:"linkedin"`
 }
 
 func GetProfileData() ProfileData {
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/pkg/sftp"
+)
+
+// GeneratedFS is a flat, read-only filesystem whose files are generated
+// each time they are opened, so downloads always match what the TUI shows.
+type GeneratedFS map[string]func() ([]byte, error)
+
+func (g GeneratedFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &generatedDir{fsys: g}, nil
+	}
+
+	generate, ok := g[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data, err := generate()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &generatedFile{
+		Reader: bytes.NewReader(data),
+		info:   generatedInfo{name: name, size: int64(len(data)), mode: 0o444, modTime: time.Now()},
+	}, nil
+}
+
+type generatedInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i generatedInfo) Name() string       { return i.name }
+func (i generatedInfo) Size() int64        { return i.size }
+func (i generatedInfo) Mode() fs.FileMode  { return i.mode }
+func (i generatedInfo) ModTime() time.Time { return i.modTime }
+func (i generatedInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i generatedInfo) Sys() any           { return nil }
+
+type generatedFile struct {
+	*bytes.Reader
+	info generatedInfo
+}
+
+func (f *generatedFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *generatedFile) Close() error               { return nil }
+
+type generatedDir struct {
+	fsys    GeneratedFS
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *generatedDir) Stat() (fs.FileInfo, error) {
+	return generatedInfo{name: ".", mode: fs.ModeDir | 0o555, modTime: time.Now()}, nil
+}
+
+func (d *generatedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *generatedDir) Close() error { return nil }
+
+func (d *generatedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		names := make([]string, 0, len(d.fsys))
+		for name := range d.fsys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			info, err := fs.Stat(d.fsys, name)
+			if err != nil {
+				// A file that fails to generate is left out of the listing
+				// rather than breaking it
+				continue
+			}
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(info))
+		}
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// sftpPath turns a client's path, which may be absolute or contain "..",
+// into a name in fsys.
+func sftpPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+// sftpHandler serves fsys over SFTP. Every request that would change
+// anything is refused.
+type sftpHandler struct{ fsys fs.FS }
+
+func (h sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	data, err := fs.ReadFile(h.fsys, sftpPath(r.Filepath))
+	if err != nil {
+		return nil, sftp.ErrSSHFxNoSuchFile
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (h sftpHandler) Filewrite(*sftp.Request) (io.WriterAt, error) {
+	return nil, sftp.ErrSSHFxPermissionDenied
+}
+
+func (h sftpHandler) Filecmd(*sftp.Request) error {
+	return sftp.ErrSSHFxPermissionDenied
+}
+
+func (h sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	name := sftpPath(r.Filepath)
+	switch r.Method {
+	case "List":
+		entries, err := fs.ReadDir(h.fsys, name)
+		if err != nil {
+			return nil, sftp.ErrSSHFxNoSuchFile
+		}
+		infos := make(listerAt, 0, len(entries))
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		return infos, nil
+	case "Stat", "Lstat":
+		info, err := fs.Stat(h.fsys, name)
+		if err != nil {
+			return nil, sftp.ErrSSHFxNoSuchFile
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(out []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(out, l[offset:])
+	if n < len(out) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// sftpSubsystem serves fsys read-only to SFTP clients. Subsystems bypass
+// the middleware, so access and the limiter are consulted here. Idle transfers are left
+// to the server's idle timeout.
+func sftpSubsystem(fsys fs.FS, limiter *Limiter, access *Access) ssh.SubsystemHandler {
+	h := sftpHandler{fsys: fsys}
+	return func(s ssh.Session) {
+		ip := RemoteIP(s.RemoteAddr())
+		// SFTP clients show what's written to stderr
+		if err := access.Check(ip, Fingerprint(s.PublicKey())); err != nil {
+			log.Printf("Refused SFTP session from %s: %v", ip, err)
+			fmt.Fprintln(s.Stderr(), "Sorry, "+err.Error()+".")
+			_ = s.Exit(1)
+			return
+		}
+		release, err := limiter.Admit(ip)
+		if err != nil {
+			log.Printf("Rejected SFTP session from %s: %v", ip, err)
+			access.Refused(ip, err)
+			_ = s.Exit(1)
+			return
+		}
+		defer release()
+
+		srv := sftp.NewRequestServer(s, sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h})
+		if err := srv.Serve(); err != nil && err != io.EOF {
+			log.Printf("SFTP session for %s ended: %v", s.RemoteAddr(), err)
+		}
+		// Closing the server closes the channel, so report the status first
+		_ = s.Exit(0)
+		_ = srv.Close()
+	}
+}
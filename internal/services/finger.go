@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"clifolio/internal/config"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fingerWidth is where profile text wraps, leaving room in a classic
+// 80-column terminal.
+const fingerWidth = 72
+
+// FingerUser is someone the finger server answers for.
+type FingerUser struct {
+	Login   string
+	Profile ProfileData
+	// Availability is a short note, like "Open to freelance work".
+	Availability string
+}
+
+// FingerServerOptions holds what the finger server answers with.
+type FingerServerOptions struct {
+	// Users are listed by `finger @host` and fingered by login.
+	Users []FingerUser
+	// Hub tells whether the owner is connected right now. Nil never says so.
+	Hub *Hub
+	// Limiter admits queries, a new one from cfg.Limits when nil. Share the
+	// SSH server's so draining for shutdown turns finger clients away too.
+	Limiter *Limiter
+}
+
+// StartFingerServer answers finger queries on cfg.Finger.Address until ctx
+// is done. It gives up with a log line, not a crash, when it can't listen,
+// since it's an extra next to the SSH server.
+func StartFingerServer(ctx context.Context, cfg config.Config, o FingerServerOptions) {
+	if o.Limiter == nil {
+		o.Limiter = NewLimiter(cfg.Limits)
+	}
+	ln, err := listen(cfg, cfg.Finger.Address)
+	if err != nil {
+		log.Printf("Finger server disabled: %v", err)
+		return
+	}
+	log.Printf("Starting finger server on %s", cfg.Finger.Address)
+	o.server().serve(ctx, ln)
+}
+
+// server answers finger queries, one per connection.
+func (o FingerServerOptions) server() lineServer {
+	return lineServer{
+		name:    "Finger",
+		limiter: o.Limiter,
+		maxLine: 512,
+		busy: func(err error) string {
+			return "Sorry, " + err.Error() + ".\r\n"
+		},
+		handle: func(w io.Writer, ip, query string) {
+			log.Printf("Finger %q from %s", strings.TrimSpace(query), ip)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, _ = io.WriteString(w, strings.ReplaceAll(o.Reply(ctx, query), "\n", "\r\n"))
+		},
+	}
+}
+
+// Reply answers one finger query line as RFC 1288 describes: an empty query
+// lists the users, a login shows that user, and /W asks for the long form,
+// which is the only one there is. Forwarding to other hosts is refused.
+func (o FingerServerOptions) Reply(ctx context.Context, query string) string {
+	fields := strings.Fields(query)
+	if len(fields) > 0 && strings.EqualFold(fields[0], "/W") {
+		fields = fields[1:]
+	}
+
+	switch {
+	case len(fields) == 0:
+		return o.list()
+	case len(fields) > 1:
+		return "finger: one user at a time, please.\n"
+	case strings.Contains(fields[0], "@"):
+		return "finger: forwarding to other hosts is not supported.\n"
+	}
+
+	name := fields[0]
+	for _, u := range o.Users {
+		if strings.EqualFold(u.Login, name) {
+			return o.profile(ctx, u)
+		}
+	}
+	return fmt.Sprintf("finger: %s: no such user.\n", name)
+}
+
+func (o FingerServerOptions) list() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-10s %-24s %s\n", "Login", "Name", "Title")
+	for _, u := range o.Users {
+		fmt.Fprintf(&b, "%-10s %-24s %s\n", u.Login, ansi.Truncate(u.Profile.Name, 24, "…"), u.Profile.Title)
+	}
+	return b.String()
+}
+
+func (o FingerServerOptions) profile(ctx context.Context, u FingerUser) string {
+	p := u.Profile
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-39s Name: %s\n", "Login: "+u.Login, p.Name)
+	fmt.Fprintf(&b, "Title: %s\n", p.Title)
+	if p.Location != "" {
+		fmt.Fprintf(&b, "Where: %s\n", p.Location)
+	}
+	b.WriteString(o.presence() + "\n")
+	if u.Availability != "" {
+		fmt.Fprintf(&b, "Availability: %s\n", u.Availability)
+	}
+	if p.Bio != "" {
+		b.WriteString("\n" + ansi.Wordwrap(p.Bio, fingerWidth, "") + "\n")
+	}
+
+	b.WriteString("\nContact:\n")
+	for _, c := range [][2]string{
+		{"Email", p.Email},
+		{"Website", p.Website},
+		{"GitHub", p.GitHub},
+		{"LinkedIn", p.LinkedIn},
+	} {
+		if c[1] != "" {
+			fmt.Fprintf(&b, "  %-10s%s\n", c[0], c[1])
+		}
+	}
+
+	b.WriteString("\n" + fingerPlan(ctx, p))
+	return b.String()
+}
+
+// presence is the "On since" line, for the owner's earliest live session.
+func (o FingerServerOptions) presence() string {
+	if o.Hub != nil {
+		for _, l := range o.Hub.Sessions() {
+			if l.Owner {
+				return "On since " + l.Started.UTC().Format("Mon Jan 2 15:04 (MST)") + " over SSH."
+			}
+		}
+	}
+	return "Not logged in."
+}
+
+// fingerPlan draws the plan from the repositories pushed to most recently.
+// It only reads what the GitHub cache already has or can fetch in time, and
+// says "No Plan." otherwise, as finger always has.
+func fingerPlan(ctx context.Context, p ProfileData) string {
+	// Without a GitHub profile there's nothing to ask the API for
+	github := strings.TrimRight(p.GitHub, "/")
+	if github == "" {
+		return "No Plan.\n"
+	}
+	repos, err := FetchRepos(ctx, path.Base(github))
+	if err != nil || len(repos) == 0 {
+		return "No Plan.\n"
+	}
+
+	repos = append([]Repo(nil), repos...)
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Pushed.After(repos[j].Pushed) })
+
+	var b strings.Builder
+	b.WriteString("Plan:\nLately hacking on\n")
+	for _, r := range repos[:min(3, len(repos))] {
+		line := "  " + r.Name
+		if r.Language != "" {
+			line += " (" + r.Language + ")"
+		}
+		if !r.Pushed.IsZero() {
+			line += ", pushed " + r.Pushed.UTC().Format("Jan 2")
+		}
+		b.WriteString(line + "\n")
+		if r.Description != "" {
+			desc := ansi.Wordwrap(r.Description, fingerWidth-4, "")
+			b.WriteString("    " + strings.ReplaceAll(desc, "\n", "\n    ") + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+// GeminiServerOptions holds what the Gemini server publishes.
+type GeminiServerOptions struct {
+	Pages PageSource
+	// Host is the name requests must be for, cfg.Gemini.Hostname by
+	// default. Requests for other hosts are refused.
+	Host string
+	// Limiter admits requests, a new one from cfg.Limits when nil. Share the
+	// SSH server's so draining for shutdown turns Gemini clients away too.
+	Limiter *Limiter
+}
+
+// StartGeminiServer serves the content tree as gemtext on
+// cfg.Gemini.Address until ctx is done. Its certificate is created,
+// self-signed, the first time it runs; Gemini clients trust it on first use.
+func StartGeminiServer(ctx context.Context, cfg config.Config, o GeminiServerOptions) {
+	if o.Host == "" {
+		o.Host = cfg.Gemini.Hostname
+	}
+	if o.Limiter == nil {
+		o.Limiter = NewLimiter(cfg.Limits)
+	}
+	certFile, keyFile := cfg.GeminiCert()
+	cert, err := loadGeminiCert(certFile, keyFile, o.Host)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sum := sha256.Sum256(cert.Certificate[0])
+	log.Printf("Gemini certificate fingerprint: SHA256:%s", hex.EncodeToString(sum[:]))
+
+	ln, err := listen(cfg, cfg.Gemini.Address)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// TLS goes inside the PROXY header, which the balancer sends in clear
+	ln = tls.NewListener(ln, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+	log.Printf("Starting Gemini server on %s", cfg.Gemini.Address)
+	serverListening("gemini")
+	o.server().serve(ctx, ln)
+}
+
+// server answers Gemini requests, read from a TLS listener.
+func (o GeminiServerOptions) server() lineServer {
+	return lineServer{
+		name:    "Gemini",
+		limiter: o.Limiter,
+		// A URL of up to 1024 bytes and its CRLF
+		maxLine: 1026,
+		busy: func(err error) string {
+			return "44 Sorry, " + err.Error() + "\r\n"
+		},
+		handle: func(w io.Writer, ip, request string) {
+			log.Printf("Gemini %q from %s", request, ip)
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			_, _ = io.WriteString(w, o.reply(ctx, request))
+		},
+	}
+}
+
+func (o GeminiServerOptions) reply(ctx context.Context, request string) string {
+	u, err := url.Parse(request)
+	switch {
+	case err != nil || !u.IsAbs() || len(request) > 1024:
+		return "59 Bad request\r\n"
+	case u.Scheme != "gemini":
+		return "53 Only gemini:// is served here\r\n"
+	case o.Host != "" && !strings.EqualFold(u.Hostname(), o.Host):
+		return "53 Proxy request refused\r\n"
+	}
+
+	page, err := o.Pages(ctx, u.Path)
+	switch {
+	case errors.Is(err, ErrPageNotFound):
+		return "51 Not found\r\n"
+	case err != nil:
+		log.Printf("Gemini %q: %v", u.Path, err)
+		return "40 Couldn't build this page, please try again later\r\n"
+	}
+	return "20 text/gemini; charset=utf-8\r\n" + gemtext(page)
+}
+
+// gemtext renders a page as a Gemini document. Clients wrap text to their
+// own width, so paragraphs stay on one line.
+func gemtext(page Page) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", page.Title)
+	for i, l := range page.Lines {
+		switch l.Kind {
+		case PageHeading:
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "## %s\n", l.Text)
+		case PageItem:
+			fmt.Fprintf(&b, "* %s\n", l.Text)
+		case PageLink:
+			fmt.Fprintf(&b, "=> %s %s\n", l.Target, l.Text)
+		case PagePre:
+			// A line of the text that opens a fence would close ours
+			text := strings.ReplaceAll("\n"+l.Text, "\n```", "\n ```")[1:]
+			fmt.Fprintf(&b, "```\n%s\n```\n", text)
+		default:
+			b.WriteString(l.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// loadGeminiCert reads the certificate and key, first creating a
+// self-signed pair for host, or the machine's name, if they don't exist.
+func loadGeminiCert(certFile, keyFile, host string) (tls.Certificate, error) {
+	if _, err := os.Stat(certFile); errors.Is(err, os.ErrNotExist) {
+		if host == "" {
+			host, _ = os.Hostname()
+		}
+		log.Printf("Creating a self-signed Gemini certificate for %s in %s", host, certFile)
+		if err := createGeminiCert(certFile, keyFile, host); err != nil {
+			return tls.Certificate{}, fmt.Errorf("creating the Gemini certificate: %w", err)
+		}
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+func createGeminiCert(certFile, keyFile, host string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		// Trust on first use pins the certificate, so it's made to last
+		NotAfter:    time.Now().AddDate(10, 0, 0),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+}
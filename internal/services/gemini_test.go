@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+func TestGeminiReply(t *testing.T) {
+	o := GeminiServerOptions{
+		Host: "folio.example",
+		Pages: func(ctx context.Context, path string) (Page, error) {
+			switch path {
+			case "", "/":
+				return Page{Title: "Home", Lines: []PageLine{{Kind: PageText, Text: "hello"}}}, nil
+			case "/broken":
+				return Page{}, errors.New("github is down")
+			}
+			return Page{}, ErrPageNotFound
+		},
+	}
+	long := "gemini://folio.example/" + strings.Repeat("a", 1024)
+
+	tests := []struct {
+		name    string
+		request string
+		status  string
+	}{
+		{"root", "gemini://folio.example/", "20 "},
+		{"root without a slash", "gemini://folio.example", "20 "},
+		{"host in other case", "gemini://FOLIO.example/", "20 "},
+		{"host with port", "gemini://folio.example:1965/", "20 "},
+		{"missing page", "gemini://folio.example/nope", "51 "},
+		{"failing page", "gemini://folio.example/broken", "40 "},
+		{"other host", "gemini://evil.example/", "53 "},
+		{"host as a suffix", "gemini://evil.folio.example/", "53 "},
+		{"other scheme", "https://folio.example/", "53 "},
+		{"relative", "/", "59 "},
+		{"empty", "", "59 "},
+		{"not a URL", "gemini://folio.example/%zz", "59 "},
+		{"over long", long, "59 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := o.reply(context.Background(), tt.request)
+			if !strings.HasPrefix(got, tt.status) {
+				t.Errorf("reply(%q) = %q, want status %q", tt.request, firstLine(got), tt.status)
+			}
+			if !strings.Contains(got, "\r\n") {
+				t.Errorf("reply(%q) = %q, has no CRLF after the header", tt.request, got)
+			}
+		})
+	}
+}
+
+func TestGeminiReplyAnyHost(t *testing.T) {
+	o := GeminiServerOptions{Pages: testPages}
+	for _, request := range []string{"gemini://folio.example/", "gemini://localhost/", "gemini://192.0.2.1:1965/"} {
+		if got := o.reply(context.Background(), request); !strings.HasPrefix(got, "20 ") {
+			t.Errorf("reply(%q) = %q, want it answered with no host set", request, firstLine(got))
+		}
+	}
+}
+
+func TestGeminiServer(t *testing.T) {
+	dir := t.TempDir()
+	cert, err := loadGeminiCert(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "folio.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := tls.NewListener(listenLocal(t), &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	o := GeminiServerOptions{Host: "folio.example", Pages: testPages, Limiter: NewLimiter(config.Limits{})}
+	startLineServer(t, o.server(), ln)
+
+	get := func(request string) string {
+		t.Helper()
+		// Clients trust the certificate on first use, there's no CA to check
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{ServerName: "folio.example", InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("dialing: %v", err)
+		}
+		defer conn.Close()
+		if got := conn.ConnectionState().PeerCertificates[0].Subject.CommonName; got != "folio.example" {
+			t.Errorf("the certificate is for %q", got)
+		}
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.WriteString(conn, request+"\r\n"); err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatalf("reading the reply to %q: %v", request, err)
+		}
+		return string(b)
+	}
+
+	want := "20 text/gemini; charset=utf-8\r\n# Home\n\nhello\n=> /projects Projects\n"
+	if got := get("gemini://folio.example/"); got != want {
+		t.Errorf("gemini://folio.example/ = %q, want %q", got, want)
+	}
+	if got := get("gemini://folio.example/nope"); got != "51 Not found\r\n" {
+		t.Errorf("missing page = %q", got)
+	}
+	if got := get("gemini://evil.example/"); !strings.HasPrefix(got, "53 ") {
+		t.Errorf("other host = %q", got)
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\r\n")
+	return line
+}
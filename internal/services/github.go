@@ -1,33 +1,79 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"net/http"
 	"os"
+	"sort"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v79/github"
 	"golang.org/x/oauth2"
 )
 
+// MaxFileSize is the largest file the repository browser will download and
+// highlight. Anything bigger is reported instead of being rendered.
+const MaxFileSize = 256 * 1024
+
+var (
+	ErrFileTooLarge = errors.New("file is too large to preview")
+	ErrBinaryFile   = errors.New("binary file cannot be previewed")
+)
+
 type Repo struct {
-	Name        string
-	Description string
-	Language    string
-	HTMLURL     string
-	Stars       int
+	Owner       string `json:"owner"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	HTMLURL     string `json:"url"`
+	Stars       int    `json:"stars"`
+	// Pushed is when the repository last had commits pushed.
+	Pushed time.Time `json:"pushed"`
 }
 
-func FetchRepos(ctx context.Context, username string) ([]Repo, error) {
-	var client *github.Client
+// RepoEntry is a single file or directory returned by the contents API.
+type RepoEntry struct {
+	Name string
+	Path string
+	Type string // "file" or "dir"
+	Size int
+}
+
+func (e RepoEntry) IsDir() bool {
+	return e.Type == "dir"
+}
+
+// RepoFile is the decoded content of a single file in a repository.
+type RepoFile struct {
+	Path    string
+	Size    int
+	Content string
+}
+
+// newGitHubClient returns a client authenticated with GITHUB_TOKEN when it's
+// set, whose calls are counted in the metrics.
+func newGitHubClient(ctx context.Context) *github.Client {
 	token := os.Getenv("GITHUB_TOKEN")
-	if token != "" {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
-	} else {
-		client = github.NewClient(nil)
+	if token == "" {
+		return github.NewClient(&http.Client{Transport: countedTransport{}})
 	}
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	client := oauth2.NewClient(ctx, ts)
+	client.Transport = countedTransport{base: client.Transport}
+	return github.NewClient(client)
+}
+
+func FetchRepos(ctx context.Context, username string) ([]Repo, error) {
+	return cached(ctx, "repos/"+username, func(ctx context.Context) ([]Repo, error) {
+		return fetchRepos(ctx, username)
+	})
+}
+
+func fetchRepos(ctx context.Context, username string) ([]Repo, error) {
+	client := newGitHubClient(ctx)
 
 	opts := &github.RepositoryListOptions{
 		Type: "all",
@@ -48,15 +94,17 @@ func FetchRepos(ctx context.Context, username string) ([]Repo, error) {
 		}
 		opts.Page = res.NextPage
 	}
-	
+
 	out := make([]Repo, 0, len(all))
 	for _, r := range all {
 		out = append(out, Repo{
-			Name: r.GetName(),
+			Owner:       r.GetOwner().GetLogin(),
+			Name:        r.GetName(),
 			Description: r.GetDescription(),
-			Language: r.GetLanguage(),
-			HTMLURL: r.GetHTMLURL(),
-			Stars: r.GetStargazersCount(),
+			Language:    r.GetLanguage(),
+			HTMLURL:     r.GetHTMLURL(),
+			Stars:       r.GetStargazersCount(),
+			Pushed:      r.GetPushedAt().Time,
 		})
 	}
 
@@ -64,15 +112,13 @@ func FetchRepos(ctx context.Context, username string) ([]Repo, error) {
 }
 
 func FetchRepoReadme(ctx context.Context, owner, repo string) (string, error) {
-	var client *github.Client
-	token := os.Getenv("GITHUB_TOKEN")
-	if token != "" {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
-	} else {
-		client = github.NewClient(nil)
-	}
+	return cached(ctx, "readme/"+owner+"/"+repo, func(ctx context.Context) (string, error) {
+		return fetchRepoReadme(ctx, owner, repo)
+	})
+}
+
+func fetchRepoReadme(ctx context.Context, owner, repo string) (string, error) {
+	client := newGitHubClient(ctx)
 
 	rc, _, err := client.Repositories.GetReadme(ctx, owner, repo, nil)
 	if err != nil {
@@ -84,4 +130,90 @@ func FetchRepoReadme(ctx context.Context, owner, repo string) (string, error) {
 	}
 
 	return content, nil
-}
\ No newline at end of file
+}
+
+// FetchRepoContents lists a directory of a repository. Directories are sorted
+// before files, each group alphabetically.
+func FetchRepoContents(ctx context.Context, owner, repo, path string) ([]RepoEntry, error) {
+	return cached(ctx, "contents/"+owner+"/"+repo+"/"+path, func(ctx context.Context) ([]RepoEntry, error) {
+		return fetchRepoContents(ctx, owner, repo, path)
+	})
+}
+
+func fetchRepoContents(ctx context.Context, owner, repo, path string) ([]RepoEntry, error) {
+	client := newGitHubClient(ctx)
+
+	_, dir, _, err := client.Repositories.GetContents(ctx, owner, repo, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]RepoEntry, 0, len(dir))
+	for _, c := range dir {
+		out = append(out, RepoEntry{
+			Name: c.GetName(),
+			Path: c.GetPath(),
+			Type: c.GetType(),
+			Size: c.GetSize(),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].IsDir() != out[j].IsDir() {
+			return out[i].IsDir()
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+// FetchRepoFile downloads a single file. Files larger than MaxFileSize and
+// files that don't look like text are rejected before they reach the viewer.
+func FetchRepoFile(ctx context.Context, owner, repo, path string) (RepoFile, error) {
+	return cached(ctx, "file/"+owner+"/"+repo+"/"+path, func(ctx context.Context) (RepoFile, error) {
+		return fetchRepoFile(ctx, owner, repo, path)
+	})
+}
+
+func fetchRepoFile(ctx context.Context, owner, repo, path string) (RepoFile, error) {
+	client := newGitHubClient(ctx)
+
+	fc, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path, nil)
+	if err != nil {
+		return RepoFile{}, err
+	}
+	if fc == nil {
+		return RepoFile{}, errors.New("path is a directory")
+	}
+	if fc.GetSize() > MaxFileSize {
+		return RepoFile{}, ErrFileTooLarge
+	}
+
+	content, err := fc.GetContent()
+	if err != nil {
+		return RepoFile{}, err
+	}
+	if isBinary([]byte(content)) {
+		return RepoFile{}, ErrBinaryFile
+	}
+
+	return RepoFile{
+		Path:    fc.GetPath(),
+		Size:    fc.GetSize(),
+		Content: content,
+	}, nil
+}
+
+// isBinary uses the same heuristic as git: a NUL byte in the first 8000
+// bytes, or content that isn't valid UTF-8.
+func isBinary(b []byte) bool {
+	head := b
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		return true
+	}
+	return !utf8.Valid(b)
+}
@@ -3,35 +3,29 @@ package services
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
-
-	"github.com/google/go-github/v79/github"
-	"golang.org/x/oauth2"
 )
 
 type GitHubStats struct {
-	TotalRepos   int
-	TotalStars   int
-	TotalForks   int
-	PublicGists  int
-	Followers    int
-	Following    int
-	TotalCommits int
-	UpdatedAt    time.Time
+	TotalRepos   int       `json:"repos"`
+	TotalStars   int       `json:"stars"`
+	TotalForks   int       `json:"forks"`
+	PublicGists  int       `json:"gists"`
+	Followers    int       `json:"followers"`
+	Following    int       `json:"following"`
+	TotalCommits int       `json:"commits"`
+	AvatarURL    string    `json:"avatar_url"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 func FetchGitHubStats(ctx context.Context, username string) (*GitHubStats, error) {
-	var client *github.Client
-	token := os.Getenv("GITHUB_TOKEN")
+	return cached(ctx, "stats/"+username, func(ctx context.Context) (*GitHubStats, error) {
+		return fetchGitHubStats(ctx, username)
+	})
+}
 
-	if token != "" {
-		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
-	} else {
-		client = github.NewClient(nil)
-	}
+func fetchGitHubStats(ctx context.Context, username string) (*GitHubStats, error) {
+	client := newGitHubClient(ctx)
 
 	user, _, err := client.Users.Get(ctx, username)
 	if err != nil {
@@ -57,6 +51,7 @@ func FetchGitHubStats(ctx context.Context, username string) (*GitHubStats, error
 		PublicGists: user.GetPublicGists(),
 		Followers: user.GetFollowers(),
 		Following: user.GetFollowing(),
+		AvatarURL: user.GetAvatarURL(),
 		UpdatedAt: time.Now(),
 	}
 
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"clifolio/internal/config"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// gopherWidth is where menu text wraps. Gopher clients don't wrap info
+// lines themselves, and 70 columns is what gophermaps have always kept to.
+const gopherWidth = 70
+
+// GopherServerOptions holds what the Gopher server publishes.
+type GopherServerOptions struct {
+	Pages PageSource
+	// Limiter admits requests, a new one from cfg.Limits when nil. Share the
+	// SSH server's so draining for shutdown turns Gopher clients away too.
+	Limiter *Limiter
+}
+
+// StartGopherServer serves the content tree as Gopher menus on
+// cfg.Gopher.Address until ctx is done.
+func StartGopherServer(ctx context.Context, cfg config.Config, o GopherServerOptions) {
+	if o.Limiter == nil {
+		o.Limiter = NewLimiter(cfg.Limits)
+	}
+	ln, err := listen(cfg, cfg.Gopher.Address)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Starting Gopher server on %s", cfg.Gopher.Address)
+	serverListening("gopher")
+
+	// Menus link back here, so they need a name clients can reach
+	host := cfg.Gopher.Hostname
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	o.server(host, port).serve(ctx, ln)
+}
+
+// server answers selectors with menus linking back to host and port.
+func (o GopherServerOptions) server(host, port string) lineServer {
+	return lineServer{
+		name:    "Gopher",
+		limiter: o.Limiter,
+		maxLine: 1024,
+		busy: func(err error) string {
+			return gopherError("Sorry, " + err.Error() + ".")
+		},
+		handle: func(w io.Writer, ip, selector string) {
+			// Gopher+ clients send more after a tab
+			selector, _, _ = strings.Cut(selector, "\t")
+			log.Printf("Gopher %q from %s", selector, ip)
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			_, _ = io.WriteString(w, o.reply(ctx, selector, host, port))
+		},
+	}
+}
+
+func (o GopherServerOptions) reply(ctx context.Context, selector, host, port string) string {
+	if selector == "" {
+		selector = "/"
+	}
+	page, err := o.Pages(ctx, selector)
+	switch {
+	case errors.Is(err, ErrPageNotFound):
+		return gopherError("Nothing here: " + selector)
+	case err != nil:
+		log.Printf("Gopher %q: %v", selector, err)
+		return gopherError("Couldn't build this page, please try again later.")
+	}
+	return gophermap(page, host, port)
+}
+
+// gophermap renders a page as a menu: text as info lines, links to other
+// pages as menus and links elsewhere as URL items, which most clients open
+// in a browser.
+func gophermap(page Page, host, port string) string {
+	var b strings.Builder
+	info := func(s string) {
+		fmt.Fprintf(&b, "i%s\t\t%s\t%s\r\n", gopherText(s), host, port)
+	}
+	// wrapped puts first before the first line and indents the rest to match
+	wrapped := func(s, first string) {
+		indent := strings.Repeat(" ", len(first))
+		for i, line := range strings.Split(ansi.Wordwrap(s, gopherWidth-len(first), ""), "\n") {
+			if i == 0 {
+				info(first + line)
+			} else {
+				info(indent + line)
+			}
+		}
+	}
+
+	info(page.Title)
+	info(strings.Repeat("=", min(ansi.StringWidth(page.Title), gopherWidth)))
+	info("")
+	for i, l := range page.Lines {
+		switch l.Kind {
+		case PageHeading:
+			if i > 0 {
+				info("")
+			}
+			info(l.Text)
+			info(strings.Repeat("-", min(ansi.StringWidth(l.Text), gopherWidth)))
+		case PageItem:
+			wrapped(l.Text, "  * ")
+		case PageLink:
+			if strings.HasPrefix(l.Target, "/") {
+				fmt.Fprintf(&b, "1%s\t%s\t%s\t%s\r\n", gopherText(l.Text), l.Target, host, port)
+			} else {
+				fmt.Fprintf(&b, "h%s\tURL:%s\t%s\t%s\r\n", gopherText(l.Text), l.Target, host, port)
+			}
+		case PagePre:
+			for _, line := range strings.Split(l.Text, "\n") {
+				info(line)
+			}
+		default:
+			wrapped(l.Text, "")
+		}
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// gopherText makes s safe for one field of a menu line.
+func gopherText(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.TrimRight(s, "\r")
+}
+
+// gopherError is a menu holding just an error item.
+func gopherError(msg string) string {
+	return "3" + gopherText(msg) + "\terror\terror.host\t1\r\n.\r\n"
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"clifolio/internal/config"
+)
+
+func testPages(ctx context.Context, path string) (Page, error) {
+	switch path {
+	case "", "/":
+		return Page{Title: "Home", Lines: []PageLine{
+			{Kind: PageText, Text: "hello"},
+			{Kind: PageLink, Text: "Projects", Target: "/projects"},
+		}}, nil
+	case "/projects":
+		return Page{Title: "Projects", Lines: []PageLine{{Kind: PageItem, Text: "clifolio"}}}, nil
+	}
+	return Page{}, ErrPageNotFound
+}
+
+func TestGopherServer(t *testing.T) {
+	ln := listenLocal(t)
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	o := GopherServerOptions{Pages: testPages, Limiter: NewLimiter(config.Limits{})}
+	startLineServer(t, o.server("127.0.0.1", port), ln)
+
+	menu := request(t, dialLocal(t, ln), "")
+	if !strings.HasSuffix(menu, "\r\n.\r\n") {
+		t.Fatalf("the menu doesn't end with a lone dot: %q", menu)
+	}
+	if !strings.HasPrefix(menu, "iHome\t\t127.0.0.1\t"+port+"\r\n") {
+		t.Errorf("the menu doesn't open with the title: %q", firstLine(menu))
+	}
+
+	// Follow the menu's link the way a client would
+	var selector string
+	for _, line := range strings.Split(menu, "\r\n") {
+		if fields := strings.Split(line, "\t"); strings.HasPrefix(line, "1Projects") && len(fields) == 4 {
+			if fields[2] != "127.0.0.1" || fields[3] != port {
+				t.Errorf("the link points at %s:%s", fields[2], fields[3])
+			}
+			selector = fields[1]
+		}
+	}
+	if selector != "/projects" {
+		t.Fatalf("no menu item for /projects in %q", menu)
+	}
+	// Gopher+ clients send more after a tab
+	page := request(t, dialLocal(t, ln), selector+"\t+")
+	if !strings.HasPrefix(page, "iProjects\t") || !strings.Contains(page, "i  * clifolio\t") {
+		t.Errorf("GET %s = %q", selector, page)
+	}
+
+	if got := request(t, dialLocal(t, ln), "/nope"); !strings.HasPrefix(got, "3Nothing here: /nope\t") {
+		t.Errorf("missing selector = %q", got)
+	}
+}
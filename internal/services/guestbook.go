@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// MaxGuestbookMessage is the longest message a visitor may leave, in runes.
+const MaxGuestbookMessage = 280
+
+// Keys cost nothing to make, so signing is also limited per address, and the
+// moderation queue is capped so a flood can't bury the owner in entries.
+const (
+	maxSignaturesPerIP = 3
+	maxPendingEntries  = 100
+	signatureWindow    = 24 * time.Hour
+)
+
+var (
+	ErrEmptyMessage   = errors.New("the message is empty")
+	ErrMessageTooLong = fmt.Errorf("messages are limited to %d characters", MaxGuestbookMessage)
+	ErrMessageBlocked = errors.New("the message didn't pass the content filter")
+	ErrNoKey          = errors.New("connect with an SSH key to sign the guestbook")
+	ErrAlreadySigned  = errors.New("you've already signed today, come back tomorrow")
+	ErrSignedFromIP   = errors.New("a few visitors have signed from your address today, come back tomorrow")
+	ErrQueueFull      = errors.New("the guestbook has a lot of entries waiting for review, please try again later")
+	ErrEntryNotFound  = errors.New("guestbook entry not found")
+)
+
+type EntryStatus string
+
+const (
+	EntryPending  EntryStatus = "pending"
+	EntryApproved EntryStatus = "approved"
+	EntryRejected EntryStatus = "rejected"
+)
+
+type GuestbookEntry struct {
+	ID          string      `json:"id"`
+	Time        time.Time   `json:"time"`
+	Fingerprint string      `json:"fingerprint"`
+	GitHub      string      `json:"github,omitempty"`
+	Message     string      `json:"message"`
+	Status      EntryStatus `json:"status"`
+}
+
+// Author is how the entry is signed: the linked GitHub user if there is one,
+// otherwise a shortened key fingerprint.
+func (e GuestbookEntry) Author() string {
+	if e.GitHub != "" {
+		return "@" + e.GitHub
+	}
+	fp := strings.TrimPrefix(e.Fingerprint, "SHA256:")
+	if len(fp) > 12 {
+		fp = fp[:12]
+	}
+	return "key " + fp
+}
+
+// Guestbook keeps every entry in memory and rewrites the JSON file on change.
+type Guestbook struct {
+	mu      sync.Mutex
+	path    string
+	entries []GuestbookEntry
+	// signed holds when each address signed, for the per-address limit
+	signed map[string][]time.Time
+}
+
+func OpenGuestbook(path string) (*Guestbook, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	g := &Guestbook{path: path, signed: map[string][]time.Time{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &g.entries); err != nil {
+		return nil, fmt.Errorf("parsing guestbook %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// save writes the entries through a temporary file so a crash can't leave a
+// half-written guestbook behind. Callers hold the lock.
+func (g *Guestbook) save() error {
+	b, err := json.MarshalIndent(g.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := g.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, g.path)
+}
+
+// Sign adds a pending entry for the owner to review. ip is the address it
+// was signed from.
+func (g *Guestbook) Sign(fingerprint, ip, githubUser, message string) (GuestbookEntry, error) {
+	if fingerprint == "" {
+		return GuestbookEntry{}, ErrNoKey
+	}
+	message, err := FilterMessage(message)
+	if err != nil {
+		return GuestbookEntry{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if err := g.allow(fingerprint, ip, now); err != nil {
+		return GuestbookEntry{}, err
+	}
+
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return GuestbookEntry{}, err
+	}
+
+	entry := GuestbookEntry{
+		ID:          hex.EncodeToString(id),
+		Time:        now,
+		Fingerprint: fingerprint,
+		GitHub:      githubUser,
+		Message:     message,
+		Status:      EntryPending,
+	}
+	g.entries = append(g.entries, entry)
+	if err := g.save(); err != nil {
+		g.entries = g.entries[:len(g.entries)-1]
+		return GuestbookEntry{}, err
+	}
+	g.signed[ip] = append(g.signed[ip], now)
+	return entry, nil
+}
+
+// allow reports why a signature by fingerprint from ip can't be taken now,
+// nil if it can. Callers hold the lock.
+func (g *Guestbook) allow(fingerprint, ip string, now time.Time) error {
+	pending := 0
+	for _, e := range g.entries {
+		if e.Fingerprint == fingerprint && now.Sub(e.Time) < signatureWindow {
+			return ErrAlreadySigned
+		}
+		if e.Status == EntryPending {
+			pending++
+		}
+	}
+	if pending >= maxPendingEntries {
+		return ErrQueueFull
+	}
+
+	// Forget addresses whose signatures have all aged out, so the map only
+	// holds those still being counted
+	for addr, times := range g.signed {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= signatureWindow {
+			delete(g.signed, addr)
+		}
+	}
+	recent := g.signed[ip][:0]
+	for _, t := range g.signed[ip] {
+		if now.Sub(t) < signatureWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(g.signed, ip)
+	} else {
+		g.signed[ip] = recent
+	}
+	if len(recent) >= maxSignaturesPerIP {
+		return ErrSignedFromIP
+	}
+	return nil
+}
+
+// Entries returns the entries with the given status, newest first.
+func (g *Guestbook) Entries(status EntryStatus) []GuestbookEntry {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var out []GuestbookEntry
+	for _, e := range g.entries {
+		if e.Status == status {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out
+}
+
+// Moderate approves or rejects the entry with the given ID.
+func (g *Guestbook) Moderate(id string, approve bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i := range g.entries {
+		if g.entries[i].ID != id {
+			continue
+		}
+		prev := g.entries[i].Status
+		g.entries[i].Status = EntryRejected
+		if approve {
+			g.entries[i].Status = EntryApproved
+		}
+		if err := g.save(); err != nil {
+			g.entries[i].Status = prev
+			return err
+		}
+		return nil
+	}
+	return ErrEntryNotFound
+}
+
+// blockedWords is deliberately short. Anything subtler is for the owner to
+// catch in the moderation queue.
+var blockedWords = []string{
+	"fuck", "shit", "cunt", "nigger", "faggot", "retard",
+	"viagra", "casino", "crypto giveaway", "onlyfans",
+}
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)`)
+
+// FilterMessage tidies a message and rejects what obviously doesn't belong
+// in the guestbook: empty or overlong text, slurs and spam words, more than
+// one link, and long runs of the same character.
+func FilterMessage(message string) (string, error) {
+	message = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, message)
+	message = strings.Join(strings.Fields(message), " ")
+
+	if message == "" {
+		return "", ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(message) > MaxGuestbookMessage {
+		return "", ErrMessageTooLong
+	}
+
+	lower := strings.ToLower(message)
+	for _, w := range blockedWords {
+		if strings.Contains(lower, w) {
+			return "", ErrMessageBlocked
+		}
+	}
+	if len(linkPattern.FindAllString(message, -1)) > 1 {
+		return "", ErrMessageBlocked
+	}
+	if hasLongRun(message, 10) {
+		return "", ErrMessageBlocked
+	}
+
+	return message, nil
+}
+
+func hasLongRun(s string, n int) bool {
+	var prev rune
+	run := 0
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run >= n {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyGitHubKey reports whether key is one of the public keys username has
+// published on GitHub, which is what links an entry to a GitHub account.
+func VerifyGitHubKey(ctx context.Context, username string, key ssh.PublicKey) (bool, error) {
+	if key == nil {
+		return false, ErrNoKey
+	}
+
+	client := newGitHubClient(ctx)
+	keys, _, err := client.Users.ListKeys(ctx, username, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, k := range keys {
+		published, _, _, _, err := gossh.ParseAuthorizedKey([]byte(k.GetKey()))
+		if err != nil {
+			continue
+		}
+		if ssh.KeysEqual(published, key) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
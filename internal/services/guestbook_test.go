@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestGuestbookSignLimits(t *testing.T) {
+	g, err := OpenGuestbook(filepath.Join(t.TempDir(), "guestbook.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(key, ip string) error {
+		_, err := g.Sign(key, ip, "", "Lovely portfolio!")
+		return err
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		ip   string
+		want error
+	}{
+		{"first signature", "SHA256:a", "192.0.2.1", nil},
+		{"same key again", "SHA256:a", "192.0.2.2", ErrAlreadySigned},
+		{"second key, same address", "SHA256:b", "192.0.2.1", nil},
+		{"third key, same address", "SHA256:c", "192.0.2.1", nil},
+		{"fourth key, same address", "SHA256:d", "192.0.2.1", ErrSignedFromIP},
+		{"fourth key, other address", "SHA256:d", "192.0.2.2", nil},
+		{"no key", "", "192.0.2.3", ErrNoKey},
+	}
+	for _, tt := range tests {
+		if err := sign(tt.key, tt.ip); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Sign() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Signatures older than a day no longer count towards the address
+	g.mu.Lock()
+	for i, ts := range g.signed["192.0.2.1"] {
+		g.signed["192.0.2.1"][i] = ts.Add(-signatureWindow)
+	}
+	g.mu.Unlock()
+	if err := sign("SHA256:e", "192.0.2.1"); err != nil {
+		t.Errorf("Sign() a day later = %v", err)
+	}
+
+	// A full queue turns everyone away until the owner catches up
+	for i := 0; len(g.Entries(EntryPending)) < maxPendingEntries; i++ {
+		if err := sign(fmt.Sprint("SHA256:flood", i), fmt.Sprint("198.51.100.", i)); err != nil {
+			t.Fatalf("filling the queue: %v", err)
+		}
+	}
+	if err := sign("SHA256:late", "203.0.113.1"); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Sign() with a full queue = %v, want %v", err, ErrQueueFull)
+	}
+	if err := g.Moderate(g.Entries(EntryPending)[0].ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := sign("SHA256:late", "203.0.113.1"); err != nil {
+		t.Errorf("Sign() after moderation = %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/muesli/termenv"
+)
+
+// HighlightCode returns source with ANSI syntax highlighting for a terminal
+// with the given color profile. The lexer is picked from the file name first
+// and from the content as a fallback.
+func HighlightCode(filename, source string, profile termenv.Profile) (string, error) {
+	lexer := lexers.Match(filename)
+	if lexer == nil {
+		lexer = lexers.Analyse(source)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	style := styles.Get("monokai")
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	formatter := formatters.Get(chromaFormatter(profile))
+	if formatter == nil {
+		formatter = formatters.Fallback
+	}
+
+	it, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := formatter.Format(&b, style, it); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func chromaFormatter(profile termenv.Profile) string {
+	switch profile {
+	case termenv.TrueColor:
+		return "terminal16m"
+	case termenv.ANSI256:
+		return "terminal256"
+	case termenv.ANSI:
+		return "terminal16"
+	default:
+		return "noop"
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"clifolio/internal/state"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AnnouncementMsg is a banner the owner broadcasts to every session. An
+// empty Text clears the current one.
+type AnnouncementMsg struct {
+	Text    string
+	Expires time.Time
+}
+
+// PresenceMsg tells every session how many visitors are connected and which
+// screens they are on. Counts include the session receiving it.
+type PresenceMsg struct {
+	Online  int
+	Screens map[state.Screen]int
+}
+
+// presenceDelay batches presence changes, so a burst of connects or screen
+// switches reaches each program as one message.
+const presenceDelay = 250 * time.Millisecond
+
+// sendQueue is how many messages can wait for a session's program. A
+// program that falls further behind misses messages rather than holding up
+// the hub.
+const sendQueue = 64
+
+// LiveSession is a connected visitor as seen by the hub.
+type LiveSession struct {
+	ID          string
+	User        string
+	IP          string
+	Fingerprint string
+	Term        string
+	Owner       bool
+	Started     time.Time
+
+	hub      *Hub
+	mu       sync.Mutex
+	screen   state.Screen
+	program  *tea.Program
+	queue    chan tea.Msg
+	farewell string
+	nick     string
+	sent     []time.Time
+}
+
+// SetScreen records which screen the visitor is on. It is safe to call on a
+// nil LiveSession, which is what local runs have.
+func (l *LiveSession) SetScreen(screen state.Screen) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	changed := l.screen != screen
+	l.screen = screen
+	l.mu.Unlock()
+
+	if changed {
+		screenViews.WithLabelValues(screen.String()).Inc()
+	}
+	if changed && l.hub != nil {
+		l.hub.presenceChanged()
+	}
+}
+
+func (l *LiveSession) Screen() state.Screen {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.screen
+}
+
+// Farewell is the goodbye for a session the server ended, by the owner or
+// on shutdown, and empty for one the visitor left themselves.
+func (l *LiveSession) Farewell() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.farewell
+}
+
+// end quits the session's program, saying farewell once it has gone.
+func (l *LiveSession) end(farewell string) {
+	l.mu.Lock()
+	l.farewell = farewell
+	p := l.program
+	l.mu.Unlock()
+	if p != nil {
+		go p.Quit()
+	}
+}
+
+// send queues msg for the session's program, in the order sent, without
+// blocking the caller on a busy or exiting program. It's dropped if the
+// queue is full.
+func (l *LiveSession) send(msg tea.Msg) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queue == nil {
+		return
+	}
+	select {
+	case l.queue <- msg:
+	default:
+	}
+}
+
+// deliver starts feeding the queue to p, one message at a time. Callers
+// hold the lock.
+func (l *LiveSession) deliver(p *tea.Program) {
+	l.program = p
+	l.queue = make(chan tea.Msg, sendQueue)
+	go func(queue <-chan tea.Msg) {
+		for msg := range queue {
+			p.Send(msg)
+		}
+	}(l.queue)
+}
+
+// stop closes the queue once the session is gone, ending its delivery.
+func (l *LiveSession) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queue != nil {
+		close(l.queue)
+		l.queue = nil
+	}
+}
+
+// Hub tracks every live session so the owner can see and reach them.
+type Hub struct {
+	mu              sync.Mutex
+	sessions        map[string]*LiveSession
+	announcement    AnnouncementMsg
+	presencePending bool
+	chat            []ChatMessage
+	muted           map[string]time.Time
+}
+
+func NewHub() *Hub {
+	return &Hub{sessions: map[string]*LiveSession{}, muted: map[string]time.Time{}}
+}
+
+func (h *Hub) add(l *LiveSession) {
+	l.hub = h
+	h.mu.Lock()
+	h.sessions[l.ID] = l
+	h.mu.Unlock()
+	h.presenceChanged()
+}
+
+func (h *Hub) remove(id string) {
+	h.mu.Lock()
+	l, ok := h.sessions[id]
+	delete(h.sessions, id)
+	h.mu.Unlock()
+	if ok {
+		h.leaveChat(l)
+		l.stop()
+	}
+	h.presenceChanged()
+}
+
+// attach links a session to its running program and catches it up on the
+// current announcement.
+func (h *Hub) attach(id string, p *tea.Program) {
+	h.mu.Lock()
+	l, ok := h.sessions[id]
+	announcement := h.announcement
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	ended := l.farewell != ""
+	if ended {
+		l.program = p
+	} else {
+		l.deliver(p)
+	}
+	l.mu.Unlock()
+
+	if ended {
+		go p.Quit()
+		return
+	}
+	if announcement.Text != "" && time.Now().Before(announcement.Expires) {
+		l.send(announcement)
+	}
+	l.send(h.Presence())
+}
+
+// Sessions returns the live sessions, oldest first.
+func (h *Hub) Sessions() []*LiveSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]*LiveSession, 0, len(h.sessions))
+	for _, l := range h.sessions {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.Before(out[j].Started) })
+	return out
+}
+
+// Presence counts the live sessions and the screens they are on.
+func (h *Hub) Presence() PresenceMsg {
+	sessions := h.Sessions()
+	msg := PresenceMsg{Online: len(sessions), Screens: make(map[state.Screen]int)}
+	for _, l := range sessions {
+		msg.Screens[l.Screen()]++
+	}
+	return msg
+}
+
+// presenceChanged schedules a presence update for every session unless one
+// is already on its way.
+func (h *Hub) presenceChanged() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.presencePending {
+		return
+	}
+	h.presencePending = true
+	time.AfterFunc(presenceDelay, func() {
+		h.mu.Lock()
+		h.presencePending = false
+		h.mu.Unlock()
+		h.Broadcast(h.Presence())
+	})
+}
+
+// Broadcast sends msg to every session's program.
+func (h *Hub) Broadcast(msg tea.Msg) {
+	for _, l := range h.Sessions() {
+		l.send(msg)
+	}
+}
+
+// Announce shows text to everyone for d, including visitors who connect
+// while it is up. Empty text takes the current announcement down.
+func (h *Hub) Announce(text string, d time.Duration) {
+	msg := AnnouncementMsg{Text: text, Expires: time.Now().Add(d)}
+	h.mu.Lock()
+	h.announcement = msg
+	h.mu.Unlock()
+	h.Broadcast(msg)
+}
+
+// Disconnect ends the session with the given ID, reporting whether it was
+// found.
+func (h *Hub) Disconnect(id string) bool {
+	h.mu.Lock()
+	l, ok := h.sessions[id]
+	h.mu.Unlock()
+	if !ok {
+		return false
+	}
+	l.end("The owner has closed your session. Farewell, traveller!")
+	return true
+}
+
+// DisconnectAll ends every session with farewell.
+func (h *Hub) DisconnectAll(farewell string) {
+	for _, l := range h.Sessions() {
+		l.end(farewell)
+	}
+}
@@ -0,0 +1,531 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/muesli/termenv"
+)
+
+// GraphicsProtocol is the way an image is drawn in the client's terminal.
+type GraphicsProtocol int
+
+const (
+	GraphicsHalfBlock GraphicsProtocol = iota
+	GraphicsKitty
+	GraphicsSixel
+)
+
+func (g GraphicsProtocol) String() string {
+	switch g {
+	case GraphicsKitty:
+		return "kitty"
+	case GraphicsSixel:
+		return "sixel"
+	default:
+		return "halfblock"
+	}
+}
+
+// maxImageBytes caps how much we are willing to download for a single image.
+const maxImageBytes = 5 * 1024 * 1024
+
+// maxImagePixels caps the size an image decodes to, since a few kilobytes of
+// compressed PNG can claim a bitmap of gigabytes. It's about a 4K screenshot,
+// far more than a terminal can show.
+const maxImagePixels = 8 << 20
+
+// maxImageCacheBytes is how much the image cache may hold on disk before the
+// least recently used images are deleted.
+const maxImageCacheBytes = 100 << 20
+
+var (
+	errImageTooLarge = errors.New("image is too large")
+	errImageHost     = errors.New("images are only fetched from GitHub's content hosts")
+)
+
+// imageHosts are the hosts FetchImage downloads from. READMEs can point
+// anywhere, and the server mustn't be made to request internal addresses on
+// their behalf.
+var imageHosts = map[string]bool{
+	"raw.githubusercontent.com":         true,
+	"user-images.githubusercontent.com": true,
+	"avatars.githubusercontent.com":     true,
+	"camo.githubusercontent.com":        true,
+}
+
+// imageClient fetches images with a deadline of its own, and follows
+// redirects only to the allowed hosts.
+var imageClient = &http.Client{
+	Timeout: 15 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return checkImageURL(req.URL)
+	},
+}
+
+func checkImageURL(u *url.URL) error {
+	if u.Scheme != "https" || !imageHosts[strings.ToLower(u.Hostname())] {
+		return errImageHost
+	}
+	return nil
+}
+
+// Terminal cells are roughly twice as tall as they are wide. The pixel size is
+// only used to pick a resolution for Kitty and Sixel output.
+const (
+	cellPixelWidth  = 10
+	cellPixelHeight = 20
+)
+
+// ImageOptions controls how an image is fitted and encoded.
+type ImageOptions struct {
+	Width    int // maximum width in cells
+	Height   int // maximum height in cells
+	Protocol GraphicsProtocol
+	Profile  termenv.Profile
+}
+
+// DetectGraphics picks the best graphics protocol the terminal described by
+// environ advertises. CLIFOLIO_GRAPHICS overrides the detection, which lets
+// SSH visitors opt in with `ssh -o SetEnv=CLIFOLIO_GRAPHICS=sixel`.
+func DetectGraphics(environ []string) GraphicsProtocol {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+
+	switch strings.ToLower(env["CLIFOLIO_GRAPHICS"]) {
+	case "kitty":
+		return GraphicsKitty
+	case "sixel":
+		return GraphicsSixel
+	case "halfblock", "none":
+		return GraphicsHalfBlock
+	}
+
+	term := strings.ToLower(env["TERM"])
+	program := strings.ToLower(env["TERM_PROGRAM"])
+
+	switch {
+	case env["KITTY_WINDOW_ID"] != "", strings.Contains(term, "kitty"),
+		program == "wezterm", program == "ghostty", strings.Contains(term, "ghostty"):
+		return GraphicsKitty
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "mlterm"), strings.HasPrefix(term, "yaft"),
+		program == "iterm.app", program == "mintty":
+		return GraphicsSixel
+	}
+
+	return GraphicsHalfBlock
+}
+
+// DecodeImage decodes a PNG, JPEG or GIF image. Its dimensions are read
+// first, and images too large to decode safely are refused.
+func DecodeImage(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, errImageTooLarge
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d", errImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// LoadImage decodes an image from a local file.
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeImage(f)
+}
+
+// ImageCacheDir is where downloaded images are kept between sessions.
+func ImageCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "clifolio", "images")
+}
+
+// FetchImage downloads an image from one of GitHub's content hosts, or loads
+// it from the local cache when it has been downloaded before.
+func FetchImage(ctx context.Context, rawURL string) (image.Image, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImageURL(u); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(rawURL))
+	cached := filepath.Join(ImageCacheDir(), hex.EncodeToString(sum[:]))
+
+	if img, err := LoadImage(cached); err == nil {
+		// The modification time doubles as the last use, for pruning
+		now := time.Now()
+		_ = os.Chtimes(cached, now, now)
+		return img, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := imageClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching image: %s", res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, errImageTooLarge
+	}
+
+	img, err := DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Caching is best effort, a read-only disk shouldn't break rendering
+	if err := os.MkdirAll(ImageCacheDir(), 0o755); err == nil {
+		if os.WriteFile(cached, data, 0o644) == nil {
+			pruneImageCache(ImageCacheDir(), maxImageCacheBytes)
+		}
+	}
+
+	return img, nil
+}
+
+// pruneImageCache deletes the least recently used images in dir until what
+// is left fits in limit bytes.
+func pruneImageCache(dir string, limit int64) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	if total <= limit {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, f := range files {
+		if total <= limit {
+			break
+		}
+		if os.Remove(filepath.Join(dir, f.Name())) == nil {
+			total -= f.Size()
+		}
+	}
+}
+
+// RenderImage draws img with the protocol in opts, scaled to fit inside
+// opts.Width x opts.Height cells while keeping its aspect ratio. The result
+// always occupies exactly the returned number of lines so it can be laid out
+// like any other block of text.
+func RenderImage(img image.Image, opts ImageOptions) string {
+	cols, rows := fitCells(img.Bounds(), opts.Width, opts.Height)
+	if cols == 0 || rows == 0 {
+		return ""
+	}
+
+	switch opts.Protocol {
+	case GraphicsKitty:
+		return renderKitty(img, cols, rows)
+	case GraphicsSixel:
+		return renderSixel(img, cols, rows)
+	default:
+		if opts.Profile == termenv.Ascii {
+			return renderASCIIArt(img, cols, rows)
+		}
+		return renderHalfBlock(img, cols, rows, opts.Profile)
+	}
+}
+
+// fitCells returns the largest cell area that fits the bounds while keeping
+// the image's aspect ratio.
+func fitCells(b image.Rectangle, maxCols, maxRows int) (int, int) {
+	if b.Dx() == 0 || b.Dy() == 0 || maxCols <= 0 || maxRows <= 0 {
+		return 0, 0
+	}
+
+	// One cell shows two vertical pixels in half-block mode
+	cols := maxCols
+	rows := cols * b.Dy() / b.Dx() / 2
+	if rows > maxRows {
+		rows = maxRows
+		cols = rows * 2 * b.Dx() / b.Dy()
+	}
+
+	return max(1, cols), max(1, rows)
+}
+
+// scaleImage resizes src to w x h by averaging the source pixels that fall
+// into each destination pixel.
+func scaleImage(src image.Image, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	b := src.Bounds()
+
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/w)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n),
+				G: uint8(g / n),
+				B: uint8(bl / n),
+				A: uint8(a / n),
+			})
+		}
+	}
+
+	return dst
+}
+
+func renderHalfBlock(img image.Image, cols, rows int, profile termenv.Profile) string {
+	px := scaleImage(img, cols, rows*2)
+
+	var b strings.Builder
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			top := px.NRGBAAt(x, y*2)
+			bottom := px.NRGBAAt(x, y*2+1)
+
+			switch {
+			case top.A < 128 && bottom.A < 128:
+				b.WriteString(" ")
+			case bottom.A < 128:
+				b.WriteString(termenv.CSI + profile.FromColor(top).Sequence(false) + "m▀" + termenv.CSI + termenv.ResetSeq + "m")
+			case top.A < 128:
+				b.WriteString(termenv.CSI + profile.FromColor(bottom).Sequence(false) + "m▄" + termenv.CSI + termenv.ResetSeq + "m")
+			default:
+				b.WriteString(termenv.CSI + profile.FromColor(top).Sequence(false) + ";" +
+					profile.FromColor(bottom).Sequence(true) + "m▀" + termenv.CSI + termenv.ResetSeq + "m")
+			}
+		}
+		if y < rows-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// renderASCIIArt is the fallback for clients without any color support.
+func renderASCIIArt(img image.Image, cols, rows int) string {
+	const ramp = " .:-=+*#%@"
+	px := scaleImage(img, cols, rows)
+
+	var b strings.Builder
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			c := px.NRGBAAt(x, y)
+			if c.A < 128 {
+				b.WriteByte(' ')
+				continue
+			}
+			lum := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+			b.WriteByte(ramp[lum*(len(ramp)-1)/255])
+		}
+		if y < rows-1 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// padGraphic puts an escape sequence that draws over a cols x rows area in
+// front of blank lines of the same size, so layout code sees a plain block.
+func padGraphic(seq string, cols, rows int) string {
+	blank := strings.Repeat(" ", cols)
+	lines := make([]string, rows)
+	for i := range lines {
+		lines[i] = blank
+	}
+	lines[0] = seq + blank
+	return strings.Join(lines, "\n")
+}
+
+func renderKitty(img image.Image, cols, rows int) string {
+	px := scaleImage(img, cols*cellPixelWidth, rows*cellPixelHeight)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, px); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	// q=2 silences replies, which would otherwise arrive as keyboard input.
+	// C=1 keeps the cursor in place so the padding below stays aligned.
+	var b strings.Builder
+	const chunk = 4096
+	for i := 0; i < len(data); i += chunk {
+		end := min(i+chunk, len(data))
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&b, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, data[i:end])
+		} else {
+			fmt.Fprintf(&b, "\x1b_Gm=%d;%s\x1b\\", more, data[i:end])
+		}
+	}
+
+	return padGraphic(b.String(), cols, rows)
+}
+
+func renderSixel(img image.Image, cols, rows int) string {
+	w, h := cols*cellPixelWidth, rows*cellPixelHeight
+	px := scaleImage(img, w, h)
+
+	// Quantize to a 6x6x6 color cube, -1 marks transparent pixels
+	idx := make([]int, w*h)
+	used := make([]bool, 216)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := px.NRGBAAt(x, y)
+			if c.A < 128 {
+				idx[y*w+x] = -1
+				continue
+			}
+			i := int(c.R)*5/255*36 + int(c.G)*5/255*6 + int(c.B)*5/255
+			idx[y*w+x] = i
+			used[i] = true
+		}
+	}
+
+	var b strings.Builder
+	// P2=1 leaves unset pixels transparent
+	fmt.Fprintf(&b, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i, ok := range used {
+		if ok {
+			fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+		}
+	}
+
+	for band := 0; band < h; band += 6 {
+		first := true
+		for c := 0; c < 216; c++ {
+			if !used[c] {
+				continue
+			}
+
+			line := make([]byte, w)
+			any := false
+			for x := 0; x < w; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if idx[(band+dy)*w+x] == c {
+						bits |= 1 << dy
+					}
+				}
+				if bits != 0 {
+					any = true
+				}
+				line[x] = '?' + bits
+			}
+			if !any {
+				continue
+			}
+
+			if !first {
+				b.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&b, "#%d", c)
+			writeSixelRLE(&b, line)
+		}
+		b.WriteByte('-')
+	}
+	b.WriteString("\x1b\\")
+
+	return padGraphic(b.String(), cols, rows)
+}
+
+func writeSixelRLE(b *strings.Builder, line []byte) {
+	for i := 0; i < len(line); {
+		j := i
+		for j < len(line) && line[j] == line[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(b, "!%d%c", n, line[i])
+		} else {
+			b.Write(line[i:j])
+		}
+		i = j
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+// pngClaiming is a tiny PNG whose header claims w x h pixels.
+func pngClaiming(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	// The signature, then IHDR's length and type, then its fields
+	const ihdr = 8 + 8
+	binary.BigEndian.PutUint32(b[ihdr:], w)
+	binary.BigEndian.PutUint32(b[ihdr+4:], h)
+	binary.BigEndian.PutUint32(b[ihdr+13:], crc32.ChecksumIEEE(b[ihdr-4:ihdr+13]))
+	return b
+}
+
+// gifClaiming is a tiny GIF whose screen claims w x h pixels.
+func gifClaiming(t *testing.T, w, h uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	binary.LittleEndian.PutUint16(b[6:], w)
+	binary.LittleEndian.PutUint16(b[8:], h)
+	return b
+}
+
+func TestDecodeImagePixelBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		tooLarge bool
+		ok       bool
+	}{
+		{"small PNG", pngClaiming(t, 2, 2), false, true},
+		{"small GIF", gifClaiming(t, 2, 2), false, true},
+		// At the budget the header passes, and the missing pixels fail
+		{"PNG at the budget", pngClaiming(t, 4096, 2048), false, false},
+		{"PNG past the budget", pngClaiming(t, 4096, 2049), true, false},
+		{"PNG bomb", pngClaiming(t, 1<<20, 1<<20), true, false},
+		{"GIF past the budget", gifClaiming(t, 65535, 65535), true, false},
+		{"not an image", []byte("<svg></svg>"), false, false},
+		{"over the byte limit", make([]byte, maxImageBytes+1), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := DecodeImage(bytes.NewReader(tt.data))
+			if got := errors.Is(err, errImageTooLarge); got != tt.tooLarge {
+				t.Errorf("DecodeImage() = %v, want too large %v", err, tt.tooLarge)
+			}
+			if (err == nil) != tt.ok {
+				t.Errorf("DecodeImage() = %v, want ok %v", err, tt.ok)
+			}
+			if tt.ok && img.Bounds().Dx() != 2 {
+				t.Errorf("decoded %v", img.Bounds())
+			}
+		})
+	}
+}
+
+func TestFitCells(t *testing.T) {
+	tests := []struct {
+		name             string
+		w, h             int
+		maxCols, maxRows int
+		cols, rows       int
+	}{
+		{"square", 100, 100, 40, 40, 40, 20},
+		{"tall, limited by rows", 100, 400, 40, 20, 10, 20},
+		{"wide, at least a row", 400, 10, 40, 20, 40, 1},
+		{"narrow, at least a column", 1, 1000, 40, 20, 1, 20},
+		{"empty image", 0, 0, 40, 20, 0, 0},
+		{"no room", 100, 100, 0, 20, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, rows := fitCells(image.Rect(0, 0, tt.w, tt.h), tt.maxCols, tt.maxRows)
+			if cols != tt.cols || rows != tt.rows {
+				t.Errorf("fitCells(%dx%d, %d, %d) = %d, %d, want %d, %d", tt.w, tt.h, tt.maxCols, tt.maxRows, cols, rows, tt.cols, tt.rows)
+			}
+		})
+	}
+}
+
+func TestRenderImageHalfBlock(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	none := color.NRGBA{}
+	// stripes is a 2x2 image with top over bottom
+	stripes := func(top, bottom color.NRGBA) image.Image {
+		img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+		for x := 0; x < 2; x++ {
+			img.SetNRGBA(x, 0, top)
+			img.SetNRGBA(x, 1, bottom)
+		}
+		return img
+	}
+
+	tests := []struct {
+		name    string
+		img     image.Image
+		profile termenv.Profile
+		want    string
+	}{
+		{"both halves", stripes(red, blue), termenv.TrueColor, "\x1b[38;2;255;0;0;48;2;0;0;255m▀\x1b[0m"},
+		{"top half", stripes(red, none), termenv.TrueColor, "\x1b[38;2;255;0;0m▀\x1b[0m"},
+		{"bottom half", stripes(none, blue), termenv.TrueColor, "\x1b[38;2;0;0;255m▄\x1b[0m"},
+		{"transparent", stripes(none, none), termenv.TrueColor, " "},
+		{"256 colors", stripes(red, none), termenv.ANSI256, "\x1b[38;5;196m▀\x1b[0m"},
+		{"no color", stripes(color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}), termenv.Ascii, "@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderImage(tt.img, ImageOptions{Width: 1, Height: 1, Profile: tt.profile})
+			if got != tt.want {
+				t.Errorf("RenderImage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A cell is two pixels tall, so a 4x4 image fills two lines of four
+	out := RenderImage(image.NewNRGBA(image.Rect(0, 0, 4, 4)), ImageOptions{Width: 4, Height: 4, Profile: termenv.TrueColor})
+	if lines := strings.Split(out, "\n"); len(lines) != 2 || lines[0] != "    " {
+		t.Errorf("4x4 image = %q, want two lines of four blank cells", out)
+	}
+}
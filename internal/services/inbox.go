@@ -0,0 +1,336 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"clifolio/internal/config"
+)
+
+const (
+	MaxContactName    = 80
+	MaxContactMessage = 2000
+)
+
+var (
+	ErrContactName      = errors.New("please tell us your name")
+	ErrContactNameLong  = fmt.Errorf("names are limited to %d characters", MaxContactName)
+	ErrContactReplyTo   = errors.New("that doesn't look like an email address")
+	ErrContactEmpty     = errors.New("the message is empty")
+	ErrContactLong      = fmt.Errorf("messages are limited to %d characters", MaxContactMessage)
+	ErrContactThrottled = errors.New("you've sent a few messages already, please try again later")
+)
+
+// ContactMessage is a message left through the contact form.
+type ContactMessage struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Name        string    `json:"name"`
+	ReplyTo     string    `json:"reply_to"`
+	Message     string    `json:"message"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Relayed     bool      `json:"relayed"`
+}
+
+// ContactSender identifies who is submitting the form, for throttling.
+type ContactSender struct {
+	Fingerprint string
+	IP          string
+}
+
+// Inbox appends contact messages to a JSONL file and, when an SMTP relay is
+// configured, emails them on to the owner.
+type Inbox struct {
+	mu     sync.Mutex
+	path   string
+	smtp   config.SMTP
+	perKey int
+	perIP  int
+	window time.Duration
+	sent   map[string][]time.Time
+	// pending counts the submissions being relayed and stored, which Close
+	// waits for
+	pending sync.WaitGroup
+	closed  bool
+}
+
+func OpenInbox(path string, cfg config.Contact) (*Inbox, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if cfg.SMTP.Address != "" && (cfg.SMTP.From == "" || cfg.SMTP.To == "") {
+		return nil, errors.New("the SMTP relay needs both a from and a to address")
+	}
+	return &Inbox{
+		path:   path,
+		smtp:   cfg.SMTP,
+		perKey: cfg.PerKey,
+		perIP:  cfg.PerIP,
+		window: cfg.Window.Std(),
+		sent:   map[string][]time.Time{},
+	}, nil
+}
+
+// CleanContactMessage tidies the form fields and checks them, returning the
+// cleaned values.
+func CleanContactMessage(name, replyTo, message string) (string, string, string, error) {
+	name = strings.Join(strings.Fields(stripControl(name, false)), " ")
+	if name == "" {
+		return "", "", "", ErrContactName
+	}
+	if utf8.RuneCountInString(name) > MaxContactName {
+		return "", "", "", ErrContactNameLong
+	}
+
+	addr, err := mail.ParseAddress(strings.TrimSpace(replyTo))
+	if err != nil || !strings.Contains(addr.Address, ".") {
+		return "", "", "", ErrContactReplyTo
+	}
+
+	message = strings.TrimSpace(stripControl(message, true))
+	if message == "" {
+		return "", "", "", ErrContactEmpty
+	}
+	if utf8.RuneCountInString(message) > MaxContactMessage {
+		return "", "", "", ErrContactLong
+	}
+	return name, addr.Address, message, nil
+}
+
+// stripControl drops control characters, keeping newlines if asked.
+func stripControl(s string, keepNewlines bool) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' && keepNewlines {
+			return r
+		}
+		if r == '\t' || r == '\n' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// allow records a submission for from and reports whether it is within the
+// per-key and per-address limits.
+func (in *Inbox) allow(from ContactSender, now time.Time) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	// Forget senders whose submissions have all aged out, so the map only
+	// holds those still being counted
+	for key, times := range in.sent {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) >= in.window {
+			delete(in.sent, key)
+		}
+	}
+
+	type bucket struct {
+		key   string
+		limit int
+	}
+	buckets := []bucket{{"ip:" + from.IP, in.perIP}}
+	if from.Fingerprint != "" {
+		buckets = append(buckets, bucket{"key:" + from.Fingerprint, in.perKey})
+	}
+
+	for _, b := range buckets {
+		recent := in.sent[b.key][:0]
+		for _, t := range in.sent[b.key] {
+			if now.Sub(t) < in.window {
+				recent = append(recent, t)
+			}
+		}
+		in.sent[b.key] = recent
+		if b.limit > 0 && len(recent) >= b.limit {
+			return false
+		}
+	}
+	for _, b := range buckets {
+		in.sent[b.key] = append(in.sent[b.key], now)
+	}
+	return true
+}
+
+// Submit stores a message from the contact form and relays it by email when
+// a relay is configured. A failed relay isn't an error as long as the
+// message was stored, the owner will still find it in the inbox.
+func (in *Inbox) Submit(ctx context.Context, from ContactSender, name, replyTo, message string) error {
+	name, replyTo, message, err := CleanContactMessage(name, replyTo, message)
+	if err != nil {
+		return err
+	}
+	if !in.allow(from, time.Now()) {
+		return ErrContactThrottled
+	}
+	in.mu.Lock()
+	if in.closed {
+		in.mu.Unlock()
+		return ErrRestarting
+	}
+	in.pending.Add(1)
+	in.mu.Unlock()
+	defer in.pending.Done()
+
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	msg := ContactMessage{
+		ID:          hex.EncodeToString(id),
+		Time:        time.Now().UTC(),
+		Name:        name,
+		ReplyTo:     replyTo,
+		Message:     message,
+		Fingerprint: from.Fingerprint,
+	}
+
+	var relayErr error
+	if in.smtp.Address != "" {
+		if relayErr = in.relay(ctx, msg); relayErr != nil {
+			log.Printf("Relaying contact message %s: %v", msg.ID, relayErr)
+		}
+		msg.Relayed = relayErr == nil
+	}
+
+	if err := in.store(msg); err != nil {
+		if msg.Relayed {
+			log.Printf("Storing contact message %s: %v", msg.ID, err)
+			return nil
+		}
+		return errors.Join(err, relayErr)
+	}
+	return nil
+}
+
+// Close waits for the messages being sent to be relayed and stored, and turns
+// away any sent after it.
+func (in *Inbox) Close() error {
+	if in == nil {
+		return nil
+	}
+	in.mu.Lock()
+	in.closed = true
+	in.mu.Unlock()
+	in.pending.Wait()
+	return nil
+}
+
+func (in *Inbox) store(msg ContactMessage) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	f, err := os.OpenFile(in.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// relay emails msg to the owner. The visitor goes in Reply-To, never From,
+// so the relay doesn't have to vouch for their address.
+func (in *Inbox) relay(ctx context.Context, msg ContactMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", in.smtp.Address)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(in.smtp.Address)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if in.smtp.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", in.smtp.Username, in.smtp.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(in.smtp.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(in.smtp.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(in.formatMail(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (in *Inbox) formatMail(msg ContactMessage) []byte {
+	from := mail.Address{Name: "clifolio", Address: in.smtp.From}
+	replyTo := mail.Address{Name: msg.Name, Address: msg.ReplyTo}
+
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", from.String())
+	header("To", in.smtp.To)
+	header("Reply-To", replyTo.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", "Portfolio message from "+msg.Name))
+	header("Date", msg.Time.Format(time.RFC1123Z))
+	header("Message-ID", "<"+msg.ID+"@clifolio>")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+
+	body := msg.Message + "\n\n-- \nSent from the clifolio contact form"
+	if msg.Fingerprint != "" {
+		body += " by key " + msg.Fingerprint
+	}
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
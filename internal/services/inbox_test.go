@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"clifolio/internal/config"
+)
+
+// fakeSMTP is an SMTP relay stand-in that accepts every message and keeps
+// what it was sent.
+type fakeSMTP struct {
+	ln       net.Listener
+	from, to chan string
+	data     chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeSMTP{ln: ln, from: make(chan string, 1), to: make(chan string, 1), data: make(chan string, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *fakeSMTP) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 fake")
+		case "MAIL":
+			s.from <- cmd
+			reply("250 ok")
+		case "RCPT":
+			s.to <- cmd
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data <- data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func TestInboxRelay(t *testing.T) {
+	relay := newFakeSMTP(t)
+	path := filepath.Join(t.TempDir(), "inbox.jsonl")
+	in, err := OpenInbox(path, config.Contact{SMTP: config.SMTP{
+		Address: relay.ln.Addr().String(),
+		From:    "folio@example.com",
+		To:      "owner@example.com",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "Zoë\r\nBcc: victim@example.com\x1b[2J"
+	message := "Hello\x1b]52;c;cGF3bmVk\x07 there\r\n\r\nBye\x00"
+	from := ContactSender{Fingerprint: "SHA256:abc", IP: "192.0.2.1"}
+	if err := in.Submit(context.Background(), from, name, "zoe@example.org", message); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	if got := <-relay.from; got != "MAIL FROM:<folio@example.com>" {
+		t.Errorf("envelope sender = %q", got)
+	}
+	if got := <-relay.to; got != "RCPT TO:<owner@example.com>" {
+		t.Errorf("envelope recipient = %q", got)
+	}
+
+	data := <-relay.data
+	if strings.ContainsAny(data, "\x1b\x07\x00") {
+		t.Errorf("control characters reached the relay: %q", data)
+	}
+	head, body, ok := strings.Cut(data, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no blank line between header and body: %q", data)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parsing the relayed mail: %v", err)
+	}
+	if got := msg.Header.Get("Bcc"); got != "" {
+		t.Errorf("the name injected a Bcc header: %q", got)
+	}
+	for _, line := range strings.Split(head, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Errorf("the name injected a header line: %q", line)
+		}
+	}
+
+	replyTo, err := mail.ParseAddress(msg.Header.Get("Reply-To"))
+	if err != nil {
+		t.Fatalf("Reply-To %q: %v", msg.Header.Get("Reply-To"), err)
+	}
+	if replyTo.Address != "zoe@example.org" || replyTo.Name != "Zoë Bcc: victim@example.com[2J" {
+		t.Errorf("Reply-To = %q <%s>", replyTo.Name, replyTo.Address)
+	}
+	if got := msg.Header.Get("Subject"); !strings.HasPrefix(got, "=?utf-8?q?") {
+		t.Errorf("Subject isn't Q-encoded: %q", got)
+	}
+	if got, err := new(mail.AddressParser).Parse(msg.Header.Get("From")); err != nil || got.Address != "folio@example.com" {
+		t.Errorf("From = %q, %v", msg.Header.Get("From"), err)
+	}
+
+	if !strings.HasPrefix(body, "Hello]52;c;cGF3bmVk there\r\n\r\nBye") {
+		t.Errorf("body = %q", body)
+	}
+	if !strings.Contains(body, "by key SHA256:abc") {
+		t.Errorf("body doesn't name the sender's key: %q", body)
+	}
+
+	// The relayed message is still kept in the inbox
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored ContactMessage
+	if err := json.Unmarshal(b, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Relayed {
+		t.Error("the stored message isn't marked as relayed")
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// KeySet is a list of public keys read from an authorized_keys style file.
+type KeySet struct {
+	keys []gossh.PublicKey
+}
+
+// LoadKeySet parses the keys in path. A missing file is an empty set, which
+// keeps owner-only features locked until the owner adds their key.
+func LoadKeySet(path string) (KeySet, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return KeySet{}, nil
+	}
+	if err != nil {
+		return KeySet{}, err
+	}
+
+	var set KeySet
+	for len(bytes.TrimSpace(b)) > 0 {
+		key, _, _, rest, err := gossh.ParseAuthorizedKey(b)
+		if err != nil {
+			// ParseAuthorizedKey skips blank and comment lines itself, so an
+			// error here means nothing usable is left.
+			break
+		}
+		set.keys = append(set.keys, key)
+		b = rest
+	}
+	return set, nil
+}
+
+// Contains reports whether key is in the set.
+func (s KeySet) Contains(key ssh.PublicKey) bool {
+	if key == nil {
+		return false
+	}
+	for _, k := range s.keys {
+		if ssh.KeysEqual(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s KeySet) Len() int {
+	return len(s.keys)
+}
+
+// Fingerprint returns the SHA256 fingerprint of key, or "" for sessions that
+// didn't authenticate with a key.
+func Fingerprint(key ssh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+	return gossh.FingerprintSHA256(key)
+}
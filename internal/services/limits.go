@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clifolio/internal/config"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+var (
+	ErrServerFull    = errors.New("the realm is full right now, please try again in a few minutes")
+	ErrTooManyFromIP = errors.New("you already have several sessions open, close one to start another")
+	ErrRateLimited   = errors.New("easy there, traveller, too many connections from your address, try again shortly")
+	ErrRestarting    = errors.New("the server is restarting, please come back in a minute")
+)
+
+// Limiter admits sessions while the server has room for them. It is safe for
+// concurrent use and can be shared by every frontend that starts sessions.
+type Limiter struct {
+	mu       sync.Mutex
+	limits   config.Limits
+	active   int
+	perIP    map[string]int
+	recent   map[string][]time.Time
+	draining bool
+}
+
+func NewLimiter(limits config.Limits) *Limiter {
+	return &Limiter{
+		limits: limits,
+		perIP:  map[string]int{},
+		recent: map[string][]time.Time{},
+	}
+}
+
+// Admit reserves a session slot for ip. The returned release function must be
+// called when the session ends.
+func (l *Limiter) Admit(ip string) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.draining {
+		return nil, ErrRestarting
+	}
+	now := time.Now()
+	if l.limits.RatePerIP > 0 && l.limits.RateWindow > 0 {
+		l.prune(now)
+		if len(l.recent[ip]) >= l.limits.RatePerIP {
+			return nil, ErrRateLimited
+		}
+	}
+
+	if l.limits.MaxSessions > 0 && l.active >= l.limits.MaxSessions {
+		return nil, ErrServerFull
+	}
+	if l.limits.MaxSessionsPerIP > 0 && l.perIP[ip] >= l.limits.MaxSessionsPerIP {
+		return nil, ErrTooManyFromIP
+	}
+
+	l.active++
+	l.perIP[ip]++
+	// Only admitted sessions count towards the rate, so a refused visitor
+	// isn't locked out for longer by retrying
+	if l.limits.RatePerIP > 0 && l.limits.RateWindow > 0 {
+		l.recent[ip] = append(l.recent[ip], now)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.active--
+			if l.perIP[ip]--; l.perIP[ip] <= 0 {
+				delete(l.perIP, ip)
+			}
+		})
+	}, nil
+}
+
+// Drain turns away every new session from now on, for shutting down.
+func (l *Limiter) Drain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.draining = true
+}
+
+// Active returns the number of sessions currently admitted.
+func (l *Limiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// prune drops connection times that fell out of the rate window.
+func (l *Limiter) prune(now time.Time) {
+	cutoff := now.Add(-l.limits.RateWindow.Std())
+	for ip, times := range l.recent {
+		i := 0
+		for i < len(times) && times[i].Before(cutoff) {
+			i++
+		}
+		if i == len(times) {
+			delete(l.recent, ip)
+		} else {
+			l.recent[ip] = times[i:]
+		}
+	}
+}
+
+// limitedContext lets the limits middleware end a session on its own terms.
+// The bubbletea middleware quits the program when the context is done, which
+// restores the client's terminal before we say goodbye.
+type limitedContext struct {
+	ssh.Context
+	cancelCtx context.Context
+}
+
+func (c limitedContext) Done() <-chan struct{} {
+	return c.cancelCtx.Done()
+}
+
+func (c limitedContext) Err() error {
+	return c.cancelCtx.Err()
+}
+
+// limitedSession tracks when the visitor last typed something.
+type limitedSession struct {
+	ssh.Session
+	ctx   limitedContext
+	watch *sessionWatch
+}
+
+func (s *limitedSession) Context() ssh.Context {
+	return s.ctx
+}
+
+func (s *limitedSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	if n > 0 {
+		s.watch.touch()
+	}
+	return n, err
+}
+
+// sessionWatch ends a session after idle minutes without input or once
+// maxDuration is up, and remembers why for the goodbye.
+type sessionWatch struct {
+	lastInput atomic.Int64
+	reason    atomic.Value
+}
+
+func newSessionWatch() *sessionWatch {
+	w := &sessionWatch{}
+	w.touch()
+	return w
+}
+
+// touch records input from the visitor.
+func (w *sessionWatch) touch() {
+	w.lastInput.Store(time.Now().UnixNano())
+}
+
+func (w *sessionWatch) idleFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, w.lastInput.Load()))
+}
+
+// run calls cancel when a limit is hit, until ctx is done. Zero disables a
+// limit.
+func (w *sessionWatch) run(ctx context.Context, cancel context.CancelFunc, idle, maxDuration time.Duration) {
+	tick := time.NewTicker(5 * time.Second)
+	defer tick.Stop()
+
+	var deadline <-chan time.Time
+	if maxDuration > 0 {
+		timer := time.NewTimer(maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			w.reason.Store(fmt.Sprintf("Your session reached the %s limit. Thanks for visiting!", shortDuration(maxDuration)))
+			cancel()
+			return
+		case now := <-tick.C:
+			if idle > 0 && w.idleFor(now) >= idle {
+				w.reason.Store(fmt.Sprintf("Disconnected after %s without input. Come back any time!", shortDuration(idle)))
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// Reason is the goodbye for a session a limit ended, false if none did.
+func (w *sessionWatch) Reason() (string, bool) {
+	msg, ok := w.reason.Load().(string)
+	return msg, ok
+}
+
+// limitsMiddleware turns away sessions the limiter has no room for, striking
+// their address with access when it's their own doing, and ends admitted
+// ones after idle minutes without input or once maxDuration is up.
+func limitsMiddleware(limiter *Limiter, access *Access, idle, maxDuration time.Duration) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			ip := RemoteIP(s.RemoteAddr())
+			release, err := limiter.Admit(ip)
+			if err != nil {
+				log.Printf("Rejected session from %s: %v", ip, err)
+				access.Refused(ip, err)
+				wish.Fatalln(s, "Sorry, "+err.Error()+".")
+				return
+			}
+			defer release()
+
+			ctx, cancel := context.WithCancel(s.Context())
+			defer cancel()
+
+			ls := &limitedSession{Session: s, ctx: limitedContext{Context: s.Context(), cancelCtx: ctx}, watch: newSessionWatch()}
+			go ls.watch.run(ctx, cancel, idle, maxDuration)
+
+			next(ls)
+
+			if msg, ok := ls.watch.Reason(); ok {
+				log.Printf("Ended session from %s: %s", ip, msg)
+				wish.Println(s, msg)
+			}
+		}
+	}
+}
+
+// shortDuration prints 10m rather than 10m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
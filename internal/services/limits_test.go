@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+func TestLimiterAdmit(t *testing.T) {
+	type step struct {
+		ip string
+		// release ends the session straight after it's admitted
+		release bool
+		want    error
+	}
+	tests := []struct {
+		name   string
+		limits config.Limits
+		drain  bool
+		steps  []step
+	}{
+		{"no limits", config.Limits{}, false, []step{
+			{"192.0.2.1", false, nil},
+			{"192.0.2.1", false, nil},
+			{"192.0.2.1", false, nil},
+		}},
+		{"server full", config.Limits{MaxSessions: 2}, false, []step{
+			{"192.0.2.1", false, nil},
+			{"192.0.2.2", true, nil},
+			{"192.0.2.3", false, nil},
+			{"192.0.2.4", false, ErrServerFull},
+		}},
+		{"per address", config.Limits{MaxSessionsPerIP: 2}, false, []step{
+			{"192.0.2.1", false, nil},
+			{"192.0.2.1", false, nil},
+			{"192.0.2.1", false, ErrTooManyFromIP},
+			{"192.0.2.2", false, nil},
+		}},
+		{"rate per address", config.Limits{RatePerIP: 2, RateWindow: config.Duration(time.Minute)}, false, []step{
+			{"192.0.2.1", true, nil},
+			{"192.0.2.1", true, nil},
+			{"192.0.2.1", true, ErrRateLimited},
+			{"192.0.2.2", true, nil},
+		}},
+		// Refusals don't count towards the rate, or retrying would extend it
+		{"refused sessions off the rate", config.Limits{RatePerIP: 2, RateWindow: config.Duration(time.Minute), MaxSessionsPerIP: 1}, false, []step{
+			{"192.0.2.1", false, nil},
+			{"192.0.2.1", false, ErrTooManyFromIP},
+			{"192.0.2.1", false, ErrTooManyFromIP},
+			{"192.0.2.2", false, nil},
+		}},
+		{"draining", config.Limits{}, true, []step{
+			{"192.0.2.1", false, ErrRestarting},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLimiter(tt.limits)
+			if tt.drain {
+				l.Drain()
+			}
+			for i, s := range tt.steps {
+				release, err := l.Admit(s.ip)
+				if !errors.Is(err, s.want) {
+					t.Fatalf("step %d: Admit(%q) = %v, want %v", i, s.ip, err, s.want)
+				}
+				if err == nil && s.release {
+					release()
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterRelease(t *testing.T) {
+	l := NewLimiter(config.Limits{MaxSessions: 1, MaxSessionsPerIP: 1})
+	release, err := l.Admit("192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	release()
+	if n := l.Active(); n != 0 {
+		t.Errorf("%d sessions active after releasing the only one twice", n)
+	}
+	if _, ok := l.perIP["192.0.2.1"]; ok {
+		t.Error("the address is still counted after its session ended")
+	}
+	if _, err := l.Admit("192.0.2.1"); err != nil {
+		t.Errorf("Admit() after release = %v", err)
+	}
+}
+
+func TestLimiterRateWindow(t *testing.T) {
+	l := NewLimiter(config.Limits{RatePerIP: 1, RateWindow: config.Duration(time.Minute)})
+	if _, err := l.Admit("192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Admit("192.0.2.1"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second Admit() = %v, want %v", err, ErrRateLimited)
+	}
+
+	// Once the window has passed the address is let in again and forgotten
+	l.recent["192.0.2.1"][0] = l.recent["192.0.2.1"][0].Add(-time.Minute - time.Second)
+	l.prune(time.Now())
+	if _, ok := l.recent["192.0.2.1"]; ok {
+		t.Error("an address with no connections in the window is still kept")
+	}
+	if _, err := l.Admit("192.0.2.1"); err != nil {
+		t.Errorf("Admit() after the window = %v", err)
+	}
+}
+
+func TestSessionWatchMaxDuration(t *testing.T) {
+	w := newSessionWatch()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.run(ctx, cancel, time.Hour, 10*time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session outlived its maximum duration")
+	}
+	if ctx.Err() == nil {
+		t.Error("the session's context wasn't cancelled")
+	}
+	if msg, ok := w.Reason(); !ok || !strings.Contains(msg, "10ms limit") {
+		t.Errorf("Reason() = %q, %v", msg, ok)
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{10 * time.Minute, "10m"},
+		{2 * time.Hour, "2h"},
+		{90 * time.Minute, "1h30m"},
+		{45 * time.Second, "45s"},
+		{time.Minute + 5*time.Second, "1m5s"},
+	}
+	for _, tt := range tests {
+		if got := shortDuration(tt.d); got != tt.want {
+			t.Errorf("shortDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
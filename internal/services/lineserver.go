@@ -0,0 +1,69 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lineServer runs the small protocols that read one request line and
+// answer it: finger, Gopher and Gemini.
+type lineServer struct {
+	name    string
+	limiter *Limiter
+	// maxLine is the longest request, its line ending included.
+	maxLine int
+	// busy is the reply for clients the limiter turns away.
+	busy func(err error) string
+	// handle answers a request line, given without its line ending.
+	handle func(w io.Writer, ip, line string)
+}
+
+// serve accepts connections on ln until ctx is done, then closes it and
+// returns once the requests in flight are answered.
+func (s lineServer) serve(ctx context.Context, ln net.Listener) {
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Printf("%s server stopped", s.name)
+			} else {
+				log.Printf("%s server stopped: %v", s.name, err)
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s lineServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	ip := RemoteIP(conn.RemoteAddr())
+	release, err := s.limiter.Admit(ip)
+	if err != nil {
+		_, _ = io.WriteString(conn, s.busy(err))
+		return
+	}
+	defer release()
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	line, err := bufio.NewReader(io.LimitReader(conn, int64(s.maxLine))).ReadString('\n')
+	if err != nil {
+		return
+	}
+	s.handle(conn, ip, strings.TrimRight(line, "\r\n"))
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+// startLineServer serves s on ln until the test ends.
+func startLineServer(t *testing.T, s lineServer, ln net.Listener) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.serve(ctx, ln)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// listenLocal listens on a free loopback port.
+func listenLocal(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+// request sends one request line over conn and reads the reply until the
+// server hangs up.
+func request(t *testing.T, conn net.Conn, line string) string {
+	t.Helper()
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, line+"\r\n"); err != nil {
+		t.Fatalf("sending %q: %v", line, err)
+	}
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("reading the reply to %q: %v", line, err)
+	}
+	return string(b)
+}
+
+func dialLocal(t *testing.T, ln net.Listener) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestLineServerShutdown(t *testing.T) {
+	limiter := NewLimiter(config.Limits{})
+	s := lineServer{
+		name:    "Echo",
+		limiter: limiter,
+		maxLine: 64,
+		busy:    func(err error) string { return "busy: " + err.Error() },
+		handle:  func(w io.Writer, ip, line string) { _, _ = io.WriteString(w, "echo: "+line) },
+	}
+	ln := listenLocal(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		s.serve(ctx, ln)
+		close(done)
+	}()
+
+	if got := request(t, dialLocal(t, ln), "hello"); got != "echo: hello" {
+		t.Errorf("reply = %q", got)
+	}
+
+	// Draining turns clients away with the busy reply, before they've sent
+	// anything
+	limiter.Drain()
+	conn := dialLocal(t, ln)
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	b, err := io.ReadAll(conn)
+	conn.Close()
+	if got := string(b); err != nil || got != "busy: "+ErrRestarting.Error() {
+		t.Errorf("reply while draining = %q, %v", got, err)
+	}
+
+	// Stopping closes the listener
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve didn't return after ctx was done")
+	}
+	if conn, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("the listener is still open after the server stopped")
+	}
+}
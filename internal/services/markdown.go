@@ -1,6 +1,20 @@
 package services
 
-import "github.com/charmbracelet/glamour"
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/muesli/termenv"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
 
 func GenerateMarkdown(md string) (string, error) {
 	r, err := glamour.NewTermRenderer(
@@ -18,6 +32,29 @@ func GenerateMarkdown(md string) (string, error) {
 	return out, nil
 }
 
+// RenderMarkdownFor renders md for a terminal with the given color profile
+// and background, instead of guessing from the server's own stdout.
+func RenderMarkdownFor(md string, profile termenv.Profile, dark bool) (string, error) {
+	style := "dark"
+	switch {
+	case profile == termenv.Ascii:
+		style = "notty"
+	case !dark:
+		style = "light"
+	}
+
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(style),
+		glamour.WithColorProfile(profile),
+		glamour.WithWordWrap(80),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return r.Render(md)
+}
+
 func RenderMarkdown(md string) (string, error) {
 	r, _ := glamour.NewTermRenderer(
 		glamour.WithStandardStyle("dark"),
@@ -30,4 +67,161 @@ func RenderMarkdown(md string) (string, error) {
 	}
 
 	return out, nil
-}
\ No newline at end of file
+}
+
+var (
+	mdImagePattern   = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImagePattern = regexp.MustCompile(`(?i)<img[^>]*\ssrc\s*=\s*["']([^"']+)["'][^>]*>`)
+)
+
+// maxReadmeImages bounds how many images one README can pull in.
+const maxReadmeImages = 6
+
+// readmeImageTimeout is how long each README image may take. They're fetched
+// together, so one slow host leaves its image as alt text without holding up
+// the others.
+const readmeImageTimeout = 5 * time.Second
+
+// RenderReadme renders a repository README and draws its images inline.
+// Relative image paths are resolved against the repository's default
+// branch. Images that can't be fetched or decoded (SVG badges, for example),
+// or that are hosted off GitHub, are left as their alt text.
+func RenderReadme(ctx context.Context, md string, repo Repo, opts ImageOptions, dark bool) (string, error) {
+	type placeholder struct {
+		token string
+		alt   string
+		src   string
+	}
+	var images []placeholder
+
+	replace := func(alt, src string) string {
+		if len(images) >= maxReadmeImages {
+			return alt
+		}
+		token := fmt.Sprintf("CLIFOLIOIMAGE%d", len(images))
+		images = append(images, placeholder{token: token, alt: alt, src: resolveImageURL(repo, src)})
+		return "\n\n" + token + "\n\n"
+	}
+
+	md = replaceImages(md, replace)
+
+	out, err := RenderMarkdownFor(md, opts.Profile, dark)
+	if err != nil {
+		return "", err
+	}
+
+	arts := make([]string, len(images))
+	var wg sync.WaitGroup
+	for i, p := range images {
+		arts[i] = "[image]"
+		if p.alt != "" {
+			arts[i] = "[image: " + p.alt + "]"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, readmeImageTimeout)
+			defer cancel()
+			if img, err := FetchImage(ctx, p.src); err == nil {
+				arts[i] = RenderImage(img, opts)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(out, "\n")
+	for n, p := range images {
+		art := arts[n]
+		for i, line := range lines {
+			if strings.Contains(line, p.token) {
+				lines[i] = "  " + strings.ReplaceAll(art, "\n", "\n  ")
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// replaceImages swaps the images in md for what replace returns. Images in
+// code are left alone, and so are those wrapped in a link, like most
+// badges, since cutting them out would leave the link's brackets behind.
+func replaceImages(md string, replace func(alt, src string) string) string {
+	type match struct {
+		start, end int
+		alt, src   string
+	}
+	var matches []match
+	for _, m := range mdImagePattern.FindAllStringSubmatchIndex(md, -1) {
+		if m[0] > 0 && md[m[0]-1] == '[' && strings.HasPrefix(md[m[1]:], "](") {
+			continue
+		}
+		matches = append(matches, match{m[0], m[1], md[m[2]:m[3]], md[m[4]:m[5]]})
+	}
+	for _, m := range htmlImagePattern.FindAllStringSubmatchIndex(md, -1) {
+		matches = append(matches, match{m[0], m[1], "", md[m[2]:m[3]]})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	code := codeRanges(md)
+	inCode := func(m match) bool {
+		for _, r := range code {
+			if m.start < r[1] && r[0] < m.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.start < last || inCode(m) {
+			continue
+		}
+		b.WriteString(md[last:m.start])
+		b.WriteString(replace(m.alt, m.src))
+		last = m.end
+	}
+	b.WriteString(md[last:])
+	return b.String()
+}
+
+// codeRanges returns the byte ranges of md's code blocks and code spans.
+func codeRanges(md string) [][2]int {
+	src := []byte(md)
+	doc := goldmark.DefaultParser().Parse(text.NewReader(src))
+
+	var ranges [][2]int
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			if lines.Len() > 0 {
+				ranges = append(ranges, [2]int{lines.At(0).Start, lines.At(lines.Len() - 1).Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			first, last := n.FirstChild(), n.LastChild()
+			if t, ok := first.(*ast.Text); ok {
+				if u, ok := last.(*ast.Text); ok {
+					ranges = append(ranges, [2]int{t.Segment.Start, u.Segment.Stop})
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+func resolveImageURL(repo Repo, src string) string {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return src
+	}
+	src = strings.TrimPrefix(strings.TrimPrefix(src, "./"), "/")
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/HEAD/%s", repo.Owner, repo.Name, src)
+}
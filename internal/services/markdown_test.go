@@ -0,0 +1,50 @@
+package services
+
+import "testing"
+
+func TestReplaceImages(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"image", "a ![logo](img/logo.png) b", "a <logo|img/logo.png> b"},
+		{"with a title", `![logo](logo.png "The logo")`, "<logo|logo.png>"},
+		{"in angle brackets", "![logo](<logo.png>)", "<logo|logo.png>"},
+		{"HTML", `<p><img width="40" src="logo.png" alt="x"></p>`, "<p><|logo.png></p>"},
+		{"in a link", "[![build](https://ci.example/badge.svg)](https://ci.example)", "[![build](https://ci.example/badge.svg)](https://ci.example)"},
+		{"next to a link", "[docs](d.md) ![logo](logo.png)", "[docs](d.md) <logo|logo.png>"},
+		{"in a code span", "Write `![alt](src)` for images", "Write `![alt](src)` for images"},
+		{"HTML in a code span", "Use `<img src=\"x.png\">`", "Use `<img src=\"x.png\">`"},
+		{"in a fenced block", "```md\n![logo](logo.png)\n```\n", "```md\n![logo](logo.png)\n```\n"},
+		{"in an indented block", "text\n\n    ![logo](logo.png)\n", "text\n\n    ![logo](logo.png)\n"},
+		{"after a code block", "```\ncode\n```\n\n![logo](logo.png)\n", "```\ncode\n```\n\n<logo|logo.png>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replaceImages(tt.md, func(alt, src string) string { return "<" + alt + "|" + src + ">" })
+			if got != tt.want {
+				t.Errorf("replaceImages(%q) = %q, want %q", tt.md, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveImageURL(t *testing.T) {
+	repo := Repo{Owner: "octo", Name: "folio"}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"docs/shot.png", "https://raw.githubusercontent.com/octo/folio/HEAD/docs/shot.png"},
+		{"./docs/shot.png", "https://raw.githubusercontent.com/octo/folio/HEAD/docs/shot.png"},
+		{"/docs/shot.png", "https://raw.githubusercontent.com/octo/folio/HEAD/docs/shot.png"},
+		{"https://user-images.githubusercontent.com/1/shot.png", "https://user-images.githubusercontent.com/1/shot.png"},
+		{"http://example.com/shot.png", "http://example.com/shot.png"},
+	}
+	for _, tt := range tests {
+		if got := resolveImageURL(repo, tt.src); got != tt.want {
+			t.Errorf("resolveImageURL(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clifolio/internal/config"
+	"clifolio/internal/state"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics are collected whether or not anything scrapes them, they cost
+// next to nothing. They're served by StartMetricsServer.
+var (
+	metrics = prometheus.NewRegistry()
+
+	activeSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clifolio",
+		Name:      "sessions_active",
+		Help:      "Interactive sessions connected right now, by transport.",
+	}, []string{"transport"})
+
+	sessionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "clifolio",
+		Name:      "session_duration_seconds",
+		Help:      "How long interactive sessions lasted, by transport.",
+		Buckets:   []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"transport"})
+
+	screenViews = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "clifolio",
+		Name:      "screen_views_total",
+		Help:      "Times a screen was opened.",
+	}, []string{"screen"})
+
+	renderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "clifolio",
+		Name:      "render_duration_seconds",
+		Help:      "Time spent drawing a frame, by screen.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"screen"})
+
+	githubRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "clifolio",
+		Name:      "github_requests_total",
+		Help:      "Calls to the GitHub API, by endpoint and HTTP status.",
+	}, []string{"endpoint", "status"})
+
+	// The rate limit gauges have no labels, they're vectors so they only
+	// show up once GitHub has told us the numbers rather than reading 0
+	githubRateRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clifolio",
+		Name:      "github_rate_limit_remaining",
+		Help:      "GitHub API calls left in the current rate limit window, as of the last call.",
+	}, nil)
+
+	githubRateLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "clifolio",
+		Name:      "github_rate_limit",
+		Help:      "GitHub API calls allowed per rate limit window, as of the last call.",
+	}, nil)
+
+	// The cache counts in atomics so the hit ratio can be derived from them
+	cacheHits, cacheMisses atomic.Uint64
+)
+
+func init() {
+	metrics.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		activeSessions,
+		sessionDuration,
+		screenViews,
+		renderDuration,
+		githubRequests,
+		githubRateRemaining,
+		githubRateLimit,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "clifolio",
+			Name:      "github_cache_hits_total",
+			Help:      "GitHub lookups answered by the cache.",
+		}, func() float64 { return float64(cacheHits.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "clifolio",
+			Name:      "github_cache_misses_total",
+			Help:      "GitHub lookups that had to wait for the API.",
+		}, func() float64 { return float64(cacheMisses.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "clifolio",
+			Name:      "github_cache_hit_ratio",
+			Help:      "Share of GitHub lookups answered by the cache since the process started.",
+		}, func() float64 {
+			hits, misses := cacheHits.Load(), cacheMisses.Load()
+			if hits+misses == 0 {
+				return 0
+			}
+			return float64(hits) / float64(hits+misses)
+		}),
+	)
+}
+
+// ObserveRender records how long a frame of screen took to draw, from
+// start until now. It's meant to be deferred at the top of a View.
+func ObserveRender(screen state.Screen, start time.Time) {
+	renderDuration.WithLabelValues(screen.String()).Observe(time.Since(start).Seconds())
+}
+
+// countedTransport counts the GitHub API calls going through it and notes
+// the rate limit headers of their responses.
+type countedTransport struct {
+	base http.RoundTripper
+}
+
+func (t countedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+		if n, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining")); err == nil {
+			githubRateRemaining.WithLabelValues().Set(float64(n))
+		}
+		if n, err := strconv.Atoi(res.Header.Get("X-RateLimit-Limit")); err == nil {
+			githubRateLimit.WithLabelValues().Set(float64(n))
+		}
+	}
+	githubRequests.WithLabelValues(githubEndpoint(req.URL.Path), status).Inc()
+	return res, err
+}
+
+// githubEndpoint names the API endpoint a path is for without the user and
+// repository names in it, so labels stay few: "users/repos", "repos/readme".
+func githubEndpoint(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case parts[0] == "users" && len(parts) == 2:
+		return "users"
+	case parts[0] == "users" && len(parts) > 2:
+		return "users/" + parts[2]
+	case parts[0] == "repos" && len(parts) == 3:
+		return "repos"
+	case parts[0] == "repos" && len(parts) > 3:
+		return "repos/" + parts[3]
+	case parts[0] == "rate_limit":
+		return parts[0]
+	}
+	return "other"
+}
+
+// readiness remembers which servers have started listening, and whether
+// they're shutting down, for /readyz.
+var readiness = struct {
+	sync.Mutex
+	listening map[string]bool
+	draining  bool
+}{listening: map[string]bool{}}
+
+// serverListening marks the server called name as taking connections.
+func serverListening(name string) {
+	readiness.Lock()
+	defer readiness.Unlock()
+	readiness.listening[name] = true
+}
+
+// serversDraining marks the servers as turning visitors away until the
+// process exits, so load balancers stop sending them.
+func serversDraining() {
+	readiness.Lock()
+	defer readiness.Unlock()
+	readiness.draining = true
+}
+
+// notReady says which of the servers aren't taking connections yet, nil
+// once they all are.
+func notReady(servers []string) error {
+	readiness.Lock()
+	defer readiness.Unlock()
+	if readiness.draining {
+		return errors.New("draining for shutdown")
+	}
+	var waiting []string
+	for _, name := range servers {
+		if !readiness.listening[name] {
+			waiting = append(waiting, name)
+		}
+	}
+	if len(waiting) > 0 {
+		return fmt.Errorf("waiting for %s", strings.Join(waiting, ", "))
+	}
+	return nil
+}
+
+// MetricsServerOptions holds what the health endpoints check.
+type MetricsServerOptions struct {
+	// Servers are the ones /readyz waits for, by the names the Start
+	// functions give them: "ssh", "web", "gopher" and "gemini".
+	Servers []string
+}
+
+// NewMetricsHandler serves /metrics for Prometheus, /healthz, which is OK
+// while the process is up, and /readyz, which is OK once the servers are
+// taking visitors.
+func NewMetricsHandler(o MetricsServerOptions) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(metrics, promhttp.HandlerOpts{}))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := notReady(o.Servers); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// StartMetricsServer serves the metrics and health endpoints on
+// cfg.Metrics.Address until the process exits. It gives up with a log line
+// when it can't listen, so monitoring can't keep visitors out.
+func StartMetricsServer(cfg config.Config, o MetricsServerOptions) {
+	ln, err := net.Listen("tcp", cfg.Metrics.Address)
+	if err != nil {
+		log.Printf("Metrics server disabled: %v", err)
+		return
+	}
+	log.Printf("Starting metrics server on %s", cfg.Metrics.Address)
+	srv := &http.Server{
+		Handler:           NewMetricsHandler(o),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Metrics server stopped: %v", err)
+	}
+}
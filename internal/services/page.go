@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrPageNotFound = errors.New("no such page")
+
+// PageKind is what a line of a Page is.
+type PageKind int
+
+const (
+	// PageText is a paragraph, wrapped by whoever shows it.
+	PageText PageKind = iota
+	PageHeading
+	// PageItem is one entry of a list.
+	PageItem
+	// PageLink goes to Target, another page's path like "/projects" or a
+	// URL.
+	PageLink
+	// PagePre is preformatted text shown as it is, like a README.
+	PagePre
+)
+
+type PageLine struct {
+	Kind   PageKind
+	Text   string
+	Target string
+}
+
+// Page is one page of the portfolio's content tree, which the Gopher server
+// serves as a menu and the Gemini server as gemtext.
+type Page struct {
+	Title string
+	Lines []PageLine
+}
+
+// PageSource builds the page at path, which starts with "/" for the
+// profile at the root. Paths outside the tree give ErrPageNotFound.
+type PageSource func(ctx context.Context, path string) (Page, error)
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"clifolio/internal/config"
+
+	proxyproto "github.com/pires/go-proxyproto"
+)
+
+// listen opens a TCP listener on addr. With trusted proxies configured,
+// connections from them may start with a PROXY protocol v1 or v2 header,
+// and the client address it gives replaces theirs everywhere RemoteAddr is
+// read: logging, the limits, analytics and the hub.
+func listen(cfg config.Config, addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Proxy.TrustedProxies) == 0 {
+		return ln, nil
+	}
+
+	trusted, err := parseCIDRs(cfg.Proxy.TrustedProxies)
+	if err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+	return &proxyproto.Listener{
+		Listener: ln,
+		// Anyone else's connections are left alone, a header they send is
+		// never parsed, so it can't pass them off as another address
+		Policy: func(upstream net.Addr) (proxyproto.Policy, error) {
+			if inNets(trusted, RemoteIP(upstream)) {
+				return proxyproto.USE, nil
+			}
+			return proxyproto.SKIP, nil
+		},
+		// The proxy sends its header as soon as it connects, this only
+		// matters for its health checks, which send nothing
+		ReadHeaderTimeout: 5 * time.Second,
+	}, nil
+}
+
+// parseCIDRs reads networks like "10.0.0.0/8", taking a bare address as a
+// network of one.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range cidrs {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("%q isn't an address or CIDR", s)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		in   string
+		ip   string
+		want bool
+		err  bool
+	}{
+		{"10.0.0.0/8", "10.1.2.3", true, false},
+		{"10.0.0.0/8", "11.0.0.1", false, false},
+		{" 192.0.2.1 ", "192.0.2.1", true, false},
+		{"192.0.2.1", "192.0.2.2", false, false},
+		{"::1", "::1", true, false},
+		{"2001:db8::/32", "2001:db8::7", true, false},
+		{"bogus", "", false, true},
+		{"10.0.0.0/33", "", false, true},
+	}
+	for _, tt := range tests {
+		nets, err := parseCIDRs([]string{tt.in})
+		if (err != nil) != tt.err {
+			t.Errorf("parseCIDRs(%q) = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && inNets(nets, tt.ip) != tt.want {
+			t.Errorf("%q containing %s = %v, want %v", tt.in, tt.ip, !tt.want, tt.want)
+		}
+	}
+}
+
+func TestListenTrustedProxies(t *testing.T) {
+	const header = "PROXY TCP4 203.0.113.7 192.0.2.1 40000 70\r\n"
+	tests := []struct {
+		name    string
+		trusted []string
+		send    string
+		// remote is the address the server sees, line the first line it reads
+		remote string
+		line   string
+	}{
+		{"trusted proxy", []string{"127.0.0.1"}, header + "hello\r\n", "203.0.113.7", "hello\r\n"},
+		{"trusted network", []string{"127.0.0.0/8"}, header + "hello\r\n", "203.0.113.7", "hello\r\n"},
+		{"trusted proxy's health check", []string{"127.0.0.1"}, "hello\r\n", "127.0.0.1", "hello\r\n"},
+		// Anyone else's header is just what they sent
+		{"untrusted client", []string{"10.0.0.0/8"}, header + "hello\r\n", "127.0.0.1", header},
+		{"no proxies", nil, header + "hello\r\n", "127.0.0.1", header},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Proxy.TrustedProxies = tt.trusted
+			ln, err := listen(cfg, "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+
+			go func() {
+				conn, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = io.WriteString(conn, tt.send)
+				_, _ = io.Copy(io.Discard, conn)
+			}()
+
+			conn, err := ln.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line != tt.line {
+				t.Errorf("first line = %q, want %q", line, tt.line)
+			}
+			if got := RemoteIP(conn.RemoteAddr()); got != tt.remote {
+				t.Errorf("remote address = %s, want %s", got, tt.remote)
+			}
+		})
+	}
+
+	cfg := config.Default()
+	cfg.Proxy.TrustedProxies = []string{"not a network"}
+	if ln, err := listen(cfg, "127.0.0.1:0"); err == nil {
+		ln.Close()
+		t.Error("listen() took a bad trusted proxy list")
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrQuietZone is the blank margin, in modules, the QR spec asks for around
+// a code so scanners can find it.
+const qrQuietZone = 4
+
+var ErrQRTooLarge = errors.New("the window is too small for this QR code")
+
+// QRCode is a QR code's modules, true for dark, quiet zone included.
+type QRCode [][]bool
+
+// EncodeQR encodes text as a QR code that fits maxCols by maxRows terminal
+// cells when drawn with half blocks, two modules to a cell vertically. Error
+// correction is lowered before giving up with ErrQRTooLarge. Everything is
+// computed locally.
+func EncodeQR(text string, maxCols, maxRows int) (QRCode, error) {
+	var cols, rows int
+	for _, level := range []qrcode.RecoveryLevel{qrcode.Medium, qrcode.Low} {
+		q, err := qrcode.New(text, level)
+		if err != nil {
+			return nil, err
+		}
+		// Draw the quiet zone here so its width doesn't depend on the library
+		q.DisableBorder = true
+		code := padQR(q.Bitmap(), qrQuietZone)
+		cols, rows = code.Size()
+		if cols <= maxCols && rows <= maxRows {
+			return code, nil
+		}
+	}
+	return nil, fmt.Errorf("%w (%d×%d cells)", ErrQRTooLarge, cols, rows)
+}
+
+func padQR(modules [][]bool, quiet int) QRCode {
+	n := len(modules) + 2*quiet
+	code := make(QRCode, n)
+	for y := range code {
+		code[y] = make([]bool, n)
+		if y >= quiet && y < n-quiet {
+			copy(code[y][quiet:], modules[y-quiet])
+		}
+	}
+	return code
+}
+
+// Size is how many terminal cells the code takes when drawn.
+func (q QRCode) Size() (cols, rows int) {
+	return len(q), (len(q) + 1) / 2
+}
+
+// HalfBlocks draws the code with a block wherever a module is dark, meant
+// for dark text on a light background. Invert swaps them for terminals that
+// can't be given colors, so a dark background shows through as dark modules.
+func (q QRCode) HalfBlocks(invert bool) string {
+	dark := func(x, y int) bool {
+		return y < len(q) && q[y][x] != invert
+	}
+
+	var b strings.Builder
+	for y := 0; y < len(q); y += 2 {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		for x := range q[y] {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"clifolio/internal/config"
+)
+
+const castExt = ".cast"
+
+var ErrRecordingNotFound = errors.New("no such recording")
+
+// castHeader is the first line of an asciicast v2 file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// RecordingInfo describes a stored recording without loading its events.
+type RecordingInfo struct {
+	Name     string
+	Start    time.Time
+	Visitor  string
+	Term     string
+	Width    int
+	Height   int
+	Size     int64
+	Duration time.Duration
+}
+
+// CastEvent is one entry in a recording: output ("o") or a resize ("r",
+// with Data like "120x40").
+type CastEvent struct {
+	Time time.Duration
+	Type string
+	Data string
+}
+
+// Cast is a loaded recording.
+type Cast struct {
+	RecordingInfo
+	Events []CastEvent
+}
+
+// Recorder keeps session recordings in a directory, enforcing the size caps
+// and retention from the config.
+type Recorder struct {
+	mu        sync.Mutex
+	dir       string
+	maxSize   int
+	maxTotal  int64
+	retention time.Duration
+	active    map[string]*Recording
+	closed    bool
+}
+
+func OpenRecorder(dir string, cfg config.Recording) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		dir:       dir,
+		maxSize:   cfg.MaxSize,
+		maxTotal:  int64(cfg.MaxTotal),
+		retention: cfg.Retention.Std(),
+		active:    map[string]*Recording{},
+	}
+	r.prune()
+	return r, nil
+}
+
+// Start begins recording a session. Visitor labels the recording, it's the
+// key fingerprint or hashed address the analytics use.
+func (r *Recorder) Start(id, visitor, term string, width, height int) (*Recording, error) {
+	now := time.Now()
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	name := now.UTC().Format("20060102-150405") + "-" + id + castExt
+
+	f, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	width, height = ClampTermSize(width, height)
+	rec := &Recording{
+		Name:     name,
+		recorder: r,
+		f:        f,
+		start:    now,
+		max:      r.maxSize,
+	}
+
+	header, _ := json.Marshal(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: now.Unix(),
+		Title:     cleanClientLabel(visitor),
+		Env:       map[string]string{"TERM": cleanClientLabel(term)},
+	})
+	rec.write(append(header, '\n'))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		f.Close()
+		_ = os.Remove(f.Name())
+		return nil, os.ErrClosed
+	}
+	r.active[name] = rec
+	return rec, nil
+}
+
+// Close ends the recordings of sessions that are still open, so they're
+// complete on disk, and turns away any started after it.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	r.closed = true
+	active := make([]*Recording, 0, len(r.active))
+	for _, rec := range r.active {
+		active = append(active, rec)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, rec := range active {
+		errs = append(errs, rec.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// List returns the stored recordings, newest first.
+func (r *Recorder) List() ([]RecordingInfo, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []RecordingInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), castExt) {
+			continue
+		}
+		info, err := r.info(e.Name())
+		if err != nil {
+			continue
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.After(out[j].Start) })
+	return out, nil
+}
+
+func (r *Recorder) info(name string) (RecordingInfo, error) {
+	path := filepath.Join(r.dir, name)
+	st, err := os.Stat(path)
+	if err != nil {
+		return RecordingInfo{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return RecordingInfo{}, err
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return RecordingInfo{}, err
+	}
+	var h castHeader
+	if err := json.Unmarshal(line, &h); err != nil || h.Version != 2 {
+		return RecordingInfo{}, fmt.Errorf("%s isn't an asciicast v2 file", name)
+	}
+
+	// Files written before sizes were clamped and labels cleaned, or by
+	// something else, are held to the same limits
+	width, height := ClampTermSize(h.Width, h.Height)
+	start := time.Unix(h.Timestamp, 0)
+	return RecordingInfo{
+		Name:     name,
+		Start:    start,
+		Visitor:  cleanClientLabel(h.Title),
+		Term:     cleanClientLabel(h.Env["TERM"]),
+		Width:    width,
+		Height:   height,
+		Size:     st.Size(),
+		Duration: max(0, st.ModTime().Sub(start).Round(time.Second)),
+	}, nil
+}
+
+// Load reads a recording and all its events. Lines that fail to parse,
+// such as the last one of a recording cut short by a crash, are skipped.
+func (r *Recorder) Load(name string) (*Cast, error) {
+	if name != filepath.Base(name) || !strings.HasSuffix(name, castExt) {
+		return nil, ErrRecordingNotFound
+	}
+	info, err := r.info(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrRecordingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(r.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cast := &Cast{RecordingInfo: info}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	sc.Scan() // the header, already read
+	for sc.Scan() {
+		var ev [3]any
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			continue
+		}
+		t, ok1 := ev[0].(float64)
+		typ, ok2 := ev[1].(string)
+		data, ok3 := ev[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			continue
+		}
+		cast.Events = append(cast.Events, CastEvent{
+			Time: time.Duration(t * float64(time.Second)),
+			Type: typ,
+			Data: data,
+		})
+	}
+	if n := len(cast.Events); n > 0 {
+		cast.Duration = cast.Events[n-1].Time.Round(time.Second)
+	}
+	return cast, sc.Err()
+}
+
+// prune deletes recordings past the retention period, then the oldest ones
+// until the rest fit in the total size cap. Recordings still being written
+// are left alone.
+func (r *Recorder) prune() {
+	list, err := r.List()
+	if err != nil {
+		log.Printf("Pruning recordings: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total int64
+	for _, info := range list {
+		total += info.Size
+	}
+	// List is newest first, so walk it backwards
+	for i := len(list) - 1; i >= 0; i-- {
+		info := list[i]
+		expired := r.retention > 0 && time.Since(info.Start) > r.retention
+		over := r.maxTotal > 0 && total > r.maxTotal
+		if r.active[info.Name] != nil || (!expired && !over) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.dir, info.Name)); err != nil {
+			log.Printf("Pruning recordings: %v", err)
+			continue
+		}
+		total -= info.Size
+	}
+}
+
+// Recording writes one session's output as asciicast events. It never
+// fails a write, so a full disk can't break the session it's recording.
+type Recording struct {
+	Name string
+
+	mu       sync.Mutex
+	recorder *Recorder
+	f        *os.File
+	start    time.Time
+	size     int
+	max      int
+	partial  []byte
+	stopped  bool
+	closed   bool
+}
+
+func (rec *Recording) Write(p []byte) (int, error) {
+	if rec == nil {
+		return len(p), nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	// Hold back a rune split across writes so the event stays valid UTF-8
+	data := append(rec.partial, p...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	rec.partial = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		rec.event("o", string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// Resize records that the visitor's terminal changed size.
+func (rec *Recording) Resize(width, height int) {
+	if rec == nil {
+		return
+	}
+	width, height = ClampTermSize(width, height)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (rec *Recording) event(typ, data string) {
+	t := time.Since(rec.start).Seconds()
+	b, _ := json.Marshal([]any{float64(int64(t*1e6)) / 1e6, typ, data})
+	rec.write(append(b, '\n'))
+}
+
+func (rec *Recording) write(b []byte) {
+	if rec.stopped {
+		return
+	}
+	if rec.max > 0 && rec.size+len(b) > rec.max {
+		log.Printf("Recording %s reached its size cap, stopping it", rec.Name)
+		rec.stopped = true
+		return
+	}
+	if _, err := rec.f.Write(b); err != nil {
+		log.Printf("Recording %s: %v", rec.Name, err)
+		rec.stopped = true
+		return
+	}
+	rec.size += len(b)
+}
+
+// Close finishes the file and applies the retention policy.
+func (rec *Recording) Close() error {
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	if rec.closed {
+		rec.mu.Unlock()
+		return nil
+	}
+	rec.stopped, rec.closed = true, true
+	err := rec.f.Close()
+	rec.mu.Unlock()
+
+	r := rec.recorder
+	r.mu.Lock()
+	delete(r.active, rec.Name)
+	r.mu.Unlock()
+	r.prune()
+	return err
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+)
+
+// writeCast stores a recording that started at start, with events as its
+// raw lines.
+func writeCast(t *testing.T, dir, name string, start time.Time, events ...string) {
+	t.Helper()
+	header, _ := json.Marshal(castHeader{Version: 2, Width: 80, Height: 24, Timestamp: start.Unix(), Title: "visitor"})
+	data := string(header) + "\n" + strings.Join(events, "\n")
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func recordingNames(t *testing.T, r *Recorder) []string {
+	t.Helper()
+	list, err := r.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, info := range list {
+		names = append(names, info.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRecorderLoad(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "recordings")
+	r, err := OpenRecorder(dir, config.Recording{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeCast(t, dir, "good.cast", time.Now(), `[0.5, "o", "hello"]`, `[1, "r", "100x30"]`, `not json`, `[2, "o"]`, `[2.5, "o", "cut sh`)
+	writeCast(t, root, "outside.cast", time.Now())
+	writeCast(t, dir, "notes.txt", time.Now())
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"good.cast", nil},
+		{"missing.cast", ErrRecordingNotFound},
+		{"../outside.cast", ErrRecordingNotFound},
+		{filepath.Join(root, "outside.cast"), ErrRecordingNotFound},
+		{"recordings/../good.cast", ErrRecordingNotFound},
+		{"notes.txt", ErrRecordingNotFound},
+		{"", ErrRecordingNotFound},
+	}
+	for _, tt := range tests {
+		cast, err := r.Load(tt.name)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Load(%q) = %v, want %v", tt.name, err, tt.err)
+		}
+		if err == nil && len(cast.Events) != 2 {
+			t.Errorf("Load(%q) kept %d events, want the 2 that parse: %+v", tt.name, len(cast.Events), cast.Events)
+		}
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	r, err := OpenRecorder(t.TempDir(), config.Recording{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := r.Start("abcdef123456", "SHA256:key\x1b[2J", "xterm\n", 100000, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A rune split across writes is held back until it's whole
+	_, _ = rec.Write([]byte("caf\xc3"))
+	_, _ = rec.Write([]byte("\xa9!"))
+	rec.Resize(120, 40)
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cast, err := r.Load(rec.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rec.Name, "-abcdef12.cast") {
+		t.Errorf("name = %q", rec.Name)
+	}
+	if cast.Visitor != "SHA256:key[2J" || cast.Term != "xterm" {
+		t.Errorf("labels = %q, %q", cast.Visitor, cast.Term)
+	}
+	if cast.Width != MaxTermWidth || cast.Height != 30 {
+		t.Errorf("size = %dx%d, want it clamped", cast.Width, cast.Height)
+	}
+	var got []string
+	for _, ev := range cast.Events {
+		got = append(got, ev.Type+":"+ev.Data)
+	}
+	if want := []string{"o:caf", "o:é!", "r:120x40"}; !slices.Equal(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestRecorderRetention(t *testing.T) {
+	now := time.Now()
+	event := `[0.1, "o", "` + strings.Repeat("x", 1000) + `"]`
+	tests := []struct {
+		name string
+		cfg  config.Recording
+		// ages are how long ago each recording started, named by index
+		ages []time.Duration
+		kept []string
+	}{
+		{"keep everything", config.Recording{}, []time.Duration{time.Hour, 48 * time.Hour}, []string{"0.cast", "1.cast"}},
+		{"past retention", config.Recording{Retention: config.Duration(24 * time.Hour)}, []time.Duration{time.Hour, 48 * time.Hour, 72 * time.Hour}, []string{"0.cast"}},
+		{"over the total", config.Recording{MaxTotal: 2500}, []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}, []string{"0.cast", "1.cast"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for i, age := range tt.ages {
+				writeCast(t, dir, fmt.Sprintf("%d.cast", i), now.Add(-age), event)
+			}
+			r, err := OpenRecorder(dir, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := recordingNames(t, r); !slices.Equal(got, tt.kept) {
+				t.Errorf("kept %q, want %q", got, tt.kept)
+			}
+		})
+	}
+
+	// Recordings being written are left alone, however full the directory
+	dir := t.TempDir()
+	writeCast(t, dir, "old.cast", now.Add(-time.Hour), event)
+	r, err := OpenRecorder(dir, config.Recording{MaxTotal: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := r.Start("live", "visitor", "xterm", 80, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = live.Write([]byte("still here"))
+	r.prune()
+	if got := recordingNames(t, r); !slices.Equal(got, []string{live.Name}) {
+		t.Errorf("kept %q while a session was recording, want only %q", got, live.Name)
+	}
+	_ = live.Close()
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"clifolio/internal/state"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+)
+
+// SessionInfo is what the server knows about a connected visitor. It is
+// attached to the session context before the app starts.
+type SessionInfo struct {
+	ID          string
+	Transport   string // "ssh" or "web"
+	IP          string
+	Fingerprint string
+	Owner       bool
+	Tracker     *SessionTracker
+	Live        *LiveSession
+	// Recording saves the session's output, nil when it isn't recorded.
+	Recording *Recording
+	// Output reaches the client's terminal in step with the program's
+	// frames, for escape sequences like OSC 52. It's set once the program
+	// is being made.
+	Output io.Writer
+}
+
+type sessionInfoKey struct{}
+
+// SessionInfoFrom returns the info attached by the session middleware, or an
+// empty SessionInfo if there is none.
+func SessionInfoFrom(s ssh.Session) *SessionInfo {
+	if info, ok := s.Context().Value(sessionInfoKey{}).(*SessionInfo); ok {
+		return info
+	}
+	return &SessionInfo{}
+}
+
+// RemoteIP returns the client's IP address without the port.
+func RemoteIP(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// sessionMiddleware identifies the visitor, checks them against the owner
+// keys, registers them with the hub and, when analytics is enabled, records
+// the session once it ends. With a recorder, visitors' output is saved too.
+func sessionMiddleware(hub *Hub, analytics *Analytics, recorder *Recorder, ownerKeysPath string) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			// Reloaded per session so key changes apply without a restart
+			owners, err := LoadKeySet(ownerKeysPath)
+			if err != nil {
+				log.Printf("Reading owner keys: %v", err)
+			}
+
+			info := &SessionInfo{
+				ID:          s.Context().SessionID(),
+				Transport:   "ssh",
+				IP:          RemoteIP(s.RemoteAddr()),
+				Fingerprint: Fingerprint(s.PublicKey()),
+				Owner:       owners.Contains(s.PublicKey()),
+			}
+
+			pty, _, _ := s.Pty()
+			openSession(info, s.User(), pty.Term, pty.Window.Width, pty.Window.Height, hub, analytics, recorder)
+
+			s.Context().SetValue(sessionInfoKey{}, info)
+			next(s)
+			closeSession(info, hub)
+
+			if msg := info.Live.Farewell(); msg != "" {
+				wish.Println(s, msg)
+			}
+		}
+	}
+}
+
+// openSession starts tracking, recording and listing a visitor whose
+// identity is already in info, whichever frontend they came in through.
+func openSession(info *SessionInfo, user, term string, width, height int, hub *Hub, analytics *Analytics, recorder *Recorder) {
+	// Both come from the client and end up on the owner's screen
+	user = cleanClientLabel(user)
+	term = cleanClientLabel(term)
+
+	if analytics != nil {
+		info.Tracker = analytics.NewTracker(info.IP, info.Fingerprint, term, width, height)
+	}
+
+	if recorder != nil && !info.Owner {
+		// Label it the way the analytics do, never with a raw address
+		visitor := info.Fingerprint
+		if visitor == "" && analytics != nil {
+			visitor = analytics.HashIP(info.IP)
+		}
+		var err error
+		info.Recording, err = recorder.Start(info.ID, visitor, term, width, height)
+		if err != nil {
+			log.Printf("Starting a session recording: %v", err)
+		}
+		info.Tracker.SetRecording(info.Recording)
+	}
+
+	info.Live = &LiveSession{
+		ID:          info.ID,
+		User:        user,
+		IP:          info.IP,
+		Fingerprint: info.Fingerprint,
+		Term:        term,
+		Owner:       info.Owner,
+		Started:     time.Now(),
+		screen:      state.ScreenIntro,
+	}
+	hub.add(info.Live)
+	activeSessions.WithLabelValues(info.Transport).Inc()
+	screenViews.WithLabelValues(state.ScreenIntro.String()).Inc()
+}
+
+// maxClientLabel caps the client-supplied names shown in the admin console.
+const maxClientLabel = 32
+
+// cleanClientLabel drops control characters from a value the client chose,
+// like the SSH user or TERM, so it can't carry escape sequences, and cuts it
+// to maxClientLabel characters.
+func cleanClientLabel(s string) string {
+	s = strings.Join(strings.Fields(stripControl(s, false)), " ")
+	if r := []rune(s); len(r) > maxClientLabel {
+		s = string(r[:maxClientLabel-1]) + "…"
+	}
+	return s
+}
+
+// closeSession takes the visitor off the hub and saves their recording and
+// analytics once they've gone.
+func closeSession(info *SessionInfo, hub *Hub) {
+	hub.remove(info.ID)
+	activeSessions.WithLabelValues(info.Transport).Dec()
+	sessionDuration.WithLabelValues(info.Transport).Observe(time.Since(info.Live.Started).Seconds())
+	if err := info.Recording.Close(); err != nil {
+		log.Printf("Closing the session recording: %v", err)
+	}
+	if err := info.Tracker.Finish(); err != nil {
+		log.Printf("Recording session analytics: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Serve runs the servers until the process is told to stop, then drains the
+// sessions they share through hub and limiter and stops them. It returns
+// once every server has stopped, leaving the stores for the caller to close.
+func Serve(hub *Hub, limiter *Limiter, grace time.Duration, servers ...func(ctx context.Context)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, serve := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(ctx)
+		}()
+	}
+
+	<-signals
+	log.Printf("Shutting down")
+	drain(hub, limiter, grace, signals)
+	cancel()
+	wg.Wait()
+}
+
+// drain winds the sessions down before the server stops, so a deploy
+// doesn't look like a crash. New sessions are turned away and /readyz fails
+// at once, everyone connected gets a countdown banner for grace, and
+// whoever is still there when it runs out is said goodbye to. A second
+// signal cuts the countdown short.
+//
+// It returns once the sessions have closed, which is when their analytics
+// and recordings are written out.
+func drain(hub *Hub, limiter *Limiter, grace time.Duration, signals <-chan os.Signal) {
+	limiter.Drain()
+	serversDraining()
+
+	deadline := time.Now().Add(grace)
+	if n := len(hub.Sessions()); n > 0 && grace > 0 {
+		log.Printf("Giving %d sessions %s to finish, signal again to skip", n, shortDuration(grace))
+	}
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+countdown:
+	for len(hub.Sessions()) > 0 {
+		left := time.Until(deadline).Round(time.Second)
+		if left <= 0 {
+			break
+		}
+		hub.Announce(fmt.Sprintf("The server is restarting in %s. Your session will end then, come back in a minute!", shortDuration(left)), left)
+		select {
+		case <-tick.C:
+		case <-signals:
+			log.Printf("Skipping the countdown")
+			break countdown
+		}
+	}
+
+	hub.DisconnectAll("The server is restarting, come back in a minute. Farewell, traveller!")
+
+	// The programs quit straight away, their sessions take a moment longer
+	// to close
+	wait := time.After(10 * time.Second)
+	for len(hub.Sessions()) > 0 {
+		select {
+		case <-wait:
+			log.Printf("Gave up waiting for %d sessions to close", len(hub.Sessions()))
+			return
+		case <-tick.C:
+		}
+	}
+}
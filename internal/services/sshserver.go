@@ -2,61 +2,242 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"net"
 	"os"
-	"os/signal"
-	"syscall"
+	"path/filepath"
 	"time"
 
+	"clifolio/internal/config"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/keygen"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/ssh"
 	"github.com/charmbracelet/wish"
 	"github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/muesli/termenv"
+	gossh "golang.org/x/crypto/ssh"
 )
 
-const (
-	host = "0.0.0.0"
-	port = "23234"
-)
+// legacyHostKeyPath is where older releases kept their host key. It is still
+// picked up so existing deployments keep their fingerprint.
+const legacyHostKeyPath = ".ssh/id_ed255219"
+
+// SessionEnviron returns the environment the client sent for s, with TERM
+// taken from the PTY request since clients don't send it as a variable.
+func SessionEnviron(s ssh.Session) []string {
+	environ := s.Environ()
+	if pty, _, ok := s.Pty(); ok {
+		environ = append(environ, "TERM="+pty.Term)
+	}
+	return environ
+}
+
+// SessionRenderer returns a lipgloss renderer for the client's terminal. The
+// color profile comes from the PTY's TERM and the COLORTERM the client sent,
+// and the background is queried from the client directly.
+func SessionRenderer(s ssh.Session) *lipgloss.Renderer {
+	return bubbletea.MakeRenderer(s)
+}
+
+// commandMiddleware runs sessions that came with a command through run and
+// ends them with its exit status, so they never reach the TUI. It sits inside
+// the limits but outside session tracking, since printing a section isn't a
+// visit.
+func commandMiddleware(run func(s ssh.Session) int) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			if len(s.Command()) == 0 || run == nil {
+				next(s)
+				return
+			}
+			log.Printf("Running command %q for %s", s.RawCommand(), s.RemoteAddr())
+			_ = s.Exit(run(s))
+		}
+	}
+}
+
+// ensureHostKey loads the private key at path, generating and writing an
+// Ed25519 key first if the file doesn't exist yet.
+func ensureHostKey(path string) error {
+	_, statErr := os.Stat(path)
+	generated := os.IsNotExist(statErr)
+	if generated {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+	}
+
+	kp, err := keygen.New(path, keygen.WithKeyType(keygen.Ed25519), keygen.WithWrite())
+	if err != nil {
+		return err
+	}
+
+	fingerprint := gossh.FingerprintSHA256(kp.PublicKey())
+	if generated {
+		log.Printf("Generated new host key %s (%s)", path, fingerprint)
+	} else {
+		log.Printf("Using host key %s (%s)", path, fingerprint)
+	}
+	return nil
+}
+
+func hostKeyOptions(cfg config.Config) ([]ssh.Option, error) {
+	paths := cfg.HostKeys()
+	if len(cfg.SSH.HostKeyPaths) == 0 {
+		if _, err := os.Stat(legacyHostKeyPath); err == nil {
+			log.Printf("Found host key at legacy path %s, set ssh.host_keys to silence this", legacyHostKeyPath)
+			paths = []string{legacyHostKeyPath}
+		}
+	}
+
+	var opts []ssh.Option
+	for _, path := range paths {
+		if err := ensureHostKey(path); err != nil {
+			return nil, err
+		}
+		opts = append(opts, wish.WithHostKeyPath(path))
+	}
+	return opts, nil
+}
+
+// SSHServerOptions holds what the SSH server hands sessions to.
+type SSHServerOptions struct {
+	// Hub registers every interactive session.
+	Hub *Hub
+	// Analytics records interactive sessions when it isn't nil.
+	Analytics *Analytics
+	// Recorder saves visitors' sessions as asciicasts when it isn't nil.
+	Recorder *Recorder
+	// Limiter admits sessions, a new one from cfg.Limits when nil.
+	Limiter *Limiter
+	// App builds the TUI for an interactive session.
+	App func(s ssh.Session) tea.Model
+	// Command runs sessions started with a command, like `ssh host skills`,
+	// and returns their exit status.
+	Command func(s ssh.Session) int
+	// Access turns away denied and banned clients, and bans those that
+	// keep failing the handshake or hitting the limits. Nil lets everyone in.
+	Access *Access
+	// Files are offered read-only over SCP and SFTP when not nil.
+	Files fs.FS
+}
+
+// StartSSHServer serves the app to every SSH session, or runs the command a
+// session asked for instead, until ctx is done. Run it through Serve so the
+// sessions are drained first.
+func StartSSHServer(ctx context.Context, cfg config.Config, o SSHServerOptions) {
+	keyOpts, err := hostKeyOptions(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	limiter := o.Limiter
+	if limiter == nil {
+		limiter = NewLimiter(cfg.Limits)
+	}
+
+	// SCP downloads are commands, so they sit with the others outside
+	// session tracking. Uploads have no handler and are refused.
+	files := func(next ssh.Handler) ssh.Handler { return next }
+	if o.Files != nil {
+		files = scp.Middleware(scp.NewFSReadHandler(o.Files), nil)
+	}
 
-func StartSSHServer(appFactory func() tea.Model) {
-	s, err := wish.NewServer(
-		wish.WithAddress(fmt.Sprintf("%s:%s", host, port)),
+	opts := []ssh.Option{
+		wish.WithAddress(cfg.SSH.Address),
 
 		// Middleware runs my bubbletea app for each SSH session
 		wish.WithMiddleware(
-			bubbletea.Middleware(func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
-				return appFactory(), []tea.ProgramOption{tea.WithAltScreen()}
-			}),
+			bubbletea.MiddlewareWithProgramHandler(func(s ssh.Session) *tea.Program {
+				// The server's signals are for the server, which drains the
+				// sessions itself rather than have every program quit
+				opts := append([]tea.ProgramOption{tea.WithAltScreen(), tea.WithoutSignalHandler()}, bubbletea.MakeOptions(s)...)
+				info := SessionInfoFrom(s)
+				out := NewSyncWriter(s)
+				info.Output = out
+				opts = append(opts, tea.WithOutput(out))
+				if rec := info.Recording; rec != nil {
+					// Everything the program draws goes to the recording too,
+					// and resizes are noted so the replay keeps up
+					opts = append(opts,
+						tea.WithOutput(io.MultiWriter(out, rec)),
+						tea.WithFilter(func(_ tea.Model, msg tea.Msg) tea.Msg {
+							if size, ok := msg.(tea.WindowSizeMsg); ok {
+								rec.Resize(size.Width, size.Height)
+							}
+							return msg
+						}))
+				}
+				p := tea.NewProgram(o.App(s), opts...)
+				o.Hub.attach(s.Context().SessionID(), p)
+				return p
+			}, termenv.Ascii),
+			sessionMiddleware(o.Hub, o.Analytics, o.Recorder, cfg.OwnerKeysPath()),
+			commandMiddleware(o.Command),
+			files,
+			limitsMiddleware(limiter, o.Access, cfg.Limits.InputIdleTimeout.Std(), cfg.SSH.MaxSessionDuration.Std()),
+			accessMiddleware(o.Access, cfg.OwnerKeysPath()),
 			logging.Middleware(),
 		),
 
-		wish.WithHostKeyPath(".ssh/id_ed255219"),
-	)
+		// Anyone may visit. Keys are accepted so they can identify visitors
+		// and the owner, keyboard-interactive lets keyless clients in too.
+		wish.WithPublicKeyAuth(func(ssh.Context, ssh.PublicKey) bool { return true }),
+		wish.WithKeyboardInteractiveAuth(func(ssh.Context, gossh.KeyboardInteractiveChallenge) bool { return true }),
+
+		// The session cap is enforced by the limits middleware so visitors
+		// get a goodbye instead of a dropped connection
+		wish.WithIdleTimeout(cfg.SSH.IdleTimeout.Std()),
+
+		// Scanners and brute forcers rarely get past the handshake
+		func(srv *ssh.Server) error {
+			srv.ConnectionFailedCallback = func(conn net.Conn, err error) {
+				o.Access.Strike(RemoteIP(conn.RemoteAddr()), "failed handshakes")
+			}
+			return nil
+		},
+	}
+	opts = append(opts, keyOpts...)
+	if o.Files != nil {
+		opts = append(opts, wish.WithSubsystem("sftp", sftpSubsystem(o.Files, limiter, o.Access)))
+	}
+	if cfg.SSH.Banner != "" {
+		opts = append(opts, wish.WithBanner(cfg.SSH.Banner+"\n"))
+	}
 
+	s, err := wish.NewServer(opts...)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-	log.Printf("Starting SSH server on %s:%s", host, port)
+	log.Printf("Starting SSH server on %s", cfg.SSH.Address)
+	ln, err := listen(cfg, cfg.SSH.Address)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	serverListening("ssh")
 
 	go func() {
-		if err = s.ListenAndServe(); err != nil {
+		if err = s.Serve(ln); err != nil && err != ssh.ErrServerClosed {
 			log.Fatalln(err)
 		}
 	}()
 
-	// Wait for shutdown signal, then close the connection
-	<-done
+	<-ctx.Done()
 	log.Printf("Stopping SSH Server")
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+	// What's left is commands and file transfers, which finish quickly
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	if err := s.Shutdown(ctx); err != nil {
-		log.Fatalln(err)
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Closing the remaining SSH connections: %v", err)
+		_ = s.Close()
 	}
+	log.Printf("SSH server stopped")
 }
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// A visitor's terminal is taken to be at most this big, bigger than any real
+// screen. Sizes they report are clamped to it wherever they're kept or
+// replayed, so no one can make the server allocate a screen of their choice.
+const (
+	MaxTermWidth  = 500
+	MaxTermHeight = 200
+)
+
+// ClampTermSize fits a reported terminal size between 1x1 and the maximum.
+func ClampTermSize(width, height int) (int, int) {
+	return min(max(1, width), MaxTermWidth), min(max(1, height), MaxTermHeight)
+}
+
+// VTerm is a small terminal emulator, enough to replay what the app draws:
+// cursor movement, erasing, the alternate screen and SGR styling. Styles are
+// kept as the escape sequences that set them, so any color depth replays.
+type VTerm struct {
+	width, height int
+	cells         [][]vcell
+	main          [][]vcell // the normal screen while the alternate one is up
+	x, y          int
+	savedX        int
+	savedY        int
+	wrapNext      bool
+	sgr           string
+
+	// Parser state carried between writes
+	mode    vtMode
+	seq     []byte
+	partial []byte
+}
+
+type vcell struct {
+	text string
+	sgr  string
+	// cont marks the right half of a wide character
+	cont bool
+}
+
+type vtMode int
+
+const (
+	vtGround vtMode = iota
+	vtEscape
+	vtCSI
+	vtString // OSC, DCS, APC and friends, ignored up to their terminator
+	vtStringEscape
+)
+
+func NewVTerm(width, height int) *VTerm {
+	v := &VTerm{}
+	v.Resize(width, height)
+	return v
+}
+
+// Resize changes the screen size, keeping what fits.
+func (v *VTerm) Resize(width, height int) {
+	width, height = ClampTermSize(width, height)
+	resize := func(old [][]vcell) [][]vcell {
+		grid := make([][]vcell, height)
+		for y := range grid {
+			grid[y] = make([]vcell, width)
+			if y < len(old) {
+				copy(grid[y], old[y])
+			}
+		}
+		return grid
+	}
+	v.cells = resize(v.cells)
+	if v.main != nil {
+		v.main = resize(v.main)
+	}
+	v.width, v.height = width, height
+	v.x, v.y = min(v.x, width-1), min(v.y, height-1)
+}
+
+func (v *VTerm) Size() (width, height int) {
+	return v.width, v.height
+}
+
+func (v *VTerm) Write(p []byte) (int, error) {
+	data := append(v.partial, p...)
+	v.partial = nil
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && !utf8.FullRune(data) {
+			v.partial = append([]byte(nil), data...)
+			break
+		}
+		data = data[size:]
+		v.feed(r)
+	}
+	return len(p), nil
+}
+
+func (v *VTerm) feed(r rune) {
+	switch v.mode {
+	case vtEscape:
+		v.escape(r)
+		return
+	case vtCSI:
+		if r >= 0x40 && r <= 0x7e {
+			v.mode = vtGround
+			v.csi(string(v.seq), r)
+			return
+		}
+		v.seq = append(v.seq, byte(r))
+		return
+	case vtString:
+		switch r {
+		case 0x07:
+			v.mode = vtGround
+		case 0x1b:
+			v.mode = vtStringEscape
+		}
+		return
+	case vtStringEscape:
+		// ESC \ ends the string, anything else is part of it
+		if r == '\\' {
+			v.mode = vtGround
+		} else {
+			v.mode = vtString
+		}
+		return
+	}
+
+	switch r {
+	case 0x1b:
+		v.mode = vtEscape
+	case '\r':
+		v.x, v.wrapNext = 0, false
+	case '\n', 0x0b, 0x0c:
+		v.lineFeed()
+	case '\b':
+		v.x, v.wrapNext = max(0, v.x-1), false
+	case '\t':
+		v.x = min(v.width-1, (v.x/8+1)*8)
+	default:
+		if r >= 0x20 && r != 0x7f {
+			v.print(r)
+		}
+	}
+}
+
+func (v *VTerm) escape(r rune) {
+	v.mode = vtGround
+	if r >= 0x20 && r <= 0x2f {
+		// An intermediate byte, as in ESC ( B, the next one ends it
+		v.mode = vtEscape
+		return
+	}
+	switch r {
+	case '[':
+		v.mode, v.seq = vtCSI, v.seq[:0]
+	case ']', 'P', '_', '^', 'X':
+		v.mode = vtString
+	case '7':
+		v.savedX, v.savedY = v.x, v.y
+	case '8':
+		v.x, v.y = v.savedX, v.savedY
+	case 'M':
+		if v.y == 0 {
+			v.scrollDown()
+		} else {
+			v.y--
+		}
+	case 'c':
+		v.sgr = ""
+		v.x, v.y = 0, 0
+		v.erase(0, 0, v.width, v.height)
+	}
+}
+
+func (v *VTerm) print(r rune) {
+	w := ansi.StringWidth(string(r))
+	if w == 0 {
+		// Combining marks and variation selectors join the previous cell,
+		// and an emoji selector can make it wide
+		px, py := v.x-1, v.y
+		if v.wrapNext {
+			px = v.x
+		}
+		for px > 0 && v.cells[py][px].cont {
+			px--
+		}
+		if px < 0 {
+			return
+		}
+		c := &v.cells[py][px]
+		c.text += string(r)
+		if ansi.StringWidth(c.text) == 2 && px+1 < v.width && !v.cells[py][px+1].cont {
+			v.cells[py][px+1] = vcell{sgr: c.sgr, cont: true}
+			if !v.wrapNext {
+				v.x = min(v.x+1, v.width-1)
+				v.wrapNext = px+1 == v.width-1
+			}
+		}
+		return
+	}
+
+	if v.wrapNext || v.x+w > v.width {
+		v.x, v.wrapNext = 0, false
+		v.lineFeed()
+	}
+	v.clearWide(v.x)
+	if w == 2 {
+		v.clearWide(v.x + 1)
+	}
+	v.cells[v.y][v.x] = vcell{text: string(r), sgr: v.sgr}
+	if w == 2 && v.x+1 < v.width {
+		v.cells[v.y][v.x+1] = vcell{sgr: v.sgr, cont: true}
+	}
+	if v.x+w >= v.width {
+		v.x, v.wrapNext = v.width-1, true
+	} else {
+		v.x += w
+	}
+}
+
+// clearWide blanks both halves of a wide character that's about to be
+// partly overwritten at x, so the row keeps its width.
+func (v *VTerm) clearWide(x int) {
+	if x >= v.width {
+		return
+	}
+	row := v.cells[v.y]
+	if row[x].cont && x > 0 {
+		row[x-1] = vcell{}
+	}
+	if x+1 < v.width && row[x+1].cont {
+		row[x+1] = vcell{}
+	}
+}
+
+func (v *VTerm) lineFeed() {
+	v.wrapNext = false
+	if v.y == v.height-1 {
+		v.scrollUp()
+		return
+	}
+	v.y++
+}
+
+func (v *VTerm) scrollUp() {
+	copy(v.cells, v.cells[1:])
+	v.cells[v.height-1] = make([]vcell, v.width)
+}
+
+func (v *VTerm) scrollDown() {
+	copy(v.cells[1:], v.cells)
+	v.cells[0] = make([]vcell, v.width)
+}
+
+// erase blanks the cells from (x0, y0) up to but not including (x1, y1),
+// reading the screen left to right, top to bottom.
+func (v *VTerm) erase(x0, y0, x1, y1 int) {
+	for y := y0; y <= min(y1, v.height-1); y++ {
+		from, to := 0, v.width
+		if y == y0 {
+			from = x0
+		}
+		if y == y1 {
+			to = x1
+		}
+		for x := max(0, from); x < min(to, v.width); x++ {
+			v.cells[y][x] = vcell{}
+		}
+	}
+}
+
+func (v *VTerm) csi(params string, final rune) {
+	private := strings.HasPrefix(params, "?")
+	args := strings.Split(strings.TrimLeft(params, "?>=<"), ";")
+	arg := func(i, def int) int {
+		if i >= len(args) {
+			return def
+		}
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n == 0 {
+			return def
+		}
+		return n
+	}
+	clampX := func(x int) int { return min(max(0, x), v.width-1) }
+	clampY := func(y int) int { return min(max(0, y), v.height-1) }
+	v.wrapNext = false
+
+	switch final {
+	case 'm':
+		switch {
+		case params == "" || params == "0":
+			v.sgr = ""
+		case strings.HasPrefix(params, "0;"):
+			v.sgr = "\x1b[" + params + "m"
+		default:
+			v.sgr += "\x1b[" + params + "m"
+		}
+	case 'H', 'f':
+		v.y, v.x = clampY(arg(0, 1)-1), clampX(arg(1, 1)-1)
+	case 'A':
+		v.y = clampY(v.y - arg(0, 1))
+	case 'B':
+		v.y = clampY(v.y + arg(0, 1))
+	case 'C':
+		v.x = clampX(v.x + arg(0, 1))
+	case 'D':
+		v.x = clampX(v.x - arg(0, 1))
+	case 'E':
+		v.x, v.y = 0, clampY(v.y+arg(0, 1))
+	case 'F':
+		v.x, v.y = 0, clampY(v.y-arg(0, 1))
+	case 'G':
+		v.x = clampX(arg(0, 1) - 1)
+	case 'd':
+		v.y = clampY(arg(0, 1) - 1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			v.erase(v.x, v.y, v.width, v.height-1)
+		case 1:
+			v.erase(0, 0, v.x+1, v.y)
+		default:
+			v.erase(0, 0, v.width, v.height-1)
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			v.erase(v.x, v.y, v.width, v.y)
+		case 1:
+			v.erase(0, v.y, v.x+1, v.y)
+		default:
+			v.erase(0, v.y, v.width, v.y)
+		}
+	case 'S':
+		for range arg(0, 1) {
+			v.scrollUp()
+		}
+	case 'T':
+		for range arg(0, 1) {
+			v.scrollDown()
+		}
+	case 'h', 'l':
+		if private {
+			for _, a := range args {
+				if a == "1049" || a == "1047" || a == "47" {
+					v.altScreen(final == 'h')
+				}
+			}
+		}
+	}
+}
+
+func (v *VTerm) altScreen(on bool) {
+	switch {
+	case on && v.main == nil:
+		v.main = v.cells
+		v.cells = nil
+		v.Resize(v.width, v.height)
+		v.savedX, v.savedY = v.x, v.y
+	case !on && v.main != nil:
+		v.cells, v.main = v.main, nil
+		v.x, v.y = v.savedX, v.savedY
+	}
+}
+
+// Lines renders the screen, one styled string per row.
+func (v *VTerm) Lines() []string {
+	lines := make([]string, v.height)
+	for y, row := range v.cells {
+		var b strings.Builder
+		sgr := ""
+		wide := false
+		for _, c := range row {
+			if c.cont && wide {
+				wide = false
+				continue
+			}
+			wide = ansi.StringWidth(c.text) == 2
+			if c.sgr != sgr {
+				if sgr != "" {
+					b.WriteString(ansi.ResetStyle)
+				}
+				b.WriteString(c.sgr)
+				sgr = c.sgr
+			}
+			if c.text == "" {
+				b.WriteByte(' ')
+			} else {
+				b.WriteString(c.text)
+			}
+		}
+		if sgr != "" {
+			b.WriteString(ansi.ResetStyle)
+		}
+		lines[y] = b.String()
+	}
+	return lines
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"clifolio/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/net/websocket"
+)
+
+// WebTerm is the terminal type the gateway's page emulates.
+const WebTerm = "xterm-256color"
+
+//go:embed webterm.html
+var webTermPage []byte
+
+// webAssets are the page's terminal and its styles, served from the binary so
+// the page runs nothing fetched from elsewhere.
+//
+//go:embed webassets
+var webAssets embed.FS
+
+// WebSession is a visitor connected through the browser gateway.
+type WebSession struct {
+	Info *SessionInfo
+	// Renderer styles for the page's terminal, which shows true color on a
+	// dark background.
+	Renderer *lipgloss.Renderer
+	// Output reaches the page's terminal in step with the program's frames,
+	// for escape sequences like OSC 52.
+	Output io.Writer
+}
+
+// WebServerOptions holds what the browser gateway hands sessions to.
+type WebServerOptions struct {
+	// Hub registers every session, alongside the SSH ones.
+	Hub *Hub
+	// Analytics records sessions when it isn't nil.
+	Analytics *Analytics
+	// Recorder saves sessions as asciicasts when it isn't nil.
+	Recorder *Recorder
+	// Limiter admits sessions. Share the SSH server's so a visitor can't
+	// get round the limits by switching to the browser.
+	Limiter *Limiter
+	// Access turns away denied and banned addresses when it isn't nil.
+	// Browsers have no key, so only address bans apply.
+	Access *Access
+	// App builds the TUI for a session.
+	App func(w *WebSession) tea.Model
+	// Sections can be fetched at /<name>, as ANSI text by curl and as HTML
+	// by browsers.
+	Sections []string
+	// Render draws a section, or for "" the summary curl gets at /, styled
+	// by r and wrapped at width. Host is where the request was sent.
+	Render func(ctx context.Context, section, host string, r *lipgloss.Renderer, width int) (string, error)
+	// APIs can be fetched as JSON at /api/<name>, as Data returns them.
+	APIs []string
+	Data func(ctx context.Context, name string) (any, error)
+}
+
+// webMessage is what the page sends over the WebSocket: keystrokes as
+// "input" with Data, or its size as "resize" with Cols and Rows. Output goes
+// the other way as binary frames of raw terminal bytes.
+type webMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Cols int    `json:"cols,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+}
+
+// NewWebHandler serves the terminal page at / with its assets under /assets/,
+// and bridges WebSockets at /ws to the app. Curl gets a summary at / instead, and the sections as text.
+// The portfolio's data is at /api/ as JSON.
+func NewWebHandler(cfg config.Config, o WebServerOptions) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		if isTextClient(r) && o.Render != nil {
+			o.servePage(w, r, "")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Vary", "User-Agent")
+		_, _ = w.Write(webTermPage)
+	})
+	assets, _ := fs.Sub(webAssets, "webassets")
+	mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServerFS(assets)))
+	mux.HandleFunc("GET /{section}", func(w http.ResponseWriter, r *http.Request) {
+		section := r.PathValue("section")
+		if o.Render == nil || !slices.Contains(o.Sections, section) {
+			http.NotFound(w, r)
+			return
+		}
+		o.servePage(w, r, section)
+	})
+	mux.HandleFunc("GET /api/{$}", func(w http.ResponseWriter, r *http.Request) {
+		o.serveAPIIndex(w, r, cfg.Web.APIOrigins)
+	})
+	mux.HandleFunc("GET /api/{name}", func(w http.ResponseWriter, r *http.Request) {
+		o.serveAPI(w, r, r.PathValue("name"), cfg.Web.APIOrigins)
+	})
+	mux.HandleFunc("OPTIONS /api/", func(w http.ResponseWriter, r *http.Request) {
+		serveAPIPreflight(w, r, cfg.Web.APIOrigins)
+	})
+	mux.Handle("GET /ws", websocket.Server{
+		Handshake: func(wc *websocket.Config, r *http.Request) error {
+			return checkWebOrigin(r, cfg.Web.AllowedOrigins)
+		},
+		Handler: func(ws *websocket.Conn) {
+			serveWebSession(ws, cfg, o)
+		},
+	})
+	return mux
+}
+
+// StartWebServer runs the browser gateway until ctx is done. Run it through
+// Serve so the sessions are drained first.
+func StartWebServer(ctx context.Context, cfg config.Config, o WebServerOptions) {
+	if o.Limiter == nil {
+		o.Limiter = NewLimiter(cfg.Limits)
+	}
+	log.Printf("Starting web gateway on %s", cfg.Web.Address)
+	ln, err := listen(cfg, cfg.Web.Address)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	serverListening("web")
+
+	srv := &http.Server{
+		Handler:           NewWebHandler(cfg, o),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Fatalln(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Stopping web gateway")
+
+	// Terminal sessions are gone by now, only page and API requests are left
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Closing the remaining web connections: %v", err)
+		_ = srv.Close()
+	}
+	log.Printf("Web gateway stopped")
+}
+
+// checkWebOrigin keeps other sites' pages from opening sessions. Clients
+// that send no Origin aren't browsers, so they're let through.
+func checkWebOrigin(r *http.Request, allowed []string) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Host, r.Host) || slices.Contains(allowed, u.Scheme+"://"+u.Host) {
+		return nil
+	}
+	return fmt.Errorf("origin %s isn't allowed", origin)
+}
+
+// serveWebSession runs the app for one page, with the same limits, tracking
+// and recording as an SSH session.
+func serveWebSession(ws *websocket.Conn, cfg config.Config, o WebServerOptions) {
+	defer ws.Close()
+	ws.PayloadType = websocket.BinaryFrame
+	ws.MaxPayloadBytes = 64 << 10
+
+	ip := ws.Request().RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	if err := o.Access.Check(ip, ""); err != nil {
+		log.Printf("Refused web session from %s: %v", ip, err)
+		fmt.Fprint(ws, "Sorry, "+err.Error()+".\r\n")
+		return
+	}
+	release, err := o.Limiter.Admit(ip)
+	if err != nil {
+		log.Printf("Rejected web session from %s: %v", ip, err)
+		o.Access.Refused(ip, err)
+		fmt.Fprint(ws, "Sorry, "+err.Error()+".\r\n")
+		return
+	}
+	defer release()
+
+	// The page says how big it is before anything is drawn
+	width, height := 80, 24
+	_ = ws.SetReadDeadline(time.Now().Add(10 * time.Second))
+	var first webMessage
+	if err := websocket.JSON.Receive(ws, &first); err != nil {
+		return
+	}
+	if first.Type == "resize" && first.Cols > 0 && first.Rows > 0 {
+		width, height = ClampTermSize(first.Cols, first.Rows)
+	}
+	_ = ws.SetReadDeadline(time.Time{})
+
+	info := &SessionInfo{ID: newSessionID(), Transport: "web", IP: ip}
+	openSession(info, "browser", WebTerm, width, height, o.Hub, o.Analytics, o.Recorder)
+	log.Printf("Web session %s from %s", info.ID[:8], ip)
+
+	renderer := lipgloss.NewRenderer(ws)
+	renderer.SetColorProfile(termenv.TrueColor)
+	renderer.SetHasDarkBackground(true)
+
+	input, keys := io.Pipe()
+	defer input.Close()
+	out := NewSyncWriter(ws)
+	info.Output = out
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithoutSignalHandler(), tea.WithInput(input), tea.WithOutput(out)}
+	if rec := info.Recording; rec != nil {
+		// As over SSH, the recording gets the output and the resizes
+		opts = append(opts,
+			tea.WithOutput(io.MultiWriter(out, rec)),
+			tea.WithFilter(func(_ tea.Model, msg tea.Msg) tea.Msg {
+				if size, ok := msg.(tea.WindowSizeMsg); ok {
+					rec.Resize(size.Width, size.Height)
+				}
+				return msg
+			}))
+	}
+	p := tea.NewProgram(o.App(&WebSession{Info: info, Renderer: renderer, Output: out}), opts...)
+	o.Hub.attach(info.ID, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := newSessionWatch()
+	go watch.run(ctx, cancel, cfg.Limits.InputIdleTimeout.Std(), cfg.SSH.MaxSessionDuration.Std())
+
+	go func() {
+		// A closed page ends the session
+		defer cancel()
+		for {
+			var msg webMessage
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+			switch msg.Type {
+			case "input":
+				watch.touch()
+				if _, err := keys.Write([]byte(msg.Data)); err != nil {
+					return
+				}
+			case "resize":
+				if msg.Cols > 0 && msg.Rows > 0 {
+					w, h := ClampTermSize(msg.Cols, msg.Rows)
+					p.Send(tea.WindowSizeMsg{Width: w, Height: h})
+				}
+			}
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		p.Quit()
+	}()
+	go p.Send(tea.WindowSizeMsg{Width: width, Height: height})
+
+	if _, err := p.Run(); err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		log.Printf("Web session %s: %v", info.ID[:8], err)
+	}
+	cancel()
+	closeSession(info, o.Hub)
+
+	if msg, ok := watch.Reason(); ok {
+		log.Printf("Ended web session from %s: %s", ip, msg)
+		fmt.Fprint(ws, msg+"\r\n")
+	}
+	if msg := info.Live.Farewell(); msg != "" {
+		fmt.Fprint(ws, msg+"\r\n")
+	}
+}
+
+// newSessionID makes an ID shaped like the SSH server's, which are hex.
+func newSessionID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"clifolio/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/net/websocket"
+)
+
+// echoModel shows the last size and key it got, and quits on q.
+type echoModel struct {
+	size string
+	key  string
+}
+
+func (m echoModel) Init() tea.Cmd { return nil }
+
+func (m echoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = fmt.Sprintf("%dx%d", msg.Width, msg.Height)
+	case tea.KeyMsg:
+		if msg.String() == "q" {
+			return m, tea.Quit
+		}
+		m.key = msg.String()
+	}
+	return m, nil
+}
+
+func (m echoModel) View() string {
+	return "size=" + m.size + " key=" + m.key
+}
+
+func newTestWebServer(t *testing.T, limits config.Limits) *httptest.Server {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Limits = limits
+	srv := httptest.NewServer(NewWebHandler(cfg, WebServerOptions{
+		Hub:     NewHub(),
+		Limiter: NewLimiter(limits),
+		App:     func(*WebSession) tea.Model { return echoModel{} },
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dialWeb(t *testing.T, srv *httptest.Server, origin string) *websocket.Conn {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", origin)
+	if err != nil {
+		t.Fatalf("dialing the gateway: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+// readUntil reads the session's output until it contains want.
+func readUntil(t *testing.T, ws *websocket.Conn, want string) string {
+	t.Helper()
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var out strings.Builder
+	buf := make([]byte, 4096)
+	for !strings.Contains(out.String(), want) {
+		n, err := ws.Read(buf)
+		if err != nil {
+			t.Fatalf("waiting for %q: %v, got %q", want, err, out.String())
+		}
+		out.Write(buf[:n])
+	}
+	return out.String()
+}
+
+func TestWebSessionRoundTrip(t *testing.T) {
+	srv := newTestWebServer(t, config.Limits{})
+	ws := dialWeb(t, srv, srv.URL)
+
+	send := func(msg webMessage) {
+		if err := websocket.JSON.Send(ws, msg); err != nil {
+			t.Fatalf("sending %+v: %v", msg, err)
+		}
+	}
+
+	send(webMessage{Type: "resize", Cols: 100, Rows: 30})
+	readUntil(t, ws, "size=100x30")
+
+	send(webMessage{Type: "input", Data: "x"})
+	readUntil(t, ws, "key=x")
+
+	// Sizes past what the server draws are clamped, not trusted
+	send(webMessage{Type: "resize", Cols: 100000, Rows: 100000})
+	readUntil(t, ws, fmt.Sprintf("size=%dx%d", MaxTermWidth, MaxTermHeight))
+
+	// Quitting the app closes the socket
+	send(webMessage{Type: "input", Data: "q"})
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	for {
+		_, err := ws.Read(buf)
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			t.Fatal("the session stayed open after the app quit")
+		}
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestWebPageAssets(t *testing.T) {
+	srv := newTestWebServer(t, config.Limits{})
+
+	res, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	assets := regexp.MustCompile(`(?:src|href)="(/assets/[^"]+)"`).FindAllStringSubmatch(string(page), -1)
+	if len(assets) == 0 {
+		t.Fatal("the page loads no assets")
+	}
+	for _, m := range assets {
+		res, err := http.Get(srv.URL + m[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %s", m[1], res.Status)
+		}
+	}
+	if regexp.MustCompile(`(?:src|href)="(?:https?:)?//`).Match(page) {
+		t.Error("the page loads assets from another site")
+	}
+}
+
+func TestWebSessionOrigin(t *testing.T) {
+	srv := newTestWebServer(t, config.Limits{})
+
+	_, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", "https://evil.example")
+	if err == nil {
+		t.Fatal("a page from another site opened a session")
+	}
+}
+
+func TestCheckWebOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		ok      bool
+	}{
+		{"no origin", "", nil, true},
+		{"same host", "https://folio.example", nil, true},
+		{"same host other case", "https://FOLIO.example", nil, true},
+		{"other site", "https://evil.example", nil, false},
+		{"allowed site", "https://friend.example", []string{"https://friend.example"}, true},
+		{"allowed host other scheme", "http://friend.example", []string{"https://friend.example"}, false},
+		{"other port", "https://folio.example:8443", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "https://folio.example/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			err := checkWebOrigin(r, tt.allowed)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkWebOrigin(%q) = %v, want ok %v", tt.origin, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWebSessionPerIPLimit(t *testing.T) {
+	srv := newTestWebServer(t, config.Limits{MaxSessionsPerIP: 1})
+
+	first := dialWeb(t, srv, srv.URL)
+	if err := websocket.JSON.Send(first, webMessage{Type: "resize", Cols: 80, Rows: 24}); err != nil {
+		t.Fatal(err)
+	}
+	readUntil(t, first, "size=80x24")
+
+	second := dialWeb(t, srv, srv.URL)
+	readUntil(t, second, ErrTooManyFromIP.Error())
+}
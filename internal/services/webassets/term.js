@@ -0,0 +1,469 @@
+// A small terminal for the browser gateway, enough to run the app: cursor
+// movement, erasing, the alternate screen, SGR styling, bracketed paste and
+// OSC 52 copies. It follows vterm.go, which replays recordings the same way.
+"use strict";
+
+(() => {
+  const palette = [
+    "#484f58", "#ff7b72", "#3fb950", "#d29922", "#58a6ff", "#bc8cff", "#39c5cf", "#b1bac4",
+    "#6e7681", "#ffa198", "#56d364", "#e3b341", "#79c0ff", "#d2a8ff", "#56d4dd", "#f0f6fc",
+  ];
+  const defaultFg = "#e6edf3";
+  const defaultBg = "#0d1117";
+
+  function color256(n) {
+    if (n < 16) return palette[n];
+    if (n < 232) {
+      n -= 16;
+      const level = (v) => (v === 0 ? 0 : 55 + v * 40);
+      return rgb(level(Math.floor(n / 36)), level(Math.floor(n / 6) % 6), level(n % 6));
+    }
+    const g = 8 + (n - 232) * 10;
+    return rgb(g, g, g);
+  }
+
+  function rgb(r, g, b) {
+    return "rgb(" + r + "," + g + "," + b + ")";
+  }
+
+  // Widths match what the server lays out with, near enough: combining marks
+  // take no cell, and CJK and most emoji take two.
+  function charWidth(cp) {
+    if (
+      (cp >= 0x300 && cp <= 0x36f) || (cp >= 0x200b && cp <= 0x200f) ||
+      (cp >= 0xfe00 && cp <= 0xfe0f) || (cp >= 0x1f3fb && cp <= 0x1f3ff)
+    ) return 0;
+    if (
+      (cp >= 0x1100 && cp <= 0x115f) || (cp >= 0x2e80 && cp <= 0xa4cf) ||
+      (cp >= 0xac00 && cp <= 0xd7a3) || (cp >= 0xf900 && cp <= 0xfaff) ||
+      (cp >= 0xfe30 && cp <= 0xfe4f) || (cp >= 0xff00 && cp <= 0xff60) ||
+      (cp >= 0xffe0 && cp <= 0xffe6) || (cp >= 0x1f300 && cp <= 0x1f64f) ||
+      (cp >= 0x1f680 && cp <= 0x1f6ff) || (cp >= 0x1f900 && cp <= 0x1faff) ||
+      (cp >= 0x20000 && cp <= 0x3fffd)
+    ) return 2;
+    return 1;
+  }
+
+  const plain = Object.freeze({});
+  const blank = () => ({ text: "", style: plain, cont: false });
+  const escapeHTML = (s) => s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+
+  class Term {
+    constructor(el) {
+      this.el = el;
+      this.cols = 80;
+      this.rows = 24;
+      this.cells = [];
+      this.main = null;
+      this.x = 0;
+      this.y = 0;
+      this.savedX = 0;
+      this.savedY = 0;
+      this.wrapNext = false;
+      this.style = plain;
+      this.cursorVisible = true;
+      this.appCursor = false;
+      this.bracketedPaste = false;
+      this.mode = "ground";
+      this.seq = "";
+      this.decoder = new TextDecoder();
+      this.dataHandlers = [];
+      this.resizeHandlers = [];
+      this.oscHandlers = {};
+      this.dirty = new Set();
+      this.frame = 0;
+
+      el.classList.add("term");
+      el.tabIndex = 0;
+      this.screen = document.createElement("div");
+      el.appendChild(this.screen);
+      this.probe = document.createElement("span");
+      this.probe.className = "term-probe";
+      this.probe.textContent = "W".repeat(10);
+      el.appendChild(this.probe);
+
+      el.addEventListener("keydown", (e) => this.key(e));
+      el.addEventListener("paste", (e) => {
+        const text = e.clipboardData.getData("text/plain").replace(/\r?\n/g, "\r");
+        e.preventDefault();
+        this.emit(this.bracketedPaste ? "\x1b[200~" + text + "\x1b[201~" : text);
+      });
+      const redrawCursor = () => {
+        this.touch(this.y);
+        this.schedule();
+      };
+      el.addEventListener("focus", redrawCursor);
+      el.addEventListener("blur", redrawCursor);
+      this.resizeGrid(this.cols, this.rows);
+    }
+
+    onData(fn) { this.dataHandlers.push(fn); }
+    onResize(fn) { this.resizeHandlers.push(fn); }
+    // onOsc handles OSC n, given the data after "n;".
+    onOsc(n, fn) { this.oscHandlers[n] = fn; }
+    focus() { this.el.focus(); }
+    emit(data) { this.dataHandlers.forEach((fn) => fn(data)); }
+
+    // fit sizes the grid to the element.
+    fit() {
+      const box = this.probe.getBoundingClientRect();
+      const cw = box.width / 10, ch = box.height;
+      if (!cw || !ch) return;
+      const cols = Math.max(1, Math.floor(this.el.clientWidth / cw));
+      const rows = Math.max(1, Math.floor(this.el.clientHeight / ch));
+      if (cols === this.cols && rows === this.rows) return;
+      this.resizeGrid(cols, rows);
+      this.resizeHandlers.forEach((fn) => fn({ cols, rows }));
+    }
+
+    resizeGrid(cols, rows) {
+      const resize = (old) => Array.from({ length: rows }, (_, y) =>
+        Array.from({ length: cols }, (_, x) => (old && old[y] && old[y][x]) || blank()));
+      this.cells = resize(this.cells);
+      if (this.main) this.main = resize(this.main);
+      this.cols = cols;
+      this.rows = rows;
+      this.x = Math.min(this.x, cols - 1);
+      this.y = Math.min(this.y, rows - 1);
+      this.screen.replaceChildren(...Array.from({ length: rows }, () => {
+        const row = document.createElement("div");
+        row.className = "term-row";
+        return row;
+      }));
+      this.touchAll();
+      this.schedule();
+    }
+
+    // write takes the server's output as bytes, or a string of the page's own.
+    write(data) {
+      const text = typeof data === "string" ? data : this.decoder.decode(data, { stream: true });
+      for (const ch of text) {
+        this.feed(ch);
+      }
+      this.touch(this.y);
+      this.schedule();
+    }
+
+    schedule() {
+      if (!this.frame) this.frame = requestAnimationFrame(() => this.render());
+    }
+
+    feed(ch) {
+      const cp = ch.codePointAt(0);
+      switch (this.mode) {
+        case "escape":
+          this.escape(ch);
+          return;
+        case "csi":
+          if (cp >= 0x40 && cp <= 0x7e) {
+            this.mode = "ground";
+            this.csi(this.seq, ch);
+          } else {
+            this.seq += ch;
+          }
+          return;
+        case "string":
+          if (cp === 0x07) this.endString();
+          else if (cp === 0x1b) this.mode = "stringEscape";
+          else if (this.seq.length < 1 << 20) this.seq += ch;
+          return;
+        case "stringEscape":
+          // ESC \ ends the string, anything else is part of it
+          if (ch === "\\") this.endString();
+          else this.mode = "string";
+          return;
+      }
+
+      switch (cp) {
+        case 0x1b: this.mode = "escape"; break;
+        case 0x0d: this.x = 0; this.wrapNext = false; break;
+        case 0x0a: case 0x0b: case 0x0c: this.lineFeed(); break;
+        case 0x08: this.x = Math.max(0, this.x - 1); this.wrapNext = false; break;
+        case 0x09: this.x = Math.min(this.cols - 1, (Math.floor(this.x / 8) + 1) * 8); break;
+        default:
+          if (cp >= 0x20 && cp !== 0x7f) this.print(ch, cp);
+      }
+    }
+
+    escape(ch) {
+      this.mode = "ground";
+      const cp = ch.codePointAt(0);
+      if (cp >= 0x20 && cp <= 0x2f) {
+        // An intermediate byte, as in ESC ( B, the next one ends it
+        this.mode = "escape";
+        return;
+      }
+      switch (ch) {
+        case "[": this.mode = "csi"; this.seq = ""; break;
+        case "]": case "P": case "_": case "^": case "X": this.mode = "string"; this.seq = ch; break;
+        case "7": this.savedX = this.x; this.savedY = this.y; break;
+        case "8": this.x = this.savedX; this.y = this.savedY; break;
+        case "M":
+          if (this.y === 0) this.scrollDown();
+          else this.y--;
+          break;
+        case "c":
+          this.style = plain;
+          this.x = this.y = 0;
+          this.erase(0, 0, this.cols, this.rows);
+          break;
+      }
+    }
+
+    endString() {
+      this.mode = "ground";
+      if (this.seq[0] !== "]") return;
+      const data = this.seq.slice(1);
+      const sep = data.indexOf(";");
+      const fn = this.oscHandlers[sep < 0 ? data : data.slice(0, sep)];
+      if (fn) fn(sep < 0 ? "" : data.slice(sep + 1));
+    }
+
+    print(ch, cp) {
+      const w = charWidth(cp);
+      if (w === 0) {
+        // Combining marks and selectors join the previous cell
+        let px = this.wrapNext ? this.x : this.x - 1;
+        while (px > 0 && this.cells[this.y][px].cont) px--;
+        if (px >= 0) this.cells[this.y][px].text += ch;
+        return;
+      }
+      if (this.wrapNext || this.x + w > this.cols) {
+        this.x = 0;
+        this.wrapNext = false;
+        this.lineFeed();
+      }
+      const row = this.cells[this.y];
+      this.clearWide(row, this.x);
+      if (w === 2) this.clearWide(row, this.x + 1);
+      row[this.x] = { text: ch, style: this.style, cont: false };
+      if (w === 2 && this.x + 1 < this.cols) row[this.x + 1] = { text: "", style: this.style, cont: true };
+      if (this.x + w >= this.cols) {
+        this.x = this.cols - 1;
+        this.wrapNext = true;
+      } else {
+        this.x += w;
+      }
+      this.touch(this.y);
+    }
+
+    // clearWide blanks both halves of a wide character that's about to be
+    // partly overwritten at x.
+    clearWide(row, x) {
+      if (x >= this.cols) return;
+      if (row[x].cont && x > 0) row[x - 1] = blank();
+      if (x + 1 < this.cols && row[x + 1].cont) row[x + 1] = blank();
+    }
+
+    lineFeed() {
+      this.wrapNext = false;
+      this.touch(this.y);
+      if (this.y === this.rows - 1) this.scrollUp();
+      else this.y++;
+    }
+
+    scrollUp() {
+      this.cells.shift();
+      this.cells.push(Array.from({ length: this.cols }, blank));
+      this.touchAll();
+    }
+
+    scrollDown() {
+      this.cells.pop();
+      this.cells.unshift(Array.from({ length: this.cols }, blank));
+      this.touchAll();
+    }
+
+    // erase blanks the cells from (x0, y0) up to but not including (x1, y1),
+    // reading the screen left to right, top to bottom.
+    erase(x0, y0, x1, y1) {
+      for (let y = y0; y <= Math.min(y1, this.rows - 1); y++) {
+        const from = y === y0 ? x0 : 0;
+        const to = y === y1 ? x1 : this.cols;
+        for (let x = Math.max(0, from); x < Math.min(to, this.cols); x++) {
+          this.cells[y][x] = blank();
+        }
+        this.touch(y);
+      }
+    }
+
+    csi(params, final) {
+      const priv = params.startsWith("?");
+      const args = params.replace(/^[?>=<]/, "").split(/[;:]/);
+      const arg = (i, def) => parseInt(args[i], 10) || def;
+      const clampX = (x) => Math.min(Math.max(0, x), this.cols - 1);
+      const clampY = (y) => Math.min(Math.max(0, y), this.rows - 1);
+      this.wrapNext = false;
+      this.touch(this.y);
+
+      switch (final) {
+        case "m": if (!priv) this.sgr(args); break;
+        case "H": case "f": this.y = clampY(arg(0, 1) - 1); this.x = clampX(arg(1, 1) - 1); break;
+        case "A": this.y = clampY(this.y - arg(0, 1)); break;
+        case "B": this.y = clampY(this.y + arg(0, 1)); break;
+        case "C": this.x = clampX(this.x + arg(0, 1)); break;
+        case "D": this.x = clampX(this.x - arg(0, 1)); break;
+        case "E": this.x = 0; this.y = clampY(this.y + arg(0, 1)); break;
+        case "F": this.x = 0; this.y = clampY(this.y - arg(0, 1)); break;
+        case "G": this.x = clampX(arg(0, 1) - 1); break;
+        case "d": this.y = clampY(arg(0, 1) - 1); break;
+        case "J":
+          switch (arg(0, 0)) {
+            case 0: this.erase(this.x, this.y, this.cols, this.rows - 1); break;
+            case 1: this.erase(0, 0, this.x + 1, this.y); break;
+            default: this.erase(0, 0, this.cols, this.rows - 1);
+          }
+          break;
+        case "K":
+          switch (arg(0, 0)) {
+            case 0: this.erase(this.x, this.y, this.cols, this.y); break;
+            case 1: this.erase(0, this.y, this.x + 1, this.y); break;
+            default: this.erase(0, this.y, this.cols, this.y);
+          }
+          break;
+        case "S": for (let i = arg(0, 1); i > 0; i--) this.scrollUp(); break;
+        case "T": for (let i = arg(0, 1); i > 0; i--) this.scrollDown(); break;
+        case "h": case "l":
+          if (priv) args.forEach((a) => this.setMode(a, final === "h"));
+          break;
+      }
+      this.touch(this.y);
+    }
+
+    setMode(mode, on) {
+      switch (mode) {
+        case "1": this.appCursor = on; break;
+        case "25": this.cursorVisible = on; break;
+        case "2004": this.bracketedPaste = on; break;
+        case "47": case "1047": case "1049": this.altScreen(on); break;
+      }
+    }
+
+    altScreen(on) {
+      if (on && !this.main) {
+        this.main = this.cells;
+        this.cells = Array.from({ length: this.rows }, () => Array.from({ length: this.cols }, blank));
+        this.savedX = this.x;
+        this.savedY = this.y;
+      } else if (!on && this.main) {
+        this.cells = this.main;
+        this.main = null;
+        this.x = this.savedX;
+        this.y = this.savedY;
+      }
+      this.touchAll();
+    }
+
+    sgr(args) {
+      const s = Object.assign({}, this.style);
+      for (let i = 0; i < args.length; i++) {
+        const n = parseInt(args[i], 10) || 0;
+        if (n === 0) Object.keys(s).forEach((k) => delete s[k]);
+        else if (n === 1) s.bold = true;
+        else if (n === 2) s.faint = true;
+        else if (n === 3) s.italic = true;
+        else if (n === 4) s.underline = true;
+        else if (n === 7) s.inverse = true;
+        else if (n === 9) s.strike = true;
+        else if (n === 22) { delete s.bold; delete s.faint; }
+        else if (n === 23) delete s.italic;
+        else if (n === 24) delete s.underline;
+        else if (n === 27) delete s.inverse;
+        else if (n === 29) delete s.strike;
+        else if (n >= 30 && n <= 37) s.fg = palette[n - 30];
+        else if (n >= 90 && n <= 97) s.fg = palette[n - 90 + 8];
+        else if (n === 39) delete s.fg;
+        else if (n >= 40 && n <= 47) s.bg = palette[n - 40];
+        else if (n >= 100 && n <= 107) s.bg = palette[n - 100 + 8];
+        else if (n === 49) delete s.bg;
+        else if (n === 38 || n === 48) {
+          const key = n === 38 ? "fg" : "bg";
+          if (args[i + 1] === "5") {
+            s[key] = color256(Math.min(255, parseInt(args[i + 2], 10) || 0));
+            i += 2;
+          } else if (args[i + 1] === "2") {
+            const c = (j) => Math.min(255, parseInt(args[i + j], 10) || 0);
+            s[key] = rgb(c(2), c(3), c(4));
+            i += 4;
+          }
+        }
+      }
+      this.style = Object.freeze(s);
+    }
+
+    touch(y) { this.dirty.add(y); }
+    touchAll() { for (let y = 0; y < this.rows; y++) this.dirty.add(y); }
+
+    css(style, cursor) {
+      let fg = style.fg || defaultFg, bg = style.bg || defaultBg;
+      if (!!style.inverse !== cursor) [fg, bg] = [bg, fg];
+      let css = "color:" + fg + ";background:" + bg;
+      if (style.bold) css += ";font-weight:bold";
+      if (style.faint) css += ";opacity:.6";
+      if (style.italic) css += ";font-style:italic";
+      if (style.underline || style.strike) {
+        css += ";text-decoration:" + (style.underline ? "underline " : "") + (style.strike ? "line-through" : "");
+      }
+      return css;
+    }
+
+    render() {
+      this.frame = 0;
+      const cursorShown = this.cursorVisible && document.activeElement === this.el;
+      for (const y of this.dirty) {
+        if (y >= this.rows) continue;
+        let html = "", run = "", runStyle = null, runCursor = false;
+        const flush = () => {
+          if (run) html += '<span style="' + this.css(runStyle, runCursor) + '">' + run + "</span>";
+          run = "";
+        };
+        this.cells[y].forEach((c, x) => {
+          if (c.cont) return;
+          const cursor = cursorShown && y === this.y && x === this.x;
+          if (c.style !== runStyle || cursor !== runCursor) {
+            flush();
+            runStyle = c.style;
+            runCursor = cursor;
+          }
+          const text = c.text || " ";
+          const w = charWidth(text.codePointAt(0));
+          // Anything outside ASCII is held to its cells, so a font that
+          // draws it wider can't push the rest of the row out of line
+          run += text.codePointAt(0) < 0x80 ? escapeHTML(text)
+            : '<span class="term-cell" style="width:' + w + 'ch">' + escapeHTML(text) + "</span>";
+        });
+        flush();
+        this.screen.children[y].innerHTML = html;
+      }
+      this.dirty.clear();
+    }
+
+    key(e) {
+      if (e.metaKey || e.isComposing) return;
+      const cursor = (c) => (this.appCursor ? "\x1bO" : "\x1b[") + c;
+      const keys = {
+        Enter: "\r", Backspace: "\x7f", Tab: e.shiftKey ? "\x1b[Z" : "\t", Escape: "\x1b",
+        ArrowUp: cursor("A"), ArrowDown: cursor("B"), ArrowRight: cursor("C"), ArrowLeft: cursor("D"),
+        Home: cursor("H"), End: cursor("F"), Insert: "\x1b[2~", Delete: "\x1b[3~",
+        PageUp: "\x1b[5~", PageDown: "\x1b[6~",
+        F1: "\x1bOP", F2: "\x1bOQ", F3: "\x1bOR", F4: "\x1bOS",
+      };
+      let data = keys[e.key];
+      if (data === undefined && e.key.length === 1) {
+        data = e.key;
+        if (e.ctrlKey) {
+          const code = e.key.toUpperCase().charCodeAt(0);
+          if (e.key === " ") data = "\x00";
+          else if (code >= 0x40 && code <= 0x5f) data = String.fromCharCode(code - 0x40);
+          else return;
+        }
+      }
+      if (data === undefined) return;
+      if (e.altKey) data = "\x1b" + data;
+      e.preventDefault();
+      this.emit(data);
+    }
+  }
+
+  window.Term = Term;
+})();
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// textAgents are user agents that print what they fetch to a terminal, so
+// they get ANSI text. Everyone else is assumed to be a browser.
+var textAgents = []string{"curl/", "wget/", "httpie/", "xh/"}
+
+func isTextClient(r *http.Request) bool {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return true
+	}
+	for _, prefix := range textAgents {
+		if strings.HasPrefix(ua, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageWidth is the width asked for with ?width=N, or a COLUMNS header as in
+// curl -H "COLUMNS: $COLUMNS", else 80.
+func pageWidth(r *http.Request) int {
+	for _, v := range []string{r.URL.Query().Get("width"), r.Header.Get("Columns")} {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return min(max(n, 30), 200)
+		}
+	}
+	return 80
+}
+
+// servePage renders a section, or the summary for "", as ANSI text for curl
+// and as HTML for browsers. ?plain drops the colors from the text.
+func (o WebServerOptions) servePage(w http.ResponseWriter, r *http.Request, section string) {
+	text := isTextClient(r)
+	profile := termenv.TrueColor
+	switch {
+	case text && r.URL.Query().Has("plain"):
+		profile = termenv.Ascii
+	case text:
+		// Not every terminal shows true color, but they all do 256
+		profile = termenv.ANSI256
+	}
+	renderer := lipgloss.NewRenderer(&bytes.Buffer{})
+	renderer.SetColorProfile(profile)
+	renderer.SetHasDarkBackground(true)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+	out, err := o.Render(ctx, section, r.Host, renderer, pageWidth(r))
+	if err != nil {
+		log.Printf("Rendering /%s: %v", section, err)
+		http.Error(w, "Couldn't render this page, please try again later.", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Vary", "User-Agent")
+	if text {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, out+"\x1b[0m")
+		return
+	}
+
+	title := "clifolio"
+	if section != "" {
+		title = section + " · clifolio"
+	}
+	var nav strings.Builder
+	nav.WriteString(`<a href="/">terminal</a>`)
+	for _, name := range o.Sections {
+		fmt.Fprintf(&nav, ` · <a href="/%s">%s</a>`, name, name)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, sectionPage, html.EscapeString(title), nav.String(), ansiToHTML(out))
+}
+
+const sectionPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<style>
+body { margin: 0; padding: 16px; background: #0d1117; color: #c9d1d9; font: 14px/1.3 Menlo, Consolas, "DejaVu Sans Mono", monospace; }
+nav { margin-bottom: 16px; }
+a { color: #58a6ff; }
+pre { margin: 0; font: inherit; }
+</style>
+</head>
+<body>
+<nav>%s</nav>
+<pre>%s</pre>
+</body>
+</html>
+`
+
+// sgrState is the styling set by SGR escape sequences so far.
+type sgrState struct {
+	fg, bg                                  string
+	bold, faint, italic, underline, reverse bool
+}
+
+func (s sgrState) css() string {
+	fg, bg := s.fg, s.bg
+	if s.reverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = "#0d1117"
+		}
+		if bg == "" {
+			bg = "#c9d1d9"
+		}
+	}
+	var css []string
+	if fg != "" {
+		css = append(css, "color:"+fg)
+	}
+	if bg != "" {
+		css = append(css, "background:"+bg)
+	}
+	if s.bold {
+		css = append(css, "font-weight:bold")
+	}
+	if s.faint {
+		css = append(css, "opacity:.6")
+	}
+	if s.italic {
+		css = append(css, "font-style:italic")
+	}
+	if s.underline {
+		css = append(css, "text-decoration:underline")
+	}
+	return strings.Join(css, ";")
+}
+
+// ansiToHTML turns styled terminal text into HTML for a <pre>, keeping what
+// the SGR sequences set as inline styles. Other escape sequences are dropped.
+func ansiToHTML(s string) string {
+	var b strings.Builder
+	var state sgrState
+	var run strings.Builder
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if css := state.css(); css != "" {
+			fmt.Fprintf(&b, `<span style="%s">%s</span>`, css, html.EscapeString(run.String()))
+		} else {
+			b.WriteString(html.EscapeString(run.String()))
+		}
+		run.Reset()
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != 0x1b {
+			run.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) || s[i+1] != '[' {
+			continue
+		}
+		// A CSI sequence runs up to its final byte
+		j := i + 2
+		for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+			j++
+		}
+		if j < len(s) && s[j] == 'm' {
+			flush()
+			state = applySGR(state, s[i+2:j])
+		}
+		i = j
+	}
+	flush()
+	return b.String()
+}
+
+func applySGR(state sgrState, params string) sgrState {
+	var codes []int
+	for _, p := range strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' }) {
+		n, _ := strconv.Atoi(p)
+		codes = append(codes, n)
+	}
+	if len(codes) == 0 {
+		return sgrState{}
+	}
+
+	for i := 0; i < len(codes); i++ {
+		switch c := codes[i]; {
+		case c == 0:
+			state = sgrState{}
+		case c == 1:
+			state.bold = true
+		case c == 2:
+			state.faint = true
+		case c == 3:
+			state.italic = true
+		case c == 4:
+			state.underline = true
+		case c == 7:
+			state.reverse = true
+		case c == 22:
+			state.bold, state.faint = false, false
+		case c == 23:
+			state.italic = false
+		case c == 24:
+			state.underline = false
+		case c == 27:
+			state.reverse = false
+		case c >= 30 && c <= 37:
+			state.fg = ansiPalette(c - 30)
+		case c >= 90 && c <= 97:
+			state.fg = ansiPalette(c - 90 + 8)
+		case c == 39:
+			state.fg = ""
+		case c >= 40 && c <= 47:
+			state.bg = ansiPalette(c - 40)
+		case c >= 100 && c <= 107:
+			state.bg = ansiPalette(c - 100 + 8)
+		case c == 49:
+			state.bg = ""
+		case c == 38 || c == 48:
+			var color string
+			switch {
+			case i+2 < len(codes) && codes[i+1] == 5:
+				color = ansiPalette(codes[i+2])
+				i += 2
+			case i+4 < len(codes) && codes[i+1] == 2:
+				color = fmt.Sprintf("#%02x%02x%02x", codes[i+2]&0xff, codes[i+3]&0xff, codes[i+4]&0xff)
+				i += 4
+			}
+			if c == 38 {
+				state.fg = color
+			} else {
+				state.bg = color
+			}
+		}
+	}
+	return state
+}
+
+// ansiPalette is the xterm color for a 256-color index.
+func ansiPalette(n int) string {
+	basic := [16]string{
+		"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+		"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+	}
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return basic[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		v := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
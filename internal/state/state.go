@@ -14,6 +14,11 @@ const (
 	ScreenStats
 	ScreenMatrix
 	ScreenHacker
+	ScreenRepoFiles
+	ScreenAnalytics
+	ScreenGuestbook
+	ScreenAdmin
+	ScreenChat
 )
 
 func (s Screen) String() string {
@@ -36,6 +41,18 @@ func (s Screen) String() string {
 		return "Theme"
 	case ScreenStats:
 		return "GitHub Stats"
+	case ScreenMatrix:
+		return "Matrix"
+	case ScreenRepoFiles:
+		return "Repository Files"
+	case ScreenAnalytics:
+		return "Visitor Log"
+	case ScreenGuestbook:
+		return "Guestbook"
+	case ScreenAdmin:
+		return "Admin Console"
+	case ScreenChat:
+		return "Tavern"
 	default:
 		return "Unknown"
 	}
@@ -21,28 +21,28 @@ type TextStyles struct {
 
 func NewStyles(theme Theme) TextStyles {
 	return TextStyles{
-		Title: lipgloss.NewStyle().
+		Title: theme.NewStyle().
 			Foreground(theme.Primary).
 			Bold(true).
 			MarginBottom(1),
 		
-		Subtitle: lipgloss.NewStyle().
+		Subtitle: theme.NewStyle().
 			Foreground(theme.Secondary).
 			Italic(true),
 
-		Body: lipgloss.NewStyle().
+		Body: theme.NewStyle().
 			Foreground(lipgloss.Color("#ffffff")),
 		
-		Code: lipgloss.NewStyle().
+		Code: theme.NewStyle().
 			Foreground(theme.Accent).
 			Background(lipgloss.Color("#1a1a1a")).
 			Padding(0, 1),
 		
-		Highlighted: lipgloss.NewStyle().
+		Highlighted: theme.NewStyle().
             Foreground(theme.Accent).
             Bold(true),
         
-        Dimmed: lipgloss.NewStyle().
+        Dimmed: theme.NewStyle().
             Foreground(theme.Secondary).
             Faint(true),
 	}
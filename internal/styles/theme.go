@@ -11,9 +11,36 @@ type Theme struct {
 	Error      lipgloss.TerminalColor
 	Title      lipgloss.Style
 	Label      lipgloss.Style
+
+	// Renderer is the terminal the theme's styles are rendered for. Over SSH
+	// this is the visitor's terminal, not the server's stdout.
+	Renderer *lipgloss.Renderer
+}
+
+// NewStyle returns a style that renders for the theme's terminal.
+func (t Theme) NewStyle() lipgloss.Style {
+	if t.Renderer == nil {
+		return lipgloss.NewStyle()
+	}
+	return t.Renderer.NewStyle()
 }
 
 func NewThemeFromName(name string) Theme {
+	return NewTheme(name, lipgloss.DefaultRenderer())
+}
+
+// NewTheme returns the named theme bound to the renderer r. Styles built
+// with Theme.NewStyle then render for r's terminal.
+func NewTheme(name string, r *lipgloss.Renderer) Theme {
+	if r == nil {
+		r = lipgloss.DefaultRenderer()
+	}
+	t := palette(name)
+	t.Renderer = r
+	return t
+}
+
+func palette(name string) Theme {
 	switch name {
 	case "warrior":
 		return Theme{
@@ -75,7 +102,7 @@ func NewThemeFromName(name string) Theme {
 }
 
 func (t Theme) BuildStyles() (title lipgloss.Style, label lipgloss.Style) {
-	title = lipgloss.NewStyle().Foreground(t.Primary)
-	label = lipgloss.NewStyle().Foreground(t.Secondary)
+	title = t.NewStyle().Foreground(t.Primary)
+	label = t.NewStyle().Foreground(t.Secondary)
 	return
 }
@@ -0,0 +1,721 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/state"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type adminTab int
+
+const (
+	adminSessions adminTab = iota
+	adminGuestbook
+	adminAnnounce
+	adminCache
+	adminRecordings
+	adminBans
+)
+
+var adminTabs = []string{"Sessions", "Guestbook", "Announce", "GitHub Cache", "Recordings", "Bans"}
+
+// announcementDuration is how long a broadcast banner stays up.
+const announcementDuration = 2 * time.Minute
+
+type adminModel struct {
+	hub       *services.Hub
+	guestbook *services.Guestbook
+	recorder  *services.Recorder
+	access    *services.Access
+	selfID    string
+
+	tickGen  int
+	tab      adminTab
+	sessions []*services.LiveSession
+	pending  []services.GuestbookEntry
+	casts    []services.RecordingInfo
+	bans     []services.Ban
+	cursor   int
+	replay   *replayModel
+
+	announce   textinput.Model
+	banInput   textinput.Model
+	cache      services.CacheStats
+	refreshing bool
+	status     string
+	statusErr  bool
+
+	spin   components.SpinnerComponent
+	theme  styles.Theme
+	keymap components.Keymap
+	width  int
+	height int
+}
+
+// adminTickMsg refreshes the live views. gen drops ticks from a previous
+// visit to the screen so only one refresh loop runs.
+type adminTickMsg struct {
+	gen int
+}
+
+type cacheRefreshedMsg struct {
+	repos int
+	err   error
+}
+
+func NewAdminModel(hub *services.Hub, guestbook *services.Guestbook, recorder *services.Recorder, access *services.Access, selfID string, theme styles.Theme) *adminModel {
+	ti := textinput.New()
+	ti.Placeholder = "Message for everyone currently connected..."
+	ti.CharLimit = 120
+	ti.Width = 60
+
+	bi := textinput.New()
+	bi.Placeholder = "Address, CIDR or SHA256 fingerprint, then a duration like 24h..."
+	bi.CharLimit = 120
+	bi.Width = 60
+
+	m := &adminModel{
+		hub:       hub,
+		guestbook: guestbook,
+		recorder:  recorder,
+		access:    access,
+		selfID:    selfID,
+		announce:  ti,
+		banInput:  bi,
+		spin:      components.NewSpinner(theme),
+		theme:     theme,
+		keymap:    components.DefaultKeymap(),
+	}
+	m.reload()
+	return m
+}
+
+func adminTick(gen int) tea.Cmd {
+	return tea.Tick(2*time.Second, func(time.Time) tea.Msg { return adminTickMsg{gen: gen} })
+}
+
+func refreshCacheCmd() tea.Cmd {
+	return func() tea.Msg {
+		services.RefreshGitHubCache()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		// Warm the entries every visitor needs first
+		repos, err := services.FetchRepos(ctx, githubUsername)
+		if err != nil {
+			return cacheRefreshedMsg{err: err}
+		}
+		if _, err := services.FetchGitHubStats(ctx, githubUsername); err != nil {
+			return cacheRefreshedMsg{err: err}
+		}
+		return cacheRefreshedMsg{repos: len(repos)}
+	}
+}
+
+func (m *adminModel) reload() {
+	if m.hub != nil {
+		m.sessions = m.hub.Sessions()
+	}
+	if m.guestbook != nil {
+		m.pending = m.guestbook.Entries(services.EntryPending)
+	}
+	m.cache = services.GitHubCacheStats()
+	if m.recorder != nil && m.tab == adminRecordings {
+		casts, err := m.recorder.List()
+		if err != nil {
+			m.setStatus("Listing recordings: "+err.Error(), true)
+		}
+		m.casts = casts
+	}
+	m.bans = m.access.Bans()
+	m.cursor = min(m.cursor, max(0, m.rows()-1))
+}
+
+func (m *adminModel) rows() int {
+	switch m.tab {
+	case adminSessions:
+		return len(m.sessions)
+	case adminGuestbook:
+		return len(m.pending)
+	case adminRecordings:
+		return len(m.casts)
+	case adminBans:
+		return len(m.bans)
+	}
+	return 0
+}
+
+// CapturingInput keeps global shortcuts out of the way while typing.
+func (m *adminModel) CapturingInput() bool {
+	return (m.tab == adminAnnounce && m.announce.Focused()) ||
+		(m.tab == adminBans && m.banInput.Focused()) || m.replay != nil
+}
+
+func (m *adminModel) Init() tea.Cmd {
+	m.reload()
+	m.tickGen++
+	return adminTick(m.tickGen)
+}
+
+func (m *adminModel) setStatus(msg string, isErr bool) {
+	m.status = msg
+	m.statusErr = isErr
+}
+
+func (m *adminModel) switchTab(tab adminTab) tea.Cmd {
+	m.tab = tab
+	m.cursor = 0
+	m.status = ""
+	m.reload()
+	if tab == adminAnnounce {
+		return m.announce.Focus()
+	}
+	m.announce.Blur()
+	m.banInput.Blur()
+	return nil
+}
+
+func (m *adminModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.replay != nil {
+		switch msg.(type) {
+		case closeReplayMsg:
+			m.replay = nil
+			m.reload()
+			return m, nil
+		case tea.KeyMsg, replayTickMsg:
+			_, cmd := m.replay.Update(msg)
+			return m, cmd
+		case tea.WindowSizeMsg:
+			m.replay.Update(msg)
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case adminTickMsg:
+		if msg.gen != m.tickGen {
+			return m, nil
+		}
+		m.reload()
+		return m, adminTick(m.tickGen)
+
+	case cacheRefreshedMsg:
+		m.refreshing = false
+		m.reload()
+		if msg.err != nil {
+			m.setStatus("Refresh failed: "+msg.err.Error(), true)
+		} else {
+			m.setStatus(fmt.Sprintf("Cache refreshed, %d repositories fetched.", msg.repos), false)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "tab":
+			return m, m.switchTab((m.tab + 1) % adminTab(len(adminTabs)))
+		case "shift+tab":
+			return m, m.switchTab((m.tab + adminTab(len(adminTabs)) - 1) % adminTab(len(adminTabs)))
+		}
+
+		if m.tab == adminAnnounce && m.announce.Focused() {
+			return m.updateAnnounce(msg)
+		}
+		if m.tab == adminBans && m.banInput.Focused() {
+			return m.updateBanInput(msg)
+		}
+
+		switch msg.String() {
+		case m.keymap.Quit:
+			return m, tea.Quit
+		case m.keymap.Back, "esc":
+			return m, func() tea.Msg { return state.ScreenMenu }
+		case "1", "2", "3", "4", "5", "6":
+			return m, m.switchTab(adminTab(msg.String()[0] - '1'))
+		case m.keymap.Up, "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case m.keymap.Down, "down":
+			if m.cursor < m.rows()-1 {
+				m.cursor++
+			}
+		case "d":
+			if m.tab == adminSessions && m.cursor < len(m.sessions) {
+				s := m.sessions[m.cursor]
+				if s.ID == m.selfID {
+					m.setStatus("That's your own session, use q to leave.", true)
+				} else if m.hub.Disconnect(s.ID) {
+					m.setStatus("Disconnected "+s.User+" from "+s.IP+".", false)
+				}
+				m.reload()
+			}
+		case "B":
+			if m.tab == adminSessions && m.cursor < len(m.sessions) {
+				m.banSession(m.sessions[m.cursor])
+				m.reload()
+			}
+		case "u":
+			if m.tab == adminBans && m.cursor < len(m.bans) {
+				b := m.bans[m.cursor]
+				if err := m.access.Unban(b.Target); err != nil {
+					m.setStatus(err.Error(), true)
+				} else {
+					m.setStatus("Lifted the ban on "+b.Target+".", false)
+				}
+				m.reload()
+			}
+		case "a", "x":
+			if m.tab == adminGuestbook && m.cursor < len(m.pending) {
+				e := m.pending[m.cursor]
+				if err := m.guestbook.Moderate(e.ID, msg.String() == "a"); err != nil {
+					m.setStatus(err.Error(), true)
+				} else if msg.String() == "a" {
+					m.setStatus("Approved the entry from "+e.Author()+".", false)
+				} else {
+					m.setStatus("Rejected the entry from "+e.Author()+".", false)
+				}
+				m.reload()
+			}
+		case "c":
+			if m.tab == adminAnnounce && m.hub != nil {
+				m.hub.Announce("", 0)
+				m.setStatus("Announcement cleared.", false)
+			}
+		case "i", "enter":
+			if m.tab == adminAnnounce {
+				return m, m.announce.Focus()
+			}
+			if m.tab == adminBans && m.access != nil {
+				return m, m.banInput.Focus()
+			}
+			if m.tab == adminRecordings && msg.String() == "enter" && m.cursor < len(m.casts) {
+				return m, m.openReplay(m.casts[m.cursor].Name)
+			}
+		case "r":
+			if m.tab == adminCache && !m.refreshing {
+				m.refreshing = true
+				m.status = ""
+				return m, tea.Batch(m.spin.Init(), refreshCacheCmd())
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.spin, cmd = m.spin.Update(msg)
+	return m, cmd
+}
+
+func (m *adminModel) openReplay(name string) tea.Cmd {
+	cast, err := m.recorder.Load(name)
+	if err != nil {
+		m.setStatus("Opening the recording: "+err.Error(), true)
+		return nil
+	}
+	if len(cast.Events) == 0 {
+		m.setStatus("That recording is empty.", true)
+		return nil
+	}
+	m.status = ""
+	m.replay = newReplayModel(cast, m.theme)
+	m.replay.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	return m.replay.Init()
+}
+
+func (m *adminModel) updateAnnounce(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.announce.Blur()
+		return m, nil
+	case "enter":
+		text := strings.TrimSpace(m.announce.Value())
+		if text == "" {
+			return m, nil
+		}
+		if m.hub == nil {
+			m.setStatus("Announcements need the SSH server.", true)
+			return m, nil
+		}
+		m.hub.Announce(text, announcementDuration)
+		m.announce.Reset()
+		m.announce.Blur()
+		m.setStatus(fmt.Sprintf("Announced to %d sessions.", len(m.hub.Sessions())), false)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.announce, cmd = m.announce.Update(msg)
+	return m, cmd
+}
+
+// banSession bans the visitor behind s, by key when they have one since
+// addresses are often shared, and disconnects them.
+func (m *adminModel) banSession(s *services.LiveSession) {
+	switch {
+	case m.access == nil:
+		m.setStatus("Bans need the SSH server.", true)
+		return
+	case s.ID == m.selfID || s.Owner:
+		m.setStatus("The owner can't be banned.", true)
+		return
+	}
+	target := s.Fingerprint
+	if target == "" {
+		target = s.IP
+	}
+	if err := m.access.Ban(target, "banned by the owner", 0); err != nil {
+		m.setStatus(err.Error(), true)
+		return
+	}
+	m.hub.Disconnect(s.ID)
+	m.setStatus("Banned and disconnected "+s.User+" ("+target+").", false)
+}
+
+func (m *adminModel) updateBanInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.banInput.Blur()
+		return m, nil
+	case "enter":
+		fields := strings.Fields(m.banInput.Value())
+		if len(fields) == 0 {
+			return m, nil
+		}
+		var d time.Duration
+		if len(fields) > 1 {
+			var err error
+			if d, err = time.ParseDuration(fields[1]); err != nil {
+				m.setStatus(fmt.Sprintf("%q isn't a duration like 24h.", fields[1]), true)
+				return m, nil
+			}
+		}
+		if err := m.access.Ban(fields[0], "banned by the owner", d); err != nil {
+			m.setStatus(err.Error(), true)
+			return m, nil
+		}
+		m.banInput.Reset()
+		m.banInput.Blur()
+		if d > 0 {
+			m.setStatus(fmt.Sprintf("Banned %s for %s.", fields[0], d), false)
+		} else {
+			m.setStatus("Banned "+fields[0]+" until lifted.", false)
+		}
+		m.reload()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.banInput, cmd = m.banInput.Update(msg)
+	return m, cmd
+}
+
+func (m *adminModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	if m.replay != nil {
+		return m.replay.View()
+	}
+
+	var sections []string
+	sections = append(sections, components.HeaderBox("ADMIN CONSOLE", m.theme, m.width-4))
+	sections = append(sections, m.renderTabs())
+	sections = append(sections, components.DividerLine(m.theme, m.width-4, "─"))
+
+	var body string
+	switch m.tab {
+	case adminSessions:
+		body = m.renderSessions()
+	case adminGuestbook:
+		body = m.renderPending()
+	case adminAnnounce:
+		body = m.renderAnnounce()
+	case adminCache:
+		body = m.renderCache()
+	case adminRecordings:
+		body = m.renderRecordings()
+	case adminBans:
+		body = m.renderBans()
+	}
+	sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, body))
+
+	if m.status != "" {
+		var color lipgloss.TerminalColor = styles.Success
+		if m.statusErr {
+			color = m.theme.Error
+		}
+		sections = append(sections, m.theme.NewStyle().
+			Foreground(color).
+			Bold(true).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Render(m.status))
+	}
+
+	sections = append(sections, components.RenderKeyBindings(m.keyBindings(), m.theme, m.width))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+func (m *adminModel) renderTabs() string {
+	active := m.theme.NewStyle().
+		Foreground(m.theme.Background).
+		Background(m.theme.Accent).
+		Bold(true).
+		Padding(0, 2)
+	inactive := m.theme.NewStyle().
+		Foreground(m.theme.Secondary).
+		Padding(0, 2)
+
+	var tabs []string
+	for i, name := range adminTabs {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if adminTab(i) == adminGuestbook && len(m.pending) > 0 {
+			label += fmt.Sprintf(" (%d)", len(m.pending))
+		}
+		if adminTab(i) == m.tab {
+			tabs = append(tabs, active.Render(label))
+		} else {
+			tabs = append(tabs, inactive.Render(label))
+		}
+	}
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+}
+
+func (m *adminModel) renderSessions() string {
+	if m.hub == nil {
+		return m.dim("Live sessions are only tracked in SSH mode.")
+	}
+
+	// The table doesn't space its columns, so every cell carries its own gap
+	headers := []string{"User  ", "Address  ", "Key  ", "Terminal  ", "Screen  ", "Connected"}
+	rows := make([][]string, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		user := s.User
+		if s.ID == m.selfID {
+			user += " (you)"
+		} else if s.Owner {
+			user += " (owner)"
+		}
+		key := "none"
+		if s.Fingerprint != "" {
+			key = strings.TrimPrefix(s.Fingerprint, "SHA256:")[:10]
+		}
+		rows = append(rows, []string{
+			user + "  ",
+			s.IP + "  ",
+			key + "  ",
+			s.Term + "  ",
+			s.Screen().String() + "  ",
+			time.Since(s.Started).Round(time.Second).String(),
+		})
+	}
+
+	title := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true).
+		Render(fmt.Sprintf("%d connected", len(m.sessions)))
+	return lipgloss.JoinVertical(lipgloss.Left, title, "",
+		components.RenderTableList(headers, rows, m.cursor, m.theme))
+}
+
+func (m *adminModel) renderPending() string {
+	if m.guestbook == nil {
+		return m.dim("The guestbook is disabled.")
+	}
+	if len(m.pending) == 0 {
+		return m.dim("Nothing waiting for approval.")
+	}
+
+	authorStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	dateStyle := m.theme.NewStyle().Foreground(m.theme.Secondary)
+	width := min(m.width-8, 90)
+
+	var cards []string
+	for i, e := range m.pending {
+		border := m.theme.Secondary
+		if i == m.cursor {
+			border = m.theme.Accent
+		}
+		cards = append(cards, m.theme.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Padding(0, 1).
+			Width(width).
+			Render(authorStyle.Render(e.Author())+dateStyle.Render(" · "+e.Time.Format("Jan 2 15:04"))+"\n"+e.Message))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, cards...)
+}
+
+func (m *adminModel) renderAnnounce() string {
+	labelStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	lines := []string{
+		labelStyle.Render("Broadcast a banner to every session"),
+		"",
+		m.announce.View(),
+		"",
+		m.dimLeft("Banners stay up for two minutes, new visitors see them too."),
+	}
+	return m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Accent).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (m *adminModel) renderCache() string {
+	labelStyle := m.theme.NewStyle().Foreground(m.theme.Secondary).Width(14)
+	valueStyle := m.theme.NewStyle().Foreground(m.theme.Primary).Bold(true)
+
+	age := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return time.Since(t).Round(time.Second).String() + " ago"
+	}
+
+	lines := []string{
+		labelStyle.Render("Entries") + valueStyle.Render(fmt.Sprint(m.cache.Entries)),
+		labelStyle.Render("Time to live") + valueStyle.Render(m.cache.TTL.String()),
+		labelStyle.Render("Oldest") + valueStyle.Render(age(m.cache.Oldest)),
+		labelStyle.Render("Newest") + valueStyle.Render(age(m.cache.Newest)),
+	}
+	if m.refreshing {
+		lines = append(lines, "", m.spin.View()+" Fetching fresh data from GitHub...")
+	}
+
+	return m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Secondary).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (m *adminModel) renderRecordings() string {
+	if m.recorder == nil {
+		return m.dim("Session recording is off, set CLIFOLIO_RECORDING=true to turn it on.")
+	}
+	if len(m.casts) == 0 {
+		return m.dim("No recordings yet.")
+	}
+
+	headers := []string{"Started  ", "Visitor  ", "Terminal  ", "Size  ", "Length"}
+	rows := make([][]string, 0, len(m.casts))
+	for _, c := range m.casts {
+		visitor := "unknown"
+		if c.Visitor != "" {
+			visitor = strings.TrimPrefix(c.Visitor, "SHA256:")
+			visitor = visitor[:min(10, len(visitor))]
+		}
+		rows = append(rows, []string{
+			c.Start.Local().Format("Jan 2 15:04") + "  ",
+			visitor + "  ",
+			fmt.Sprintf("%s %dx%d  ", c.Term, c.Width, c.Height),
+			fmt.Sprintf("%.1f KB  ", float64(c.Size)/1024),
+			c.Duration.String(),
+		})
+	}
+
+	title := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true).
+		Render(fmt.Sprintf("%d recordings", len(m.casts)))
+	return lipgloss.JoinVertical(lipgloss.Left, title, "",
+		components.RenderTableList(headers, rows, m.cursor, m.theme))
+}
+
+func (m *adminModel) renderBans() string {
+	if m.access == nil {
+		return m.dim("Bans are only kept in SSH mode.")
+	}
+
+	var list string
+	if len(m.bans) == 0 {
+		list = m.dim("Nobody is banned.")
+	} else {
+		headers := []string{"Target  ", "Reason  ", "Since  ", "Lifts"}
+		rows := make([][]string, 0, len(m.bans))
+		for _, b := range m.bans {
+			target := b.Target
+			if b.IsKey() {
+				target = "key " + strings.TrimPrefix(target, "SHA256:")[:min(10, len(target)-7)]
+			}
+			lifts := "manually"
+			if !b.Expires.IsZero() {
+				lifts = "in " + strings.TrimSuffix(time.Until(b.Expires).Round(time.Minute).String(), "0s")
+			}
+			rows = append(rows, []string{
+				target + "  ",
+				b.Reason + "  ",
+				b.Created.Local().Format("Jan 2 15:04") + "  ",
+				lifts,
+			})
+		}
+		title := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true).
+			Render(fmt.Sprintf("%d banned", len(m.bans)))
+		list = lipgloss.JoinVertical(lipgloss.Left, title, "",
+			components.RenderTableList(headers, rows, m.cursor, m.theme))
+	}
+	if !m.banInput.Focused() {
+		return list
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, list, "", m.banInput.View())
+}
+
+func (m *adminModel) dim(s string) string {
+	return m.theme.NewStyle().
+		Foreground(m.theme.Secondary).
+		Italic(true).
+		Padding(1, 0).
+		Render(s)
+}
+
+func (m *adminModel) dimLeft(s string) string {
+	return m.theme.NewStyle().Foreground(m.theme.Secondary).Italic(true).Render(s)
+}
+
+func (m *adminModel) keyBindings() []components.KeyBind {
+	binds := []components.KeyBind{{Key: "Tab/1-6", Desc: "Switch"}}
+	switch m.tab {
+	case adminSessions:
+		binds = append(binds, components.KeyBind{Key: "↑↓", Desc: "Select"}, components.KeyBind{Key: "d", Desc: "Disconnect"}, components.KeyBind{Key: "B", Desc: "Ban"})
+	case adminGuestbook:
+		binds = append(binds, components.KeyBind{Key: "↑↓", Desc: "Select"}, components.KeyBind{Key: "a", Desc: "Approve"}, components.KeyBind{Key: "x", Desc: "Reject"})
+	case adminAnnounce:
+		if m.announce.Focused() {
+			return []components.KeyBind{{Key: "Enter", Desc: "Broadcast"}, {Key: "Esc", Desc: "Stop Typing"}}
+		}
+		binds = append(binds, components.KeyBind{Key: "i", Desc: "Write"}, components.KeyBind{Key: "c", Desc: "Clear Banner"})
+	case adminCache:
+		binds = append(binds, components.KeyBind{Key: "r", Desc: "Refresh"})
+	case adminRecordings:
+		binds = append(binds, components.KeyBind{Key: "↑↓", Desc: "Select"}, components.KeyBind{Key: "Enter", Desc: "Replay"})
+	case adminBans:
+		if m.banInput.Focused() {
+			return []components.KeyBind{{Key: "Enter", Desc: "Ban"}, {Key: "Esc", Desc: "Stop Typing"}}
+		}
+		binds = append(binds, components.KeyBind{Key: "↑↓", Desc: "Select"}, components.KeyBind{Key: "i", Desc: "New Ban"}, components.KeyBind{Key: "u", Desc: "Lift"})
+	}
+	return append(binds, components.KeyBind{Key: "b/Esc", Desc: "Retreat"})
+}
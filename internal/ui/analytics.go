@@ -0,0 +1,223 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/state"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const analyticsDays = 14
+
+type analyticsModel struct {
+	store   *services.Analytics
+	summary services.AnalyticsSummary
+	loading bool
+	err     error
+	spin    components.SpinnerComponent
+	theme   styles.Theme
+	keymap  components.Keymap
+	width   int
+	height  int
+}
+
+type analyticsLoadedMsg struct {
+	summary services.AnalyticsSummary
+	err     error
+}
+
+func NewAnalyticsModel(store *services.Analytics, theme styles.Theme) *analyticsModel {
+	return &analyticsModel{
+		store:   store,
+		loading: store != nil,
+		spin:    components.NewSpinner(theme),
+		theme:   theme,
+		keymap:  components.DefaultKeymap(),
+	}
+}
+
+func loadAnalyticsCmd(store *services.Analytics) tea.Cmd {
+	return func() tea.Msg {
+		records, err := store.Load()
+		if err != nil {
+			return analyticsLoadedMsg{err: err}
+		}
+		return analyticsLoadedMsg{summary: services.Summarize(records, analyticsDays, time.Now())}
+	}
+}
+
+func (m *analyticsModel) Init() tea.Cmd {
+	if m.store == nil {
+		return nil
+	}
+	m.loading = true
+	return tea.Batch(m.spin.Init(), loadAnalyticsCmd(m.store))
+}
+
+func (m *analyticsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.spin, cmd = m.spin.Update(msg)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case analyticsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		m.summary = msg.summary
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case m.keymap.Quit, "ctrl+c":
+			return m, tea.Quit
+		case m.keymap.Back, "esc":
+			return m, func() tea.Msg { return state.ScreenMenu }
+		case "r":
+			return m, m.Init()
+		}
+	}
+
+	return m, cmd
+}
+
+func (m *analyticsModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var sections []string
+	sections = append(sections, components.HeaderBox("VISITOR LOG", m.theme, m.width-4))
+
+	dimStyle := m.theme.NewStyle().
+		Foreground(m.theme.Secondary).
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+
+	switch {
+	case m.store == nil:
+		sections = append(sections, dimStyle.Render("Analytics are disabled on this server."))
+	case m.loading:
+		sections = append(sections, dimStyle.Render(m.spin.View()+" Reading the visitor log..."))
+	case m.err != nil:
+		sections = append(sections, m.theme.NewStyle().Foreground(m.theme.Error).Render(fmt.Sprintf("Error: %v", m.err)))
+	default:
+		sections = append(sections, m.renderTotals())
+		sections = append(sections, components.DividerLine(m.theme, m.width-4, "─"))
+		sections = append(sections, m.renderPerDay())
+
+		half := (m.width - 12) / 2
+		tops := lipgloss.JoinHorizontal(lipgloss.Top,
+			m.renderTop("Most viewed screens", m.summary.TopScreens, half),
+			"  ",
+			m.renderTop("Top projects opened", m.summary.TopProjects, half),
+		)
+		sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, tops))
+	}
+
+	keyBindings := []components.KeyBind{
+		{Key: "r", Desc: "Refresh"},
+		{Key: "b/Esc", Desc: "Retreat"},
+		{Key: "q", Desc: "Exit Realm"},
+	}
+	sections = append(sections, components.RenderKeyBindings(keyBindings, m.theme, m.width))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+func (m *analyticsModel) renderTotals() string {
+	stats := []string{
+		fmt.Sprintf("Sessions: %d", m.summary.Sessions),
+		fmt.Sprintf("Visitors: %d", m.summary.Visitors),
+		fmt.Sprintf("Avg session: %s", m.summary.AverageSession),
+	}
+
+	statStyle := m.theme.NewStyle().
+		Foreground(m.theme.Primary).
+		Bold(true).
+		Padding(0, 3)
+
+	var boxes []string
+	for _, s := range stats {
+		boxes = append(boxes, statStyle.Render(s))
+	}
+
+	container := m.theme.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(m.theme.Secondary).
+		Padding(0, 1).
+		Render(lipgloss.JoinHorizontal(lipgloss.Top, boxes...))
+
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, container)
+}
+
+func (m *analyticsModel) renderPerDay() string {
+	peak := 1
+	for _, d := range m.summary.PerDay {
+		peak = max(peak, d.Visitors)
+	}
+
+	labelStyle := m.theme.NewStyle().Foreground(m.theme.Secondary).Width(8)
+	barStyle := m.theme.NewStyle().Foreground(m.theme.Accent)
+	countStyle := m.theme.NewStyle().Foreground(m.theme.Primary)
+
+	barWidth := max(10, m.width-64)
+	var lines []string
+	for _, d := range m.summary.PerDay {
+		n := d.Visitors * barWidth / peak
+		bar := strings.Repeat("█", n)
+		if n == 0 && d.Visitors > 0 {
+			bar = "▏"
+		}
+		lines = append(lines, labelStyle.Render(d.Day.Format("Jan 02"))+
+			barStyle.Render(bar)+" "+
+			countStyle.Render(fmt.Sprintf("%d visitors, %d sessions", d.Visitors, d.Sessions)))
+	}
+
+	return lipgloss.PlaceHorizontal(
+		m.width,
+		lipgloss.Center,
+		components.SectionBox("", strings.Join(lines, "\n"), m.theme, m.width-8),
+	)
+}
+
+func (m *analyticsModel) renderTop(title string, counts []services.NameCount, width int) string {
+	titleStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	nameStyle := m.theme.NewStyle().Foreground(m.theme.Primary)
+	countStyle := m.theme.NewStyle().Foreground(m.theme.Secondary)
+
+	lines := []string{titleStyle.Render(title)}
+	if len(counts) == 0 {
+		lines = append(lines, countStyle.Render("Nothing yet"))
+	}
+	for i, c := range counts {
+		if i == 5 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s %s", i+1, nameStyle.Render(c.Name), countStyle.Render(fmt.Sprintf("(%d)", c.Count))))
+	}
+
+	return m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Secondary).
+		Padding(0, 1).
+		Width(width).
+		Render(strings.Join(lines, "\n"))
+}
@@ -1,13 +1,27 @@
 package ui
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"clifolio/internal/services"
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
-	"clifolio/internal/ui/state"
+	"clifolio/internal/ui/components"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
 )
 
+type announcementExpiredMsg struct {
+	announcement services.AnnouncementMsg
+}
+
+// githubUsername is whose repositories and stats the portfolio shows.
+const githubUsername = "Polqt"
+
 type appModel struct {
 	screen state.Screen
 
@@ -15,17 +29,64 @@ type appModel struct {
 	menu          tea.Model
 	projects      tea.Model
 	projectDetail tea.Model
+	fileBrowser   tea.Model
 	skills        tea.Model
 	experience    tea.Model
 	contact       tea.Model
 	themePicker   tea.Model
 	stats         tea.Model
 	matrix        tea.Model
+	analytics     tea.Model
+	guestbook     tea.Model
+	admin         tea.Model
+	chat          tea.Model
+
+	theme        string
+	announcement services.AnnouncementMsg
+	presence     services.PresenceMsg
+	menuOpen     bool
+	width        int
+	height       int
+
+	opts Options
+}
+
+// Options describes the terminal the app renders to. Local runs detect it
+// from the process environment, SSH sessions from what the client sent.
+type Options struct {
+	Graphics services.GraphicsProtocol
+	// Renderer styles every screen for the client's color support.
+	Renderer *lipgloss.Renderer
+	// Tracker records the screens this session visits. Nil disables it.
+	Tracker *services.SessionTracker
+	// Owner unlocks owner-only screens such as the visitor log.
+	Owner     bool
+	Analytics *services.Analytics
+	// Key is the public key the visitor connected with, nil without one.
+	Key       ssh.PublicKey
+	Guestbook *services.Guestbook
+	// Live is this session's entry in the hub, which the owner's admin
+	// console reads through Hub. Both are nil outside SSH mode.
+	Live *services.LiveSession
+	Hub  *services.Hub
+	// Inbox receives messages from the contact form, which is hidden when
+	// it's nil.
+	Inbox *services.Inbox
+	// Recorder lists session recordings for the admin console's replay.
+	Recorder *services.Recorder
+	// Access holds the bans the admin console manages.
+	Access *services.Access
+	// Clipboard is where copied values go, the visitor's terminal over SSH.
+	// Nil shows them for copying by hand.
+	Clipboard services.Clipboard
+}
 
-	theme    string
-	menuOpen bool
-	width    int
-	height   int
+func LocalOptions() Options {
+	return Options{
+		Graphics:  services.DetectGraphics(os.Environ()),
+		Renderer:  lipgloss.DefaultRenderer(),
+		Clipboard: services.LocalClipboard(),
+	}
 }
 
 func AppWithTheme(themeName string) tea.Model {
@@ -39,28 +100,144 @@ func AppWithTheme(themeName string) tea.Model {
 }
 
 func AppModel() appModel {
+	return NewAppModel(LocalOptions())
+}
+
+func NewAppModel(opts Options) appModel {
+	theme := styles.NewTheme("default", opts.Renderer)
 	return appModel{
 		screen:        state.ScreenIntro,
-		intro:         IntroModel(),
-		menu:          MenuModel(),
-		projects:      ProjectsModel("Polqt"),
-		projectDetail: ProjectDetailsModel(services.Repo{}, ""),
-		skills:        SkillsModel(),
-		experience:    ExperienceModel(),
-		contact:       ContactModel(),
-		themePicker:   ThemePickerModel(),
-		stats:         StatsModel("Polqt"),
-		matrix:        MatrixModel(),
+		intro:         IntroModel(theme),
+		menu:          newMenu(theme, opts),
+		projects:      ProjectsModel(githubUsername, theme),
+		projectDetail: ProjectDetailsModel(services.Repo{}, "", theme, opts.Graphics),
+		skills:        NewSkillsModel(theme),
+		experience:    NewExperienceModel(theme),
+		contact:       newContact(theme, opts),
+		themePicker:   NewThemePickerModel(theme),
+		stats:         StatsModel(githubUsername, theme, opts.Graphics),
+		matrix:        MatrixModel(theme),
 		theme:         "default",
 		menuOpen:      false,
+		opts:          opts,
 	}
 }
 
+// currentTheme returns the active theme bound to this session's renderer.
+func (m appModel) currentTheme() styles.Theme {
+	return styles.NewTheme(m.theme, m.opts.Renderer)
+}
+
+// newMenu builds the main menu with the entries this session may use.
+func newMenu(theme styles.Theme, opts Options) *menuModel {
+	menu := NewMenuModel(theme)
+	if opts.Guestbook != nil {
+		menu.addItem(components.ListItem{
+			Title:   "Guestbook",
+			Content: "Leave your mark on the realm",
+			Icon:    "🪶",
+			Badge:   "Visitors",
+		}, state.ScreenGuestbook)
+	}
+	if opts.Hub != nil && opts.Live != nil {
+		menu.addItem(components.ListItem{
+			Title:   "Tavern",
+			Content: "Chat with the other travellers online",
+			Icon:    "💬",
+			Badge:   "Live",
+		}, state.ScreenChat)
+	}
+	if opts.Owner {
+		menu.addItem(components.ListItem{
+			Title:   "Admin Console",
+			Content: "Sessions, moderation and announcements",
+			Icon:    "🛡️",
+			Badge:   "Owner",
+		}, state.ScreenAdmin)
+		menu.addItem(components.ListItem{
+			Title:   "Visitor Log",
+			Content: "Who has been reading the chronicles",
+			Icon:    "🔭",
+			Badge:   "Owner",
+		}, state.ScreenAnalytics)
+	}
+	return menu
+}
+
+// newContact builds the contact screen, with the message form when there's
+// an inbox.
+func newContact(theme styles.Theme, opts Options) *contactModel {
+	sender := services.ContactSender{Fingerprint: services.Fingerprint(opts.Key), IP: visitorIP(opts)}
+	return NewContactModel(theme, opts.Clipboard, opts.Inbox, sender)
+}
+
+// visitorIP is the address the visitor connected from, for the per-address
+// limits, and "local" for the local app.
+func visitorIP(opts Options) string {
+	if opts.Live != nil {
+		return opts.Live.IP
+	}
+	return "local"
+}
+
 func (m appModel) Init() tea.Cmd {
 	return m.intro.Init()
 }
 
 func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if pm, ok := msg.(openProjectMsg); ok {
+		m.opts.Tracker.OpenProject(pm.repo.Name)
+	}
+
+	model, cmd := m.update(msg)
+	if app, ok := model.(appModel); ok {
+		if app.screen != m.screen {
+			app.opts.Tracker.Visit(app.screen)
+			app.opts.Live.SetScreen(app.screen)
+		}
+		// The banner and status bar come and go, and the screens get
+		// whatever rows they leave
+		if app.chromeHeight() != m.chromeHeight() {
+			app.resizeScreens()
+		}
+		model = app
+	}
+	return model, cmd
+}
+
+// screenSize is the area left to the screens once the announcement banner
+// and the status bar have their rows.
+func (m appModel) screenSize() tea.WindowSizeMsg {
+	return tea.WindowSizeMsg{Width: m.width, Height: max(1, m.height-m.chromeHeight())}
+}
+
+func (m appModel) chromeHeight() int {
+	h := 0
+	if banner := m.banner(); banner != "" {
+		h += lipgloss.Height(banner)
+	}
+	if m.statusBar() != "" {
+		h++
+	}
+	return h
+}
+
+// resizeScreens forwards the screen size to every initialized model.
+func (m *appModel) resizeScreens() {
+	size := m.screenSize()
+	models := []*tea.Model{
+		&m.intro, &m.menu, &m.projects, &m.skills, &m.experience, &m.contact,
+		&m.themePicker, &m.stats, &m.matrix, &m.analytics, &m.guestbook,
+		&m.admin, &m.chat, &m.projectDetail, &m.fileBrowser,
+	}
+	for _, model := range models {
+		if *model != nil {
+			*model, _ = (*model).Update(size)
+		}
+	}
+}
+
+func (m appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	// Handle WindowSizeMsg FIRST - forward to all models
@@ -68,38 +245,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = wsm.Width
 		m.height = wsm.Height
 
-		// Forward to all initialized models
-		if m.intro != nil {
-			m.intro, _ = m.intro.Update(msg)
-		}
-		if m.menu != nil {
-			m.menu, _ = m.menu.Update(msg)
-		}
-		if m.projects != nil {
-			m.projects, _ = m.projects.Update(msg)
-		}
-		if m.skills != nil {
-			m.skills, _ = m.skills.Update(msg)
-		}
-		if m.experience != nil {
-			m.experience, _ = m.experience.Update(msg)
-		}
-		if m.contact != nil {
-			m.contact, _ = m.contact.Update(msg)
-		}
-		if m.themePicker != nil {
-			m.themePicker, _ = m.themePicker.Update(msg)
-		}
-		if m.stats != nil {
-			m.stats, _ = m.stats.Update(msg)
-		}
-		if m.matrix != nil {
-			m.matrix, _ = m.matrix.Update(msg)
-		}
-		if m.projectDetail != nil {
-			m.projectDetail, _ = m.projectDetail.Update(msg)
-		}
-
+		m.resizeScreens()
 		return m, nil
 	}
 
@@ -109,13 +255,46 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
-		// Global menu toggle (except during intro)
-		if key.String() == "/" && m.screen != state.ScreenIntro {
+		// Global menu toggle (except during intro and while typing)
+		if key.String() == "/" && m.screen != state.ScreenIntro && !m.capturingInput() {
 			m.screen = state.ScreenMenu
 			return m, nil
 		}
 	}
 
+	// Owner announcements are shown over every screen until they expire
+	if am, ok := msg.(services.AnnouncementMsg); ok {
+		m.announcement = am
+		if am.Text == "" {
+			return m, nil
+		}
+		return m, tea.Tick(time.Until(am.Expires), func(time.Time) tea.Msg { return announcementExpiredMsg{am} })
+	}
+	if ae, ok := msg.(announcementExpiredMsg); ok {
+		// A newer announcement may have replaced this one meanwhile
+		if m.announcement == ae.announcement {
+			m.announcement = services.AnnouncementMsg{}
+		}
+		return m, nil
+	}
+
+	// Presence feeds the status bar and the menu's per-screen counters
+	if pm, ok := msg.(services.PresenceMsg); ok {
+		m.presence = pm
+		if m.menu != nil {
+			m.menu, _ = m.menu.Update(pm)
+		}
+		return m, nil
+	}
+
+	// Chat lines arrive on every screen so the tavern is current on return
+	if cm, ok := msg.(services.ChatMsg); ok {
+		if m.chat != nil {
+			m.chat, _ = m.chat.Update(cm)
+		}
+		return m, nil
+	}
+
 	// Handle intro -> menu transition
 	if _, ok := msg.(goToMenuMsg); ok {
 		m.screen = state.ScreenMenu
@@ -131,26 +310,44 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle theme change
 	if tc, ok := msg.(ThemeChangeMsg); ok {
 		m.theme = tc.ThemeName
-		newTheme := styles.NewThemeFromName(m.theme)
+		newTheme := m.currentTheme()
 
 		// Reinitialize all models with new theme
-		m.menu = NewMenuModel(newTheme)
+		m.menu = newMenu(newTheme, m.opts)
 		m.skills = NewSkillsModel(newTheme)
 		m.experience = NewExperienceModel(newTheme)
-		m.contact = NewContactModel(newTheme)
+		m.contact = newContact(newTheme, m.opts)
 		m.themePicker = NewThemePickerModel(newTheme)
 
+		// The new screens start without the presence counters or a size
+		m.menu, _ = m.menu.Update(m.presence)
+		m.resizeScreens()
+
 		m.screen = state.ScreenMenu
 		return m, nil
 	}
 
 	// Handle project detail opening
 	if pm, ok := msg.(openProjectMsg); ok {
-		m.projectDetail = ProjectDetailsModel(pm.repo, pm.md)
+		m.projectDetail = ProjectDetailsModel(pm.repo, pm.md, m.currentTheme(), m.opts.Graphics)
+		m.projectDetail, _ = m.projectDetail.Update(m.screenSize())
 		m.screen = state.ScreenProjectDetail
 		return m, m.projectDetail.Init()
 	}
 
+	// Handle repository file browser
+	if fb, ok := msg.(openFileBrowserMsg); ok {
+		m.fileBrowser = FileBrowserModel(fb.repo, m.currentTheme())
+		m.fileBrowser, _ = m.fileBrowser.Update(m.screenSize())
+		m.screen = state.ScreenRepoFiles
+		return m, m.fileBrowser.Init()
+	}
+
+	if _, ok := msg.(backToProjectDetailMsg); ok {
+		m.screen = state.ScreenProjectDetail
+		return m, nil
+	}
+
 	// Handle screen navigation
 	if screen, ok := msg.(state.Screen); ok {
 		m.screen = screen
@@ -159,39 +356,82 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch screen {
 		case state.ScreenProjects:
 			if m.projects == nil {
-				m.projects = ProjectsModel("Polqt")
+				m.projects = ProjectsModel(githubUsername, m.currentTheme())
 			}
 			return m, m.projects.Init()
 		case state.ScreenSkills:
 			if m.skills == nil {
-				m.skills = SkillsModel()
+				m.skills = NewSkillsModel(m.currentTheme())
 			}
 			return m, m.skills.Init()
 		case state.ScreenExperience:
 			if m.experience == nil {
-				m.experience = ExperienceModel()
+				m.experience = NewExperienceModel(m.currentTheme())
 			}
 			return m, m.experience.Init()
 		case state.ScreenContact:
 			if m.contact == nil {
-				m.contact = ContactModel()
+				m.contact = newContact(m.currentTheme(), m.opts)
 			}
 			return m, m.contact.Init()
 		case state.ScreenStats:
 			if m.stats == nil {
-				m.stats = StatsModel("Polqt")
+				m.stats = StatsModel(githubUsername, m.currentTheme(), m.opts.Graphics)
 			}
 			return m, m.stats.Init()
 		case state.ScreenTheme:
 			if m.themePicker == nil {
-				m.themePicker = ThemePickerModel()
+				m.themePicker = NewThemePickerModel(m.currentTheme())
 			}
 			return m, m.themePicker.Init()
 		case state.ScreenMatrix:
 			if m.matrix == nil {
-				m.matrix = MatrixModel()
+				m.matrix = MatrixModel(m.currentTheme())
 			}
 			return m, m.matrix.Init()
+		case state.ScreenAnalytics:
+			if !m.opts.Owner {
+				m.screen = state.ScreenMenu
+				return m, nil
+			}
+			// Rebuilt on every visit so the numbers are current
+			m.analytics = NewAnalyticsModel(m.opts.Analytics, m.currentTheme())
+			m.analytics, _ = m.analytics.Update(m.screenSize())
+			return m, m.analytics.Init()
+		case state.ScreenGuestbook:
+			if m.opts.Guestbook == nil {
+				m.screen = state.ScreenMenu
+				return m, nil
+			}
+			if m.guestbook == nil {
+				m.guestbook = NewGuestbookModel(m.opts.Guestbook, m.opts.Key, visitorIP(m.opts), m.opts.Owner, m.currentTheme())
+				m.guestbook, _ = m.guestbook.Update(m.screenSize())
+			}
+			return m, m.guestbook.Init()
+		case state.ScreenAdmin:
+			if !m.opts.Owner {
+				m.screen = state.ScreenMenu
+				return m, nil
+			}
+			if m.admin == nil {
+				var selfID string
+				if m.opts.Live != nil {
+					selfID = m.opts.Live.ID
+				}
+				m.admin = NewAdminModel(m.opts.Hub, m.opts.Guestbook, m.opts.Recorder, m.opts.Access, selfID, m.currentTheme())
+				m.admin, _ = m.admin.Update(m.screenSize())
+			}
+			return m, m.admin.Init()
+		case state.ScreenChat:
+			if m.opts.Hub == nil || m.opts.Live == nil {
+				m.screen = state.ScreenMenu
+				return m, nil
+			}
+			if m.chat == nil {
+				m.chat = NewChatModel(m.opts.Hub, m.opts.Live, m.currentTheme())
+				m.chat, _ = m.chat.Update(m.screenSize())
+			}
+			return m, m.chat.Init()
 		case state.ScreenMenu:
 			return m, nil
 		}
@@ -216,6 +456,10 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.projectDetail, cmd = m.projectDetail.Update(msg)
 		return m, cmd
 
+	case state.ScreenRepoFiles:
+		m.fileBrowser, cmd = m.fileBrowser.Update(msg)
+		return m, cmd
+
 	case state.ScreenSkills:
 		m.skills, cmd = m.skills.Update(msg)
 		return m, cmd
@@ -239,12 +483,108 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case state.ScreenMatrix:
 		m.matrix, cmd = m.matrix.Update(msg)
 		return m, cmd
+
+	case state.ScreenAnalytics:
+		m.analytics, cmd = m.analytics.Update(msg)
+		return m, cmd
+
+	case state.ScreenGuestbook:
+		m.guestbook, cmd = m.guestbook.Update(msg)
+		return m, cmd
+
+	case state.ScreenAdmin:
+		m.admin, cmd = m.admin.Update(msg)
+		return m, cmd
+
+	case state.ScreenChat:
+		m.chat, cmd = m.chat.Update(msg)
+		return m, cmd
 	}
 
 	return m, nil
 }
 
+// inputCapturer is implemented by screens with text fields, which need
+// every key including the global shortcuts.
+type inputCapturer interface {
+	CapturingInput() bool
+}
+
+func (m appModel) capturingInput() bool {
+	var current tea.Model
+	switch m.screen {
+	case state.ScreenGuestbook:
+		current = m.guestbook
+	case state.ScreenAdmin:
+		current = m.admin
+	case state.ScreenChat:
+		current = m.chat
+	case state.ScreenContact:
+		current = m.contact
+	}
+	c, ok := current.(inputCapturer)
+	return ok && c.CapturingInput()
+}
+
 func (m appModel) View() string {
+	defer services.ObserveRender(m.screen, time.Now())
+	view := m.screenView()
+
+	banner, status := m.banner(), m.statusBar()
+	if banner == "" && status == "" {
+		return view
+	}
+	// Screens that don't fill their height are padded, so the status bar
+	// stays at the bottom
+	view = lipgloss.PlaceVertical(m.screenSize().Height, lipgloss.Top, view)
+	if banner != "" {
+		view = banner + "\n" + view
+	}
+	if status != "" {
+		view += "\n" + status
+	}
+	return view
+}
+
+// banner shows the owner's current announcement, empty when there is none.
+func (m appModel) banner() string {
+	if m.announcement.Text == "" {
+		return ""
+	}
+	return m.currentTheme().NewStyle().
+		Foreground(m.currentTheme().Background).
+		Background(m.currentTheme().Accent).
+		Bold(true).
+		Width(m.width).
+		Align(lipgloss.Center).
+		Render("📣 " + m.announcement.Text)
+}
+
+// statusBar tells the visitor how many others are exploring, empty when
+// they're alone or outside SSH mode.
+func (m appModel) statusBar() string {
+	others := m.presence.Online - 1
+	if others <= 0 || m.screen == state.ScreenIntro {
+		return ""
+	}
+
+	text := "1 other online"
+	if others > 1 {
+		text = strconv.Itoa(others) + " others online"
+	}
+	if here := m.presence.Screens[m.screen] - 1; here > 0 {
+		text += " · " + strconv.Itoa(here) + " here with you"
+	}
+	return m.currentTheme().NewStyle().
+		Foreground(m.currentTheme().Secondary).
+		Faint(true).
+		Width(m.width).
+		Align(lipgloss.Right).
+		PaddingRight(1).
+		Render("● " + text)
+}
+
+func (m appModel) screenView() string {
 	switch m.screen {
 	case state.ScreenIntro:
 		return m.intro.View()
@@ -254,6 +594,8 @@ func (m appModel) View() string {
 		return m.projects.View()
 	case state.ScreenProjectDetail:
 		return m.projectDetail.View()
+	case state.ScreenRepoFiles:
+		return m.fileBrowser.View()
 	case state.ScreenSkills:
 		return m.skills.View()
 	case state.ScreenExperience:
@@ -266,6 +608,14 @@ func (m appModel) View() string {
 		return m.stats.View()
 	case state.ScreenMatrix:
 		return m.matrix.View()
+	case state.ScreenAnalytics:
+		return m.analytics.View()
+	case state.ScreenGuestbook:
+		return m.guestbook.View()
+	case state.ScreenAdmin:
+		return m.admin.View()
+	case state.ScreenChat:
+		return m.chat.View()
 	default:
 		return "Unknown Screen"
 	}
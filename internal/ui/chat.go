@@ -0,0 +1,364 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/state"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const chatHelp = "/nick NAME · /who · /help"
+
+const chatOwnerHelp = chatHelp + " · /kick NICK · /mute NICK [10m] · /unmute NICK"
+
+type chatModel struct {
+	hub  *services.Hub
+	live *services.LiveSession
+
+	lines     []services.ChatMessage
+	input     textinput.Model
+	scroll    int
+	status    string
+	statusErr bool
+
+	theme  styles.Theme
+	keymap components.Keymap
+	width  int
+	height int
+}
+
+func NewChatModel(hub *services.Hub, live *services.LiveSession, theme styles.Theme) *chatModel {
+	input := textinput.New()
+	input.CharLimit = services.MaxChatMessage
+	input.Width = 60
+
+	m := &chatModel{
+		hub:    hub,
+		live:   live,
+		lines:  hub.ChatHistory(),
+		input:  input,
+		theme:  theme,
+		keymap: components.DefaultKeymap(),
+	}
+	if !m.joined() {
+		// Suggest a name derived from the visitor's key
+		seed := live.Fingerprint
+		if seed == "" {
+			seed = live.ID
+		}
+		m.input.SetValue(services.DefaultNick(seed))
+		m.input.CursorEnd()
+	}
+	m.resetPlaceholder()
+	return m
+}
+
+func (m *chatModel) joined() bool {
+	return m.live.Nick() != ""
+}
+
+func (m *chatModel) resetPlaceholder() {
+	if m.joined() {
+		m.input.Placeholder = "Say something, or /help"
+	} else {
+		m.input.Placeholder = "Pick a nickname"
+	}
+}
+
+// CapturingInput keeps global shortcuts out of the way while typing.
+func (m *chatModel) CapturingInput() bool {
+	return m.input.Focused()
+}
+
+func (m *chatModel) setStatus(text string, isErr bool) {
+	m.status = text
+	m.statusErr = isErr
+}
+
+func (m *chatModel) Init() tea.Cmd {
+	return m.input.Focus()
+}
+
+func (m *chatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.input.Width = min(80, max(20, msg.Width-20))
+		return m, nil
+
+	case services.ChatMsg:
+		m.lines = append(m.lines, services.ChatMessage(msg))
+		if len(m.lines) > services.ChatHistorySize {
+			m.lines = m.lines[len(m.lines)-services.ChatHistorySize:]
+		}
+		// Keep the view still for someone reading back
+		if m.scroll > 0 {
+			m.scroll++
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "pgup":
+			m.scroll = min(m.scroll+m.logHeight()/2, max(0, len(m.renderLog())-m.logHeight()))
+			return m, nil
+		case "pgdown":
+			m.scroll = max(0, m.scroll-m.logHeight()/2)
+			return m, nil
+		}
+
+		if !m.input.Focused() {
+			switch msg.String() {
+			case m.keymap.Quit:
+				return m, tea.Quit
+			case m.keymap.Back, "esc":
+				return m, func() tea.Msg { return state.ScreenMenu }
+			case "i", "enter":
+				return m, m.input.Focus()
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			if !m.joined() {
+				return m, func() tea.Msg { return state.ScreenMenu }
+			}
+			m.input.Blur()
+			return m, nil
+		case "enter":
+			m.submit(m.input.Value())
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *chatModel) submit(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+
+	if !m.joined() {
+		if err := m.hub.JoinChat(m.live, text); err != nil {
+			m.setStatus(sentence(err.Error()), true)
+			return
+		}
+		m.setStatus("Welcome, "+text+"! Type /help for commands.", false)
+		m.input.Reset()
+		m.resetPlaceholder()
+		return
+	}
+
+	var err error
+	if strings.HasPrefix(text, "/") {
+		err = m.command(strings.Fields(text))
+	} else {
+		err = m.hub.Say(m.live, text)
+		if err == nil {
+			m.status = ""
+			m.scroll = 0
+		}
+	}
+	if err != nil {
+		m.setStatus(sentence(err.Error()), true)
+		return
+	}
+	m.input.Reset()
+}
+
+// command runs a slash command typed into the chat.
+func (m *chatModel) command(args []string) error {
+	switch args[0] {
+	case "/help":
+		help := chatHelp
+		if m.live.Owner {
+			help = chatOwnerHelp
+		}
+		m.setStatus(help, false)
+		return nil
+	case "/who":
+		m.setStatus("In the tavern: "+strings.Join(m.hub.ChatNicks(), ", "), false)
+		return nil
+	case "/nick":
+		if len(args) != 2 {
+			return errors.New("usage: /nick NAME")
+		}
+		if err := m.hub.JoinChat(m.live, args[1]); err != nil {
+			return err
+		}
+		m.setStatus("You are now "+args[1]+".", false)
+		return nil
+	case "/kick":
+		if len(args) != 2 {
+			return errors.New("usage: /kick NICK")
+		}
+		return m.hub.Kick(m.live, args[1])
+	case "/mute":
+		if len(args) < 2 || len(args) > 3 {
+			return errors.New("usage: /mute NICK [duration]")
+		}
+		d := services.DefaultMute
+		if len(args) == 3 {
+			var err error
+			if d, err = time.ParseDuration(args[2]); err != nil || d <= 0 {
+				return errors.New("durations look like 30s, 10m or 1h")
+			}
+		}
+		return m.hub.Mute(m.live, args[1], d)
+	case "/unmute":
+		if len(args) != 2 {
+			return errors.New("usage: /unmute NICK")
+		}
+		return m.hub.Mute(m.live, args[1], 0)
+	}
+	return fmt.Errorf("unknown command %s, try /help", args[0])
+}
+
+// sentence capitalizes an error message for display.
+func sentence(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// logHeight is how many chat lines fit between the header and the input.
+func (m *chatModel) logHeight() int {
+	return max(3, m.height-14)
+}
+
+// renderLog renders the history as wrapped terminal lines, oldest first.
+func (m *chatModel) renderLog() []string {
+	timeStyle := m.theme.NewStyle().Foreground(m.theme.Secondary).Faint(true)
+	nickStyle := m.theme.NewStyle().Foreground(m.theme.Primary).Bold(true)
+	ownerStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	systemStyle := m.theme.NewStyle().Foreground(m.theme.Secondary).Italic(true)
+	textStyle := m.theme.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+
+	width := min(m.width-8, 100)
+	var out []string
+	for _, c := range m.lines {
+		prefix := timeStyle.Render(c.Time.Format("15:04") + " ")
+		var line string
+		switch {
+		case c.System:
+			line = prefix + systemStyle.Render("— "+c.Text)
+		case c.Owner:
+			// The owner stands out so visitors know who is answering
+			line = prefix + ownerStyle.Render("★ "+c.Nick+": ") + ownerStyle.UnsetBold().Render(c.Text)
+		default:
+			line = prefix + nickStyle.Render(c.Nick+": ") + textStyle.Render(c.Text)
+		}
+		wrapped := m.theme.NewStyle().Width(width).Render(line)
+		out = append(out, strings.Split(wrapped, "\n")...)
+	}
+	return out
+}
+
+func (m *chatModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var sections []string
+	sections = append(sections, components.HeaderBox("WARRIORS' TAVERN", m.theme, m.width-4))
+
+	subtitle := "Pick a name to join the conversation"
+	if m.joined() {
+		n := len(m.hub.ChatNicks())
+		subtitle = fmt.Sprintf("Chatting as %s · %d in the tavern", m.live.Nick(), n)
+	}
+	sections = append(sections, m.theme.NewStyle().
+		Foreground(m.theme.Secondary).
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width).
+		Render(subtitle))
+	sections = append(sections, components.DividerLine(m.theme, m.width-4, "─"))
+
+	// Newest lines at the bottom, padded so the input stays in place
+	log := m.renderLog()
+	end := len(log) - m.scroll
+	visible := log[max(0, end-m.logHeight()):end]
+	if len(visible) == 0 {
+		visible = []string{m.theme.NewStyle().
+			Foreground(m.theme.Secondary).
+			Italic(true).
+			Render("It's quiet in here. Say hello!")}
+	}
+	for len(visible) < m.logHeight() {
+		visible = append([]string{""}, visible...)
+	}
+	logBox := m.theme.NewStyle().Width(min(m.width-8, 100)).Render(strings.Join(visible, "\n"))
+	sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, logBox))
+
+	border := m.theme.Secondary
+	if m.input.Focused() {
+		border = m.theme.Accent
+	}
+	inputBox := m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(border).
+		Padding(0, 1).
+		Width(min(m.width-8, 100)).
+		Render(m.input.View())
+	sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, inputBox))
+
+	var color lipgloss.TerminalColor = m.theme.Secondary
+	if m.statusErr {
+		color = m.theme.Error
+	}
+	sections = append(sections, m.theme.NewStyle().
+		Foreground(color).
+		Align(lipgloss.Center).
+		Width(m.width).
+		Render(m.status))
+
+	sections = append(sections, components.RenderKeyBindings(m.keyBindings(), m.theme, m.width))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+func (m *chatModel) keyBindings() []components.KeyBind {
+	if m.input.Focused() {
+		desc := "Send"
+		if !m.joined() {
+			desc = "Join"
+		}
+		return []components.KeyBind{
+			{Key: "Enter", Desc: desc},
+			{Key: "PgUp/PgDn", Desc: "Scroll"},
+			{Key: "Esc", Desc: "Stop Typing"},
+		}
+	}
+	return []components.KeyBind{
+		{Key: "i/Enter", Desc: "Type"},
+		{Key: "PgUp/PgDn", Desc: "Scroll"},
+		{Key: "b/Esc", Desc: "Retreat"},
+	}
+}
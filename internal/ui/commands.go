@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"clifolio/internal/services"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// Sections are the portfolio sections that can be printed without the TUI,
+// as in `ssh host skills --json`.
+var Sections = []string{"about", "projects", "skills", "experience", "contact"}
+
+var errUnknownSection = errors.New("unknown section")
+
+type CommandOptions struct {
+	// Renderer styles the output. A renderer with the Ascii profile, which
+	// is what sessions without a PTY get, prints plain text.
+	Renderer *lipgloss.Renderer
+	// Width is where text wraps, usually the client's terminal width.
+	Width int
+	// Host is where the page came from, for RenderPage's hints. It's
+	// unused elsewhere.
+	Host string
+}
+
+// RunCommand prints the section named in args to out and returns the exit
+// status for the session.
+func RunCommand(ctx context.Context, args []string, out, errOut io.Writer, opts CommandOptions) int {
+	fs := flag.NewFlagSet("clifolio", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.Usage = func() { printUsage(errOut, fs) }
+	asJSON := fs.Bool("json", false, "print JSON")
+	plain := fs.Bool("plain", false, "print without colors")
+	color := fs.Bool("color", false, "print colors even without a terminal")
+	width := fs.Int("width", opts.Width, "wrap text at this many columns")
+
+	// Flags may come before or after the section name
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if name == "" {
+		name = fs.Arg(0)
+	}
+	if name == "" || name == "help" {
+		printUsage(out, fs)
+		return 0
+	}
+
+	data, err := sectionData(ctx, name)
+	if errors.Is(err, errUnknownSection) {
+		fmt.Fprintf(errOut, "Unknown section %q.\n\n", name)
+		printUsage(errOut, fs)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "Error: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return 1
+		}
+		return 0
+	}
+
+	r := opts.Renderer
+	if r == nil {
+		r = lipgloss.NewRenderer(out)
+	}
+	switch {
+	case *plain:
+		r.SetColorProfile(termenv.Ascii)
+	case *color && r.ColorProfile() == termenv.Ascii:
+		r.SetColorProfile(termenv.ANSI256)
+	}
+
+	w := *width
+	if w <= 0 {
+		w = 80
+	}
+	fmt.Fprintln(out, RenderSection(name, data, styles.NewTheme("default", r), min(w, 100)))
+	return 0
+}
+
+// RenderPage renders a section for `curl host` and friends: the projects,
+// skills and experience screens as the TUI draws them, the other sections the
+// way RunCommand prints them, and for "" the intro banner and a short
+// summary.
+func RenderPage(ctx context.Context, name string, opts CommandOptions) (string, error) {
+	r := opts.Renderer
+	if r == nil {
+		r = lipgloss.NewRenderer(io.Discard)
+	}
+	theme := styles.NewTheme("default", r)
+	width := opts.Width
+	if width <= 0 {
+		width = 80
+	}
+
+	// The screens' own views, so the page looks like the TUI
+	switch name {
+	case "":
+		return renderSummary(theme, width, opts.Host), nil
+	case "projects":
+		repos, err := services.FetchRepos(ctx, githubUsername)
+		if err != nil {
+			return "", err
+		}
+		m := ProjectsModel(githubUsername, theme)
+		m.projects, m.loading, m.width = repos, false, width
+		return m.renderPage(), nil
+	case "skills":
+		m := NewSkillsModel(theme)
+		m.width = width
+		return m.renderPage(), nil
+	case "experience":
+		m := NewExperienceModel(theme)
+		m.width = width
+		return m.renderPage(), nil
+	}
+	data, err := sectionData(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return RenderSection(name, data, theme, min(width, 100)), nil
+}
+
+// renderSummary is the intro screen's banner, without the prompt to press
+// a key, followed by the highlights and where to read more.
+func renderSummary(theme styles.Theme, width int, host string) string {
+	intro := IntroModel(theme)
+	text, _, _ := strings.Cut(intro.fullText, "Press Any Key")
+	intro.lines = strings.Split(strings.TrimRight(text, "\n "), "\n")
+	for i, line := range intro.lines {
+		// The box pads it already
+		intro.lines[i] = strings.TrimPrefix(line, "    ")
+	}
+	intro.maxLines = len(intro.lines)
+	intro.width = width
+
+	heading := theme.NewStyle().Foreground(theme.Accent).Bold(true)
+	label := theme.NewStyle().Foreground(theme.Secondary).Width(12)
+	value := theme.NewStyle().Foreground(theme.Primary)
+
+	p := services.GetProfileData()
+	var strongest []string
+	for _, s := range portfolioSkills() {
+		if s.Level >= 4 {
+			strongest = append(strongest, s.Name)
+		}
+	}
+	var work string
+	for _, e := range portfolioExperiences() {
+		if e.Type == "work" {
+			work = e.Title + " at " + e.Organization
+			break
+		}
+	}
+
+	lines := []string{
+		components.HeaderBox("WARRIOR AWAKENS", theme, width-2),
+		intro.renderIntroText(),
+		"",
+		"  " + heading.Render(p.Name) + value.Render(" · "+p.Title),
+		"",
+	}
+	for _, f := range [][2]string{
+		{"Location", p.Location},
+		{"Strongest", strings.Join(strongest, ", ")},
+		{"Work", work},
+		{"Email", p.Email},
+		{"GitHub", p.GitHub},
+		{"Website", p.Website},
+	} {
+		lines = append(lines, "  "+label.Render(f[0])+value.Render(ansi.Truncate(f[1], width-16, "…")))
+	}
+
+	if host != "" {
+		lines = append(lines, "")
+		for _, name := range Sections {
+			lines = append(lines, "  "+label.Render(name)+value.Render("curl "+host+"/"+name))
+		}
+		lines = append(lines, "  "+label.Render("")+theme.NewStyle().Foreground(theme.Secondary).Render("?width=N or a COLUMNS header sets the width"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: ssh <host> <section> [flags]\n\nSections: %s\n\nFlags:\n", strings.Join(Sections, ", "))
+	out := fs.Output()
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(out)
+}
+
+// sectionData returns what a section shows, in the shape it is printed as
+// JSON.
+func sectionData(ctx context.Context, name string) (any, error) {
+	switch name {
+	case "about":
+		return services.GetProfileData(), nil
+	case "projects":
+		return services.FetchRepos(ctx, githubUsername)
+	case "skills":
+		return portfolioSkills(), nil
+	case "experience":
+		return portfolioExperiences(), nil
+	case "contact":
+		return portfolioContacts(), nil
+	}
+	return nil, errUnknownSection
+}
+
+// APIs are what the web server's JSON API serves at /api/<name>.
+var APIs = []string{"profile", "projects", "skills", "experience", "stats"}
+
+// APIData returns what the API serves for name: a section as
+// `ssh host <section> --json` prints it, or the GitHub stats.
+func APIData(ctx context.Context, name string) (any, error) {
+	switch name {
+	case "profile":
+		return sectionData(ctx, "about")
+	case "stats":
+		return services.FetchGitHubStats(ctx, githubUsername)
+	}
+	return sectionData(ctx, name)
+}
+
+// RenderSection formats a section's data as text for the terminal, styled
+// with theme. The result has no trailing newline.
+func RenderSection(name string, data any, theme styles.Theme, width int) string {
+	heading := theme.NewStyle().Foreground(theme.Accent).Bold(true)
+	label := theme.NewStyle().Foreground(theme.Secondary)
+	text := theme.NewStyle().Foreground(theme.Primary)
+
+	var lines []string
+	switch name {
+	case "about":
+		p := data.(services.ProfileData)
+		lines = append(lines,
+			heading.Render(p.Name),
+			text.Render(p.Title),
+			"",
+			wrapLines(text, p.Bio, "", width),
+			"",
+		)
+		for _, f := range [][2]string{
+			{"Location", p.Location},
+			{"Website", p.Website},
+			{"Email", p.Email},
+			{"GitHub", p.GitHub},
+			{"LinkedIn", p.LinkedIn},
+		} {
+			lines = append(lines, label.Width(10).Render(f[0])+text.Render(f[1]))
+		}
+
+	case "projects":
+		for i, r := range data.([]services.Repo) {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			title := heading.Render(r.Name) + label.Render(fmt.Sprintf("  ★ %d", r.Stars))
+			if r.Language != "" {
+				title += label.Render("  " + r.Language)
+			}
+			lines = append(lines, title)
+			if r.Description != "" {
+				lines = append(lines, wrapLines(text, r.Description, "  ", width))
+			}
+			lines = append(lines, label.Render("  "+r.HTMLURL))
+		}
+
+	case "skills":
+		skills := data.([]Skill)
+		for i, c := range skillCategories() {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, heading.Render(c.DisplayName)+label.Render(" · "+c.Description))
+			for _, s := range skills {
+				if s.Category != c.ID {
+					continue
+				}
+				level := strings.Repeat("●", s.Level) + strings.Repeat("○", max(0, 5-s.Level))
+				lines = append(lines, "  "+
+					text.Width(16).Render(s.Name)+
+					theme.NewStyle().Foreground(theme.Accent).Render(level)+
+					label.Render(fmt.Sprintf("  %d yrs  %d projects", s.Years, s.Projects)))
+			}
+		}
+
+	case "experience":
+		for i, e := range data.([]Experience) {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines,
+				heading.Render(e.Title),
+				label.Render(fmt.Sprintf("%s · %s · %s – %s", e.Organization, e.Location, e.StartDate, e.EndDate)),
+			)
+			for _, d := range e.Description {
+				lines = append(lines, "  "+text.Render("•")+" "+strings.TrimLeft(wrapLines(text, d, "    ", width), " "))
+			}
+			if len(e.Skills) > 0 {
+				lines = append(lines, label.Render("  Skills: ")+text.Render(strings.Join(e.Skills, ", ")))
+			}
+		}
+
+	case "contact":
+		for _, c := range data.([]ContactInfo) {
+			lines = append(lines, label.Width(12).Render(c.Label)+text.Render(c.Value))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// wrapLines word-wraps s to width with every line indented by indent. Lines
+// are styled one at a time so they don't get padded to the widest, which
+// would leave trailing spaces in piped output.
+func wrapLines(style lipgloss.Style, s, indent string, width int) string {
+	wrapped := strings.Split(ansi.Wordwrap(s, width-len(indent), ""), "\n")
+	for i, line := range wrapped {
+		wrapped[i] = indent + style.Render(line)
+	}
+	return strings.Join(wrapped, "\n")
+}
@@ -19,21 +19,21 @@ const (
 )
 
 func StandardBorder(theme styles.Theme) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return theme.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(theme.Primary).
 		Padding(1, 2)
 }
 
 func AccentBorder(theme styles.Theme) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return theme.NewStyle().
 		Border(lipgloss.DoubleBorder()).
 		BorderForeground(theme.Accent).
 		Padding(1, 2)
 }
 
 func TitleBox(theme styles.Theme) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return theme.NewStyle().
 		Border(lipgloss.DoubleBorder()).
 		BorderForeground(theme.Primary).
 		Padding(0, 2).
@@ -41,14 +41,14 @@ func TitleBox(theme styles.Theme) lipgloss.Style {
 }
 
 func SubtleBorder(theme styles.Theme) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return theme.NewStyle().
 		Border(lipgloss.NormalBorder()).
 		BorderForeground(theme.Secondary).
 		Padding(1, 2)
 }
 
 func GlowBorder(theme styles.Theme) lipgloss.Style {
-	return lipgloss.NewStyle().
+	return theme.NewStyle().
 		Border(lipgloss.ThickBorder()).
 		BorderForeground(theme.Accent).
 		Padding(1, 3).
@@ -57,7 +57,7 @@ func GlowBorder(theme styles.Theme) lipgloss.Style {
 
 func SectionBox(title, content string, theme styles.Theme, width int) string {
 	// Scroll-like box with decorative borders
-	boxStyle := lipgloss.NewStyle().
+	boxStyle := theme.NewStyle().
 		Border(lipgloss.Border{
 			Top:         "═",
 			Bottom:      "═",
@@ -76,7 +76,7 @@ func SectionBox(title, content string, theme styles.Theme, width int) string {
 }
 
 func CardBox(content string, theme styles.Theme, selected bool) string {
-	style := lipgloss.NewStyle().
+	style := theme.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		Padding(1, 2).
 		MarginBottom(1)
@@ -94,13 +94,13 @@ func CardBox(content string, theme styles.Theme, selected bool) string {
 }
 
 func InfoPanel(label, value string, theme styles.Theme) string {
-	labelStyle := lipgloss.NewStyle().
+	labelStyle := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Bold(true).
 		Width(15).
 		Align(lipgloss.Right)
 
-	valueStyle := lipgloss.NewStyle().
+	valueStyle := theme.NewStyle().
 		Foreground(theme.Primary)
 
 	return lipgloss.JoinHorizontal(
@@ -112,10 +112,10 @@ func InfoPanel(label, value string, theme styles.Theme) string {
 }
 
 func HeaderBox(title string, theme styles.Theme, width int) string {
-	borderStyle := lipgloss.NewStyle().
+	borderStyle := theme.NewStyle().
 		Foreground(theme.Primary)
 
-	titleStyle := lipgloss.NewStyle().
+	titleStyle := theme.NewStyle().
 		Foreground(theme.Accent).
 		Bold(true)
 
@@ -165,7 +165,7 @@ func HeaderBox(title string, theme styles.Theme, width int) string {
 }
 
 func FooterBox(content string, theme styles.Theme, width int) string {
-	style := lipgloss.NewStyle().
+	style := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Background(theme.Background).
 		Padding(0, 2).
@@ -180,7 +180,7 @@ func DividerLine(theme styles.Theme, width int, char string) string {
 		char = "─"
 	}
 
-	style := lipgloss.NewStyle().
+	style := theme.NewStyle().
 		Foreground(theme.Secondary)
 
 	swordDecor := " ⚔️   "
@@ -219,19 +219,19 @@ func WarriorStatusBar(label string, current, max int, theme styles.Theme, width
 	filledBar := strings.Repeat("█", filled)
 	emptyBar := strings.Repeat("░", barWidth-filled)
 
-	barStyle := lipgloss.NewStyle().Foreground(theme.Accent)
-	emptyStyle := lipgloss.NewStyle().Foreground(theme.Secondary)
-	labelStyle := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true)
-	statsStyle := lipgloss.NewStyle().Foreground(theme.Secondary)
+	barStyle := theme.NewStyle().Foreground(theme.Accent)
+	emptyStyle := theme.NewStyle().Foreground(theme.Secondary)
+	labelStyle := theme.NewStyle().Foreground(theme.Primary).Bold(true)
+	statsStyle := theme.NewStyle().Foreground(theme.Secondary)
 
 	return labelStyle.Render(label) + " [" +
 		barStyle.Render(filledBar) + emptyStyle.Render(emptyBar) +
-		"] " + statsStyle.Render(lipgloss.NewStyle().Render(string(rune(current)))+"/"+string(rune(max)))
+		"] " + statsStyle.Render(theme.NewStyle().Render(string(rune(current)))+"/"+string(rune(max)))
 }
 
 // WarriorBox creates a warrior-themed decorated box
 func WarriorBox(content string, theme styles.Theme, width int) string {
-	borderStyle := lipgloss.NewStyle().
+	borderStyle := theme.NewStyle().
 		Border(lipgloss.Border{
 			Top:         "═",
 			Bottom:      "═",
@@ -250,6 +250,6 @@ func WarriorBox(content string, theme styles.Theme, width int) string {
 }
 
 func PixelDecoration(theme styles.Theme) string {
-	decorStyle := lipgloss.NewStyle().Foreground(theme.Primary)
+	decorStyle := theme.NewStyle().Foreground(theme.Primary)
 	return decorStyle.Render("▓▒░")
 }
@@ -14,13 +14,13 @@ type KeyBind struct {
 }
 
 func RenderKeyBindings(bindings []KeyBind, theme styles.Theme, width int) string {
-	keyStyle := lipgloss.NewStyle().
+	keyStyle := theme.NewStyle().
 		Foreground(theme.Accent).
 		Bold(true).
 		Background(lipgloss.Color("#1a1a1a")).
 		Padding(0, 1)
 
-	descStyle := lipgloss.NewStyle().
+	descStyle := theme.NewStyle().
 		Foreground(theme.Secondary)
 
 	var hints []string
@@ -32,7 +32,7 @@ func RenderKeyBindings(bindings []KeyBind, theme styles.Theme, width int) string
 	content := strings.Join(hints, "  •  ")
 
 	// Add warrior-themed decorative border
-	borderStyle := lipgloss.NewStyle().
+	borderStyle := theme.NewStyle().
 		Foreground(theme.Primary)
 
 	commandLabel := " ⚔️  COMMANDS ⚔️  "
@@ -49,7 +49,7 @@ func RenderKeyBindings(bindings []KeyBind, theme styles.Theme, width int) string
 	footerContent := lipgloss.JoinVertical(
 		lipgloss.Left,
 		borderStyle.Render(topLine),
-		lipgloss.NewStyle().
+		theme.NewStyle().
 			Width(width).
 			Align(lipgloss.Center).
 			Foreground(theme.Secondary).
@@ -63,18 +63,18 @@ func RenderKeyBindings(bindings []KeyBind, theme styles.Theme, width int) string
 func RenderHelpMenu(sections map[string][]KeyBind, theme styles.Theme) string {
 	var output strings.Builder
 
-	sectionStyle := lipgloss.NewStyle().
+	sectionStyle := theme.NewStyle().
 		Foreground(theme.Primary).
 		Bold(true).
 		MarginTop(1).
 		MarginBottom(1)
 
-	keyStyle := lipgloss.NewStyle().
+	keyStyle := theme.NewStyle().
 		Foreground(theme.Accent).
 		Width(15).
 		Bold(true)
 
-	descStyle := lipgloss.NewStyle().
+	descStyle := theme.NewStyle().
 		Foreground(theme.Secondary)
 
 	for section, bindings := range sections {
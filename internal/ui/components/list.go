@@ -25,34 +25,34 @@ type ListStyle struct {
 
 func RenderList(items []ListItem, cursor int, theme styles.Theme, style ListStyle) string {
 	if len(items) == 0 {
-		return lipgloss.NewStyle().
+		return theme.NewStyle().
 			Foreground(theme.Secondary).
 			Italic(true).
 			Render("No items to display")
 	}
 
-	selectedStyle := lipgloss.NewStyle().
+	selectedStyle := theme.NewStyle().
 		Foreground(theme.Accent).
 		Bold(true)
 	
-	normalStyle := lipgloss.NewStyle().
+	normalStyle := theme.NewStyle().
 		Foreground(lipgloss.Color("#ffffff"))
 
-	descStyle := lipgloss.NewStyle().
+	descStyle := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Italic(true)
 
-	badgeStyle := lipgloss.NewStyle().
+	badgeStyle := theme.NewStyle().
 		Foreground(theme.Accent).
 		Background(lipgloss.Color("#1a1a1a")).
 		Padding(0, 1).
 		Bold(true)
 	
-	metaStyle := lipgloss.NewStyle().
+	metaStyle := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Faint(true)
 		
-	cursorIcon := lipgloss.NewStyle().
+	cursorIcon := theme.NewStyle().
         Foreground(theme.Accent).
         Bold(true).
         Render("▸")
@@ -123,7 +123,7 @@ func RenderCardList(items []ListItem, cursor int, theme styles.Theme, width int)
 
 		var content strings.Builder
 
-		titleStyle := lipgloss.NewStyle().
+		titleStyle := theme.NewStyle().
 			Foreground(theme.Primary).
 			Bold(true)
 
@@ -133,7 +133,7 @@ func RenderCardList(items []ListItem, cursor int, theme styles.Theme, width int)
 		content.WriteString(titleStyle.Render(item.Title) + "\n\n")
 
 		if item.Content != "" {
-			descStyle := lipgloss.NewStyle().
+			descStyle := theme.NewStyle().
 				Foreground(theme.Secondary).
 				Width(width - 8)
 			content.WriteString(descStyle.Render(item.Content) + "\n")
@@ -142,14 +142,14 @@ func RenderCardList(items []ListItem, cursor int, theme styles.Theme, width int)
 		if item.Badge != "" || item.Meta != "" {
 			content.WriteString("\n")
 			if item.Badge != "" {
-				badgeStyle := lipgloss.NewStyle().
+				badgeStyle := theme.NewStyle().
 					Foreground(theme.Accent).
 					Background(lipgloss.Color("#1a1a1a")).
 					Padding(0, 1)
 				content.WriteString(badgeStyle.Render(item.Badge) + " ")
 			}
 			if item.Meta != "" {
-				metaStyle := lipgloss.NewStyle().
+				metaStyle := theme.NewStyle().
 					Foreground(theme.Secondary).
 					Faint(true)
 				content.WriteString(metaStyle.Render(item.Meta))
@@ -172,19 +172,19 @@ func RenderGridList(items []ListItem, cursor int, theme styles.Theme, width int)
 	for i, item := range items {
 		isSelected := i == cursor
 
-		titleStyle := lipgloss.NewStyle().
+		titleStyle := theme.NewStyle().
 			Foreground(theme.Primary).
 			Bold(true)
 		
 		content := titleStyle.Render(item.Icon + " " + item.Title) + "\n"
 		if item.Content != "" {
-			descStyle := lipgloss.NewStyle().
+			descStyle := theme.NewStyle().
 				Foreground(theme.Secondary).
 				Width(cardWidth - 4)
 			content += descStyle.Render(item.Content)
 		}
 
-		cardStyle := lipgloss.NewStyle().
+		cardStyle := theme.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			Padding(1).
 			Width(cardWidth)
@@ -214,19 +214,19 @@ func RenderGridList(items []ListItem, cursor int, theme styles.Theme, width int)
 }
 
 func RenderTableList(headers []string, rows [][]string, cursor int, theme styles.Theme) string {
-    headerStyle := lipgloss.NewStyle().
+    headerStyle := theme.NewStyle().
         Foreground(theme.Primary).
         Bold(true).
         BorderBottom(true).
         BorderStyle(lipgloss.NormalBorder()).
         BorderForeground(theme.Secondary)
 
-    selectedRowStyle := lipgloss.NewStyle().
+    selectedRowStyle := theme.NewStyle().
         Foreground(theme.Accent).
         Bold(true).
         Background(lipgloss.Color("#1a1a1a"))
 
-    normalRowStyle := lipgloss.NewStyle().
+    normalRowStyle := theme.NewStyle().
         Foreground(lipgloss.Color("#ffffff"))
 
     // Calculate column widths
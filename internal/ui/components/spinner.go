@@ -1,6 +1,8 @@
 package components
 
 import (
+	"clifolio/internal/styles"
+
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -10,10 +12,10 @@ type SpinnerComponent struct {
 	Model spinner.Model
 }
 
-func NewSpinner() SpinnerComponent {
+func NewSpinner(theme styles.Theme) SpinnerComponent {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = theme.NewStyle().Foreground(lipgloss.Color("205"))
 	return SpinnerComponent{Model: s}
 }
 
@@ -1,21 +1,27 @@
 package ui
 
 import (
+	"clifolio/internal/services"
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 type ContactInfo struct {
-	Label string
-	Value string
-	Icon  string
-	Link  string
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Icon  string `json:"-"`
+	Link  string `json:"link,omitempty"`
 }
 
 type contactModel struct {
@@ -27,15 +33,33 @@ type contactModel struct {
 	keymap    components.Keymap
 	copiedMsg string
 	showQR    bool
+	qrVCard   bool
+	clip      services.Clipboard
+
+	// The message form, available when there's an inbox to deliver to
+	inbox     *services.Inbox
+	sender    services.ContactSender
+	form      bool
+	name      textinput.Model
+	replyTo   textinput.Model
+	body      textarea.Model
+	focus     int
+	sending   bool
+	formError string
+}
+
+type contactSentMsg struct {
+	err error
 }
 
 func ContactModel() tea.Model {
 	theme := styles.NewThemeFromName("default")
-	return NewContactModel(theme)
+	return NewContactModel(theme, services.LocalClipboard(), nil, services.ContactSender{})
 }
 
-func NewContactModel(theme styles.Theme) *contactModel {
-	contacts := []ContactInfo{
+// portfolioContacts is shared by the contact screen and command.
+func portfolioContacts() []ContactInfo {
+	return []ContactInfo{
 		{
 			Label: "LinkedIn",
 			Value: "https://www.linkedin.com/in/janpol-hidalgo",
@@ -57,12 +81,115 @@ func NewContactModel(theme styles.Theme) *contactModel {
 			Icon:  "🌐",
 		},
 	}
+}
+
+// NewContactModel builds the contact screen. Values are copied with clip,
+// or shown for copying by hand when it's nil. With an inbox it also offers a
+// form for sending the owner a message, throttled per sender.
+func NewContactModel(theme styles.Theme, clip services.Clipboard, inbox *services.Inbox, sender services.ContactSender) *contactModel {
+	contacts := portfolioContacts()
+
+	name := textinput.New()
+	name.Placeholder = "Your name"
+	name.CharLimit = services.MaxContactName
+	name.Width = 40
+
+	replyTo := textinput.New()
+	replyTo.Placeholder = "you@example.com"
+	replyTo.CharLimit = 254
+	replyTo.Width = 40
+
+	body := textarea.New()
+	body.Placeholder = "What quest do you have in mind?"
+	body.CharLimit = services.MaxContactMessage
+	body.ShowLineNumbers = false
+	body.SetHeight(6)
 
 	return &contactModel{
 		contacts: contacts,
 		theme:    theme,
 		keymap:   components.DefaultKeymap(),
+		clip:     clip,
+		inbox:    inbox,
+		sender:   sender,
+		name:     name,
+		replyTo:  replyTo,
+		body:     body,
+	}
+}
+
+// CapturingInput keeps global shortcuts out of the way while typing.
+func (m *contactModel) CapturingInput() bool {
+	return m.form
+}
+
+func sendContactCmd(inbox *services.Inbox, sender services.ContactSender, name, replyTo, body string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return contactSentMsg{err: inbox.Submit(ctx, sender, name, replyTo, body)}
+	}
+}
+
+// focusField moves the cursor to the form field at index i.
+func (m *contactModel) focusField(i int) tea.Cmd {
+	m.focus = (i + 3) % 3
+	m.name.Blur()
+	m.replyTo.Blur()
+	m.body.Blur()
+	switch m.focus {
+	case 0:
+		return m.name.Focus()
+	case 1:
+		return m.replyTo.Focus()
+	}
+	return m.body.Focus()
+}
+
+func (m *contactModel) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.sending {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.form = false
+		m.formError = ""
+		return m, nil
+	case "tab", "down":
+		if msg.String() == "tab" || m.focus < 2 {
+			return m, m.focusField(m.focus + 1)
+		}
+	case "shift+tab", "up":
+		if msg.String() == "shift+tab" || m.focus < 2 {
+			return m, m.focusField(m.focus - 1)
+		}
+	case "enter":
+		if m.focus < 2 {
+			return m, m.focusField(m.focus + 1)
+		}
+	case "ctrl+s":
+		if _, _, _, err := services.CleanContactMessage(m.name.Value(), m.replyTo.Value(), m.body.Value()); err != nil {
+			m.formError = "Sorry, " + err.Error() + "."
+			return m, nil
+		}
+		m.sending = true
+		m.formError = ""
+		return m, sendContactCmd(m.inbox, m.sender, m.name.Value(), m.replyTo.Value(), m.body.Value())
+	}
+
+	var cmd tea.Cmd
+	switch m.focus {
+	case 0:
+		m.name, cmd = m.name.Update(msg)
+	case 1:
+		m.replyTo, cmd = m.replyTo.Update(msg)
+	default:
+		m.body, cmd = m.body.Update(msg)
 	}
+	return m, cmd
 }
 
 func (m *contactModel) Init() tea.Cmd {
@@ -74,12 +201,35 @@ func (m *contactModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.body.SetWidth(min(70, max(20, msg.Width-24)))
+		return m, nil
+
+	case contactSentMsg:
+		m.sending = false
+		if msg.err != nil {
+			m.formError = "Sorry, " + msg.err.Error() + "."
+			return m, nil
+		}
+		m.form = false
+		m.formError = ""
+		m.body.Reset()
+		m.copiedMsg = "Message sent! The warrior will reply to " + m.replyTo.Value() + "."
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.form {
+			return m.updateForm(msg)
+		}
+
 		switch msg.String() {
 		case m.keymap.Quit, "ctrl+c":
 			return m, tea.Quit
+		case "m":
+			if m.inbox != nil {
+				m.form = true
+				m.copiedMsg = ""
+				return m, m.focusField(0)
+			}
 		case m.keymap.Up, "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
@@ -96,21 +246,45 @@ func (m *contactModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.copiedMsg = ""
 		case "c":
 			if m.cursor < len(m.contacts) {
-				contact := m.contacts[m.cursor]
-				err := clipboard.WriteAll(contact.Value)
-				if err == nil {
-					m.copiedMsg = fmt.Sprintf("Copied %s to clipboard!", contact.Label)
-				} else {
-					m.copiedMsg = "Failed to copy to clipboard."
-				}
+				m.copiedMsg = m.copyValue(m.contacts[m.cursor])
+			}
+		case "r":
+			m.showQR = !m.showQR || m.qrVCard
+			m.qrVCard = false
+			m.copiedMsg = ""
+		case "v":
+			m.showQR = !m.showQR || !m.qrVCard
+			m.qrVCard = true
+			m.copiedMsg = ""
+		case "esc":
+			if m.showQR {
+				m.showQR = false
+				return m, nil
 			}
-		case m.keymap.Back, "esc":
+			return m, func() tea.Msg { return state.ScreenMenu }
+		case m.keymap.Back:
 			return m, func() tea.Msg { return state.ScreenMenu }
 		}
 	}
 	return m, nil
 }
 
+// copyValue puts the contact's value on the visitor's clipboard and returns the
+// message to show. Without a clipboard the value is shown to select instead.
+func (m *contactModel) copyValue(contact ContactInfo) string {
+	if m.clip == nil {
+		return fmt.Sprintf("No clipboard here, select the %s by hand: %s", contact.Label, contact.Value)
+	}
+	if err := m.clip.Copy(contact.Value); err != nil {
+		return fmt.Sprintf("Couldn't copy, select the %s by hand: %s", contact.Label, contact.Value)
+	}
+	if m.clip.Remote() {
+		// OSC 52 is fire and forget, so don't promise more than was done
+		return fmt.Sprintf("Sent %s to your terminal's clipboard!", contact.Label)
+	}
+	return fmt.Sprintf("Copied %s to clipboard!", contact.Label)
+}
+
 func (m *contactModel) View() string {
 	if m.width == 0 {
 		return "Loading..."
@@ -118,10 +292,14 @@ func (m *contactModel) View() string {
 
 	var sections []string
 
+	if m.showQR && !m.form {
+		return m.viewQR()
+	}
+
 	header := components.HeaderBox("SUMMON THE DEV-WARRIOR", m.theme, m.width-4)
 	sections = append(sections, header)
 
-	intro := lipgloss.NewStyle().
+	intro := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Italic(true).
 		Align(lipgloss.Center).
@@ -132,11 +310,14 @@ func (m *contactModel) View() string {
 
 	sections = append(sections, components.DividerLine(m.theme, m.width-2, "─"))
 
-	contactList := m.renderContactList()
-	sections = append(sections, contactList)
+	if m.form {
+		sections = append(sections, m.renderForm())
+	} else {
+		sections = append(sections, m.renderContactList())
+	}
 
 	if m.copiedMsg != "" {
-		msgStyle := lipgloss.NewStyle().
+		msgStyle := m.theme.NewStyle().
 			Foreground(styles.Success).
 			Bold(true).
 			Align(lipgloss.Center).
@@ -148,7 +329,19 @@ func (m *contactModel) View() string {
 	keyBindings := []components.KeyBind{
 		{Key: "↑↓/k/j", Desc: "Navigate"},
 		{Key: "c", Desc: "Copy to Clipboard"},
-		{Key: "b/Esc", Desc: "Retreat"},
+		{Key: "r", Desc: "QR Code"},
+		{Key: "v", Desc: "vCard QR"},
+	}
+	if m.inbox != nil {
+		keyBindings = append(keyBindings, components.KeyBind{Key: "m", Desc: "Send a Message"})
+	}
+	keyBindings = append(keyBindings, components.KeyBind{Key: "b/Esc", Desc: "Retreat"})
+	if m.form {
+		keyBindings = []components.KeyBind{
+			{Key: "Tab", Desc: "Next Field"},
+			{Key: "Ctrl+S", Desc: "Send"},
+			{Key: "Esc", Desc: "Cancel"},
+		}
 	}
 
 	footer := components.RenderKeyBindings(keyBindings, m.theme, m.width)
@@ -165,6 +358,115 @@ func (m *contactModel) View() string {
 	)
 }
 
+func (m *contactModel) renderForm() string {
+	labelStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	field := func(i int, label, view string) string {
+		border := m.theme.Secondary
+		if i == m.focus {
+			border = m.theme.Accent
+		}
+		box := m.theme.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Padding(0, 1).
+			Width(min(74, m.width-16)).
+			Render(view)
+		return labelStyle.Render(label) + "\n" + box
+	}
+
+	parts := []string{
+		field(0, "Name", m.name.View()),
+		field(1, "Reply-to email", m.replyTo.View()),
+		field(2, "Message", m.body.View()),
+	}
+
+	switch {
+	case m.sending:
+		parts = append(parts, m.theme.NewStyle().Foreground(m.theme.Secondary).Italic(true).Render("Sending your message..."))
+	case m.formError != "":
+		parts = append(parts, m.theme.NewStyle().Foreground(m.theme.Error).Bold(true).Render(m.formError))
+	}
+
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// viewQR fills the screen with the QR code so it's big enough to scan.
+func (m *contactModel) viewQR() string {
+	keyBindings := []components.KeyBind{
+		{Key: "↑↓/k/j", Desc: "Next Contact"},
+		{Key: "r", Desc: "Contact QR"},
+		{Key: "v", Desc: "vCard QR"},
+		{Key: "Esc", Desc: "Close"},
+	}
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.renderQR(),
+		components.RenderKeyBindings(keyBindings, m.theme, m.width),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// contactURL is what a phone scanning the contact, or a Gopher or Gemini
+// client following it, should open: links get a scheme and email addresses
+// become mailto links.
+func contactURL(c ContactInfo) string {
+	switch {
+	case c.Label == "Email":
+		return "mailto:" + c.Value
+	case !strings.Contains(c.Value, "://"):
+		return "https://" + c.Value
+	}
+	return c.Value
+}
+
+// renderQR draws the selected contact, or the whole vCard, as a QR code
+// sized to what's left of the window.
+func (m *contactModel) renderQR() string {
+	caption := "Scan to open " + m.contacts[m.cursor].Label
+	payload := contactURL(m.contacts[m.cursor])
+	if m.qrVCard {
+		caption = "Scan to add the warrior to your contacts"
+		payload = string(buildVCard(true))
+	}
+
+	// The header makes way for the code, leave room for the caption and
+	// key bindings
+	code, err := services.EncodeQR(payload, m.width-4, m.height-6)
+	if err != nil {
+		msg := m.theme.NewStyle().
+			Foreground(m.theme.Error).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Render(sentence(err.Error()))
+		return lipgloss.JoinVertical(lipgloss.Left, msg, "")
+	}
+
+	// Pin the colors so the code scans on dark terminals too. Without
+	// colors, draw the light modules instead if the background is dark.
+	r := m.theme.Renderer
+	var drawn string
+	if r != nil && r.ColorProfile() == termenv.Ascii {
+		drawn = code.HalfBlocks(r.HasDarkBackground())
+	} else {
+		drawn = m.theme.NewStyle().
+			Foreground(lipgloss.Color("#000000")).
+			Background(lipgloss.Color("#ffffff")).
+			Render(code.HalfBlocks(false))
+	}
+
+	captionStyle := m.theme.NewStyle().
+		Foreground(m.theme.Accent).
+		Bold(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		lipgloss.PlaceHorizontal(m.width, lipgloss.Center, drawn),
+		captionStyle.Render(caption),
+	)
+}
+
 func (m *contactModel) renderContactList() string {
 	var cards []string
 
@@ -172,13 +474,13 @@ func (m *contactModel) renderContactList() string {
 		isSelected := i == m.cursor
 
 		// Label with better styling
-		labelStyle := lipgloss.NewStyle().
+		labelStyle := m.theme.NewStyle().
 			Foreground(m.theme.Accent).
 			Bold(true).
 			Underline(isSelected)
 
 		// Value with subtle styling
-		valueStyle := lipgloss.NewStyle().
+		valueStyle := m.theme.NewStyle().
 			Foreground(m.theme.Primary).
 			Italic(true)
 
@@ -192,7 +494,7 @@ func (m *contactModel) renderContactList() string {
 		// Card styling
 		var card string
 		if isSelected {
-			cardStyle := lipgloss.NewStyle().
+			cardStyle := m.theme.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(m.theme.Accent).
 				Padding(1, 3).
@@ -202,7 +504,7 @@ func (m *contactModel) renderContactList() string {
 
 			card = cardStyle.Render(contactContent)
 		} else {
-			cardStyle := lipgloss.NewStyle().
+			cardStyle := m.theme.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(m.theme.Secondary).
 				Padding(1, 3).
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"clifolio/internal/services"
+)
+
+// ContentPage builds the page at path in the content tree the Gopher and
+// Gemini servers share: the profile at "/", then /projects with a page per
+// repository, /skills, /experience and /contact.
+func ContentPage(ctx context.Context, path string) (services.Page, error) {
+	path = "/" + strings.Trim(path, "/")
+	switch path {
+	case "/":
+		return profilePage(), nil
+	case "/projects":
+		return projectsPage(ctx), nil
+	case "/skills":
+		return skillsPage(), nil
+	case "/experience":
+		return experiencePage(), nil
+	case "/contact":
+		return contactPage(), nil
+	}
+	if name, ok := strings.CutPrefix(path, "/projects/"); ok && !strings.Contains(name, "/") {
+		return projectPage(ctx, name)
+	}
+	return services.Page{}, services.ErrPageNotFound
+}
+
+func pageText(s string) services.PageLine {
+	return services.PageLine{Kind: services.PageText, Text: s}
+}
+
+func pageHeading(s string) services.PageLine {
+	return services.PageLine{Kind: services.PageHeading, Text: s}
+}
+
+func pageItem(s string) services.PageLine {
+	return services.PageLine{Kind: services.PageItem, Text: s}
+}
+
+func pageLink(target, s string) services.PageLine {
+	return services.PageLine{Kind: services.PageLink, Text: s, Target: target}
+}
+
+func profilePage() services.Page {
+	p := services.GetProfileData()
+	lines := []services.PageLine{
+		pageText(p.Title),
+		pageText(""),
+		pageText(p.Bio),
+		pageText(""),
+	}
+	if p.Location != "" {
+		lines = append(lines, pageText("Location: "+p.Location))
+	}
+	lines = append(lines,
+		pageText(""),
+		pageLink("/projects", "Projects"),
+		pageLink("/skills", "Skills"),
+		pageLink("/experience", "Experience"),
+		pageLink("/contact", "Contact"),
+	)
+	return services.Page{Title: p.Name, Lines: lines}
+}
+
+func projectsPage(ctx context.Context) services.Page {
+	page := services.Page{Title: "Projects"}
+	repos, err := services.FetchRepos(ctx, githubUsername)
+	if err != nil {
+		page.Lines = append(page.Lines,
+			pageText("The projects couldn't be fetched from GitHub right now."),
+			pageLink("https://github.com/"+githubUsername, "See them on GitHub"),
+		)
+		return page
+	}
+	for _, r := range repos {
+		label := r.Name
+		if r.Description != "" {
+			label += " - " + r.Description
+		}
+		page.Lines = append(page.Lines, pageLink("/projects/"+r.Name, label))
+	}
+	page.Lines = append(page.Lines, pageText(""), pageLink("/", "Back to the profile"))
+	return page
+}
+
+// projectPage is one repository with its README. Only repositories in the
+// projects list have pages, so the path can't reach anyone else's.
+func projectPage(ctx context.Context, name string) (services.Page, error) {
+	repos, err := services.FetchRepos(ctx, githubUsername)
+	if err != nil {
+		return services.Page{}, err
+	}
+	for _, r := range repos {
+		if r.Name != name {
+			continue
+		}
+		page := services.Page{Title: r.Name}
+		if r.Description != "" {
+			page.Lines = append(page.Lines, pageText(r.Description), pageText(""))
+		}
+		details := fmt.Sprintf("★ %d", r.Stars)
+		if r.Language != "" {
+			details = r.Language + " · " + details
+		}
+		page.Lines = append(page.Lines, pageText(details), pageLink(r.HTMLURL, "View on GitHub"))
+
+		if readme, err := services.FetchRepoReadme(ctx, r.Owner, r.Name); err == nil && strings.TrimSpace(readme) != "" {
+			page.Lines = append(page.Lines,
+				pageText(""),
+				pageHeading("README"),
+				services.PageLine{Kind: services.PagePre, Text: strings.TrimSpace(readme)},
+			)
+		}
+		page.Lines = append(page.Lines, pageText(""), pageLink("/projects", "Back to the projects"))
+		return page, nil
+	}
+	return services.Page{}, services.ErrPageNotFound
+}
+
+func skillsPage() services.Page {
+	page := services.Page{Title: "Skills"}
+	skills := portfolioSkills()
+	for _, c := range skillCategories() {
+		page.Lines = append(page.Lines, pageHeading(c.DisplayName), pageText(c.Description))
+		for _, s := range skills {
+			if s.Category != c.ID {
+				continue
+			}
+			level := strings.Repeat("●", s.Level) + strings.Repeat("○", max(0, 5-s.Level))
+			page.Lines = append(page.Lines, pageItem(fmt.Sprintf("%s %s, %d yrs", s.Name, level, s.Years)))
+		}
+	}
+	page.Lines = append(page.Lines, pageText(""), pageLink("/", "Back to the profile"))
+	return page
+}
+
+func experiencePage() services.Page {
+	page := services.Page{Title: "Experience"}
+	for _, e := range portfolioExperiences() {
+		page.Lines = append(page.Lines,
+			pageHeading(e.Title),
+			pageText(fmt.Sprintf("%s · %s · %s – %s", e.Organization, e.Location, e.StartDate, e.EndDate)),
+		)
+		for _, d := range e.Description {
+			page.Lines = append(page.Lines, pageItem(d))
+		}
+		if len(e.Skills) > 0 {
+			page.Lines = append(page.Lines, pageText("Skills: "+strings.Join(e.Skills, ", ")))
+		}
+	}
+	page.Lines = append(page.Lines, pageText(""), pageLink("/", "Back to the profile"))
+	return page
+}
+
+func contactPage() services.Page {
+	page := services.Page{Title: "Contact"}
+	for _, c := range portfolioContacts() {
+		page.Lines = append(page.Lines, pageLink(contactURL(c), c.Label+": "+c.Value))
+	}
+	page.Lines = append(page.Lines, pageText(""), pageLink("/", "Back to the profile"))
+	return page
+}
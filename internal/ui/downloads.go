@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"clifolio/internal/services"
+	"clifolio/internal/styles"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// resumeSections are the sections a résumé covers, in order. Projects are
+// left out since they need GitHub and a download shouldn't wait on it.
+var resumeSections = []string{"about", "experience", "skills", "contact"}
+
+// DownloadFS returns the files visitors can fetch with scp or sftp. Each is
+// generated from the portfolio data when it is opened.
+func DownloadFS() fs.FS {
+	return services.GeneratedFS{
+		"resume.md":   resumeMarkdown,
+		"resume.txt":  resumeText,
+		"contact.vcf": contactVCard,
+		"ascii.txt":   func() ([]byte, error) { return os.ReadFile("assets/ascii.txt") },
+	}
+}
+
+// resumeText is the plain text `ssh host <section>` prints, for every
+// résumé section.
+func resumeText() ([]byte, error) {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.Ascii)
+	theme := styles.NewTheme("default", r)
+
+	var b strings.Builder
+	for i, name := range resumeSections {
+		data, err := sectionData(context.Background(), name)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			fmt.Fprintf(&b, "\n%s\n\n", strings.ToUpper(name))
+		}
+		b.WriteString(RenderSection(name, data, theme, 80))
+		b.WriteString("\n")
+	}
+	return []byte(b.String()), nil
+}
+
+func resumeMarkdown() ([]byte, error) {
+	p := services.GetProfileData()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n**%s** · %s\n\n%s\n", p.Name, p.Title, p.Location, p.Bio)
+
+	b.WriteString("\n## Experience\n")
+	for _, e := range portfolioExperiences() {
+		fmt.Fprintf(&b, "\n### %s, %s\n\n*%s – %s · %s*\n\n", e.Title, e.Organization, e.StartDate, e.EndDate, e.Location)
+		for _, d := range e.Description {
+			fmt.Fprintf(&b, "- %s\n", d)
+		}
+		if len(e.Skills) > 0 {
+			fmt.Fprintf(&b, "\nSkills: %s\n", strings.Join(e.Skills, ", "))
+		}
+	}
+
+	b.WriteString("\n## Skills\n\n")
+	skills := portfolioSkills()
+	for _, c := range skillCategories() {
+		var names []string
+		for _, s := range skills {
+			if s.Category == c.ID {
+				names = append(names, s.Name)
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(&b, "- **%s:** %s\n", c.DisplayName, strings.Join(names, ", "))
+		}
+	}
+
+	b.WriteString("\n## Contact\n\n")
+	for _, c := range portfolioContacts() {
+		fmt.Fprintf(&b, "- %s: %s\n", c.Label, c.Value)
+	}
+	return []byte(b.String()), nil
+}
+
+// contactVCard is a vCard 3.0 card that address books can import.
+func contactVCard() ([]byte, error) {
+	return buildVCard(false), nil
+}
+
+// buildVCard writes the owner's card. The compact card leaves out the title,
+// bio and location so it fits in a QR code a terminal can show.
+func buildVCard(compact bool) []byte {
+	p := services.GetProfileData()
+	first, last, _ := strings.Cut(p.Name, " ")
+
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"FN:" + vcardEscape(p.Name),
+		"N:" + vcardEscape(last) + ";" + vcardEscape(first) + ";;;",
+	}
+	if !compact {
+		lines = append(lines,
+			"TITLE:"+vcardEscape(p.Title),
+			"NOTE:"+vcardEscape(p.Bio),
+			"ADR;TYPE=home:;;;"+vcardEscape(p.Location)+";;;",
+		)
+	}
+	for _, c := range portfolioContacts() {
+		value := c.Value
+		if c.Label == "Email" {
+			lines = append(lines, "EMAIL;TYPE=internet:"+vcardEscape(value))
+			continue
+		}
+		if !strings.Contains(value, "://") {
+			value = "https://" + value
+		}
+		lines = append(lines, "URL;TYPE="+strings.ToLower(c.Label)+":"+vcardEscape(value))
+	}
+	lines = append(lines, "END:VCARD")
+
+	// vCard lines end in CRLF
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
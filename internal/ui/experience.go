@@ -1,9 +1,9 @@
 package ui
 
 import (
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
 	"fmt"
 	"strings"
 
@@ -12,15 +12,15 @@ import (
 )
 
 type Experience struct {
-	Type         string
-	Title        string
-	Organization string
-	Location     string
-	StartDate    string
-	EndDate      string
-	Description  []string
-	Skills       []string
-	Icon         string
+	Type         string   `json:"type"`
+	Title        string   `json:"title"`
+	Organization string   `json:"organization"`
+	Location     string   `json:"location"`
+	StartDate    string   `json:"start"`
+	EndDate      string   `json:"end"`
+	Description  []string `json:"description"`
+	Skills       []string `json:"skills"`
+	Icon         string   `json:"-"`
 }
 
 type experienceModel struct {
@@ -33,8 +33,9 @@ type experienceModel struct {
 	viewType    string
 }
 
-func NewExperienceModel(theme styles.Theme) *experienceModel {
-	experiences := []Experience{
+// portfolioExperiences is shared by the experience screen and command.
+func portfolioExperiences() []Experience {
+	return []Experience{
 		{
 			Type:         "work",
 			Title:        "Part Time Mobile Developer",
@@ -66,6 +67,10 @@ func NewExperienceModel(theme styles.Theme) *experienceModel {
 			Icon:   "🎓",
 		},
 	}
+}
+
+func NewExperienceModel(theme styles.Theme) *experienceModel {
+	experiences := portfolioExperiences()
 
 	return &experienceModel{
 		experiences: experiences,
@@ -172,6 +177,17 @@ func (m *experienceModel) View() string {
 	)
 }
 
+// renderPage draws every entry in full, one after another, for
+// `curl host/experience`.
+func (m *experienceModel) renderPage() string {
+	sections := []string{components.HeaderBox("COMBAT HISTORY & TRAINING", m.theme, m.width-4)}
+	for i := range m.experiences {
+		m.cursor = i
+		sections = append(sections, m.renderDetailed())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
 func (m *experienceModel) renderStats() string {
 	workCount := 0
 	eduCount := 0
@@ -195,7 +211,7 @@ func (m *experienceModel) renderStats() string {
 		fmt.Sprintf("👁️  %s View", m.viewType),
 	}
 
-	statStyle := lipgloss.NewStyle().
+	statStyle := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Background(lipgloss.Color("#1a1a1a")).
 		Padding(0, 2).
@@ -219,22 +235,22 @@ func (m *experienceModel) renderTimeline() string {
 	for i, exp := range m.experiences {
 		isSelected := i == m.cursor
 
-		headerStyle := lipgloss.NewStyle().
+		headerStyle := m.theme.NewStyle().
 			Foreground(m.theme.Accent).
 			Bold(true).
 			PaddingBottom(1)
 
 		cardHeader := headerStyle.Render(exp.Icon + "  " + exp.Title)
 
-		orgStyle := lipgloss.NewStyle().
+		orgStyle := m.theme.NewStyle().
 			Foreground(m.theme.Primary).
 			Bold(true)
 
-		locationStyle := lipgloss.NewStyle().
+		locationStyle := m.theme.NewStyle().
 			Foreground(m.theme.Secondary).
 			Italic(true)
 
-		dateStyle := lipgloss.NewStyle().
+		dateStyle := m.theme.NewStyle().
 			Foreground(m.theme.Secondary).
 			Italic(true)
 
@@ -244,7 +260,7 @@ func (m *experienceModel) renderTimeline() string {
 
 		var descPreview string
 		if len(exp.Description) > 0 {
-			descStyle := lipgloss.NewStyle().
+			descStyle := m.theme.NewStyle().
 				Foreground(m.theme.Secondary).
 				PaddingTop(1).
 				Italic(true)
@@ -259,7 +275,7 @@ func (m *experienceModel) renderTimeline() string {
 		// Skills badges
 		var skillsLine string
 		if len(exp.Skills) > 0 {
-			skillStyle := lipgloss.NewStyle().
+			skillStyle := m.theme.NewStyle().
 				Foreground(m.theme.Background).
 				Background(m.theme.Accent).
 				Padding(0, 1).
@@ -287,7 +303,7 @@ func (m *experienceModel) renderTimeline() string {
 		// Apply card styling
 		var card string
 		if isSelected {
-			cardStyle := lipgloss.NewStyle().
+			cardStyle := m.theme.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(m.theme.Accent).
 				Padding(1, 2).
@@ -297,7 +313,7 @@ func (m *experienceModel) renderTimeline() string {
 
 			card = cardStyle.Render(cardContent)
 		} else {
-			cardStyle := lipgloss.NewStyle().
+			cardStyle := m.theme.NewStyle().
 				Border(lipgloss.RoundedBorder()).
 				BorderForeground(m.theme.Secondary).
 				Padding(1, 2).
@@ -329,7 +345,7 @@ func (m *experienceModel) renderDetailed() string {
 	var sections []string
 
 	// Title section
-	titleStyle := lipgloss.NewStyle().
+	titleStyle := m.theme.NewStyle().
 		Foreground(m.theme.Accent).
 		Bold(true).
 		Underline(true)
@@ -339,12 +355,12 @@ func (m *experienceModel) renderDetailed() string {
 	sections = append(sections, "")
 
 	// Info in clean aligned format
-	labelStyle := lipgloss.NewStyle().
+	labelStyle := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Width(10).
 		Align(lipgloss.Right)
 
-	valueStyle := lipgloss.NewStyle().
+	valueStyle := m.theme.NewStyle().
 		Foreground(m.theme.Primary)
 
 	orgLine := lipgloss.JoinHorizontal(lipgloss.Left,
@@ -367,7 +383,7 @@ func (m *experienceModel) renderDetailed() string {
 
 	// Quest Log (Description) with better styling
 	if len(exp.Description) > 0 {
-		divider := lipgloss.NewStyle().
+		divider := m.theme.NewStyle().
 			Foreground(m.theme.Accent).
 			Bold(true).
 			Render("━━━ QUEST LOG ━━━")
@@ -377,7 +393,7 @@ func (m *experienceModel) renderDetailed() string {
 		for _, desc := range exp.Description {
 			// Add spacing to align with the values above (10 char label + 2 spaces)
 			indent := strings.Repeat(" ", 12)
-			descText := lipgloss.NewStyle().Foreground(m.theme.Primary).Render(desc)
+			descText := m.theme.NewStyle().Foreground(m.theme.Primary).Render(desc)
 			sections = append(sections, indent+descText)
 		}
 		sections = append(sections, "")
@@ -385,14 +401,14 @@ func (m *experienceModel) renderDetailed() string {
 
 	// Skills Arsenal with enhanced badges
 	if len(exp.Skills) > 0 {
-		divider := lipgloss.NewStyle().
+		divider := m.theme.NewStyle().
 			Foreground(m.theme.Accent).
 			Bold(true).
 			Render("━━━ SKILLS ━━━")
 
 		sections = append(sections, divider)
 
-		skillStyle := lipgloss.NewStyle().
+		skillStyle := m.theme.NewStyle().
 			Foreground(m.theme.Background).
 			Background(m.theme.Accent).
 			Padding(0, 1).
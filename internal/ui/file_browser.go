@@ -0,0 +1,427 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type treeNode struct {
+	entry    services.RepoEntry
+	depth    int
+	children []*treeNode
+	expanded bool
+	loaded   bool
+	loading  bool
+}
+
+type fileBrowserModel struct {
+	repo    services.Repo
+	root    []*treeNode
+	visible []*treeNode
+	cursor  int
+	offset  int
+	loading bool
+	err     error
+
+	viewing     bool
+	viewPath    string
+	viewLoading bool
+	viewErr     error
+	viewer      viewport.Model
+
+	spin  components.SpinnerComponent
+	theme styles.Theme
+
+	width  int
+	height int
+}
+
+type openFileBrowserMsg struct {
+	repo services.Repo
+}
+
+type backToProjectDetailMsg struct{}
+
+type dirLoadedMsg struct {
+	path    string
+	entries []services.RepoEntry
+	err     error
+}
+
+type fileLoadedMsg struct {
+	path    string
+	content string
+	err     error
+}
+
+func FileBrowserModel(repo services.Repo, theme styles.Theme) *fileBrowserModel {
+	return &fileBrowserModel{
+		repo:    repo,
+		loading: true,
+		spin:    components.NewSpinner(theme),
+		theme:   theme,
+		viewer:  viewport.New(0, 0),
+	}
+}
+
+func fetchDirCmd(repo services.Repo, dir string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		entries, err := services.FetchRepoContents(ctx, repo.Owner, repo.Name, dir)
+		return dirLoadedMsg{path: dir, entries: entries, err: err}
+	}
+}
+
+func fetchFileCmd(repo services.Repo, file string, theme styles.Theme) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		f, err := services.FetchRepoFile(ctx, repo.Owner, repo.Name, file)
+		if err != nil {
+			return fileLoadedMsg{path: file, err: err}
+		}
+		highlighted, err := services.HighlightCode(path.Base(file), f.Content, theme.Renderer.ColorProfile())
+		if err != nil {
+			// Fall back to the raw text rather than failing the whole view
+			highlighted = f.Content
+		}
+		return fileLoadedMsg{path: file, content: withLineNumbers(highlighted, theme)}
+	}
+}
+
+func withLineNumbers(s string, theme styles.Theme) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	gutter := theme.NewStyle().Foreground(lipgloss.Color("#586e75"))
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(gutter.Render(fmt.Sprintf("%*d │ ", width, i+1)))
+		b.WriteString(line)
+		if i < len(lines)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func (m *fileBrowserModel) Init() tea.Cmd {
+	return tea.Batch(m.spin.Init(), fetchDirCmd(m.repo, ""))
+}
+
+// findNode returns the directory node for p, or nil for the repository root.
+func findNode(nodes []*treeNode, p string) *treeNode {
+	for _, n := range nodes {
+		if n.entry.Path == p {
+			return n
+		}
+		if n.entry.IsDir() && strings.HasPrefix(p, n.entry.Path+"/") {
+			return findNode(n.children, p)
+		}
+	}
+	return nil
+}
+
+func (m *fileBrowserModel) rebuildVisible() {
+	m.visible = m.visible[:0]
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			m.visible = append(m.visible, n)
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+	walk(m.root)
+
+	if m.cursor >= len(m.visible) {
+		m.cursor = max(0, len(m.visible)-1)
+	}
+	m.ensureCursorInWindow()
+}
+
+func (m *fileBrowserModel) pageSize() int {
+	return max(3, m.height-10)
+}
+
+func (m *fileBrowserModel) ensureCursorInWindow() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.pageSize() {
+		m.offset = m.cursor - m.pageSize() + 1
+	}
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m *fileBrowserModel) resizeViewer() {
+	m.viewer.Width = max(20, m.width-4)
+	m.viewer.Height = max(3, m.height-6)
+}
+
+func (m *fileBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	km := components.DefaultKeymap()
+
+	var cmds []tea.Cmd
+
+	newSpin, spinCmd := m.spin.Update(msg)
+	m.spin = newSpin
+	cmds = append(cmds, spinCmd)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resizeViewer()
+		m.ensureCursorInWindow()
+
+	case dirLoadedMsg:
+		nodes := make([]*treeNode, 0, len(msg.entries))
+		parent := findNode(m.root, msg.path)
+		depth := 0
+		if parent != nil {
+			depth = parent.depth + 1
+		}
+		for _, e := range msg.entries {
+			nodes = append(nodes, &treeNode{entry: e, depth: depth})
+		}
+
+		if msg.path == "" {
+			m.loading = false
+			m.err = msg.err
+			m.root = nodes
+		} else if parent != nil {
+			parent.loading = false
+			if msg.err != nil {
+				parent.expanded = false
+				m.err = msg.err
+			} else {
+				m.err = nil
+				parent.loaded = true
+				parent.children = nodes
+			}
+		}
+		m.rebuildVisible()
+		return m, nil
+
+	case fileLoadedMsg:
+		if msg.path != m.viewPath {
+			return m, nil
+		}
+		m.viewLoading = false
+		m.viewErr = msg.err
+		m.viewer.SetContent(msg.content)
+		m.viewer.GotoTop()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.viewing {
+			switch msg.String() {
+			case km.Quit, "ctrl+c":
+				return m, tea.Quit
+			case km.Back, "esc", km.Left, "left":
+				m.viewing = false
+				return m, nil
+			case "g", "home":
+				m.viewer.GotoTop()
+			case "G", "end":
+				m.viewer.GotoBottom()
+			default:
+				var cmd tea.Cmd
+				m.viewer, cmd = m.viewer.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+			return m, tea.Batch(cmds...)
+		}
+
+		switch msg.String() {
+		case km.Quit, "ctrl+c":
+			return m, tea.Quit
+		case km.Back, "esc":
+			return m, func() tea.Msg { return backToProjectDetailMsg{} }
+		case km.Up, "up":
+			if m.cursor > 0 {
+				m.cursor--
+				m.ensureCursorInWindow()
+			}
+		case km.Down, "down":
+			if m.cursor < len(m.visible)-1 {
+				m.cursor++
+				m.ensureCursorInWindow()
+			}
+		case km.Confirm, km.Right, "right", " ":
+			if m.cursor >= len(m.visible) {
+				return m, nil
+			}
+			node := m.visible[m.cursor]
+			if node.entry.IsDir() {
+				if node.expanded {
+					node.expanded = false
+					m.rebuildVisible()
+					return m, nil
+				}
+				node.expanded = true
+				if !node.loaded && !node.loading {
+					node.loading = true
+					m.err = nil
+					m.rebuildVisible()
+					return m, fetchDirCmd(m.repo, node.entry.Path)
+				}
+				m.rebuildVisible()
+				return m, nil
+			}
+			m.viewing = true
+			m.viewPath = node.entry.Path
+			m.viewLoading = true
+			m.viewErr = nil
+			m.err = nil
+			m.viewer.SetContent("")
+			m.resizeViewer()
+			return m, fetchFileCmd(m.repo, node.entry.Path, m.theme)
+		case km.Left, "left":
+			if m.cursor >= len(m.visible) {
+				return m, nil
+			}
+			node := m.visible[m.cursor]
+			if node.entry.IsDir() && node.expanded {
+				node.expanded = false
+				m.rebuildVisible()
+				return m, nil
+			}
+			// Jump to the parent directory
+			if parent := findNode(m.root, path.Dir(node.entry.Path)); parent != nil {
+				for i, n := range m.visible {
+					if n == parent {
+						m.cursor = i
+						m.ensureCursorInWindow()
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m *fileBrowserModel) View() string {
+	theme := m.theme
+	titleStyle := theme.NewStyle().Foreground(theme.Primary).Bold(true).MarginBottom(1)
+	metaStyle := theme.NewStyle().Foreground(theme.Secondary)
+	errorStyle := theme.NewStyle().Foreground(theme.Error)
+	helpStyle := theme.NewStyle().Foreground(theme.Help).MarginTop(1)
+	boxStyle := theme.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Primary).Padding(2, 4)
+
+	if m.viewing {
+		return m.viewFile(theme)
+	}
+
+	if m.loading {
+		loadingBox := boxStyle.Render(fmt.Sprintf("%s Loading %s...", m.spin.View(), m.repo.Name))
+		if m.width > 0 && m.height > 0 {
+			return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, loadingBox)
+		}
+		return "\n\n" + loadingBox
+	}
+
+	s := titleStyle.Render(fmt.Sprintf("🗂  %s/%s", m.repo.Owner, m.repo.Name)) + "\n"
+
+	if m.err != nil {
+		s += errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n\n"
+	}
+
+	if len(m.visible) == 0 {
+		s += metaStyle.Render("This repository is empty.") + "\n"
+	}
+
+	dirStyle := theme.NewStyle().Foreground(theme.Accent).Bold(true)
+	fileStyle := theme.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	selectedStyle := theme.NewStyle().Foreground(theme.Primary).Bold(true)
+
+	end := min(len(m.visible), m.offset+m.pageSize())
+	for i := m.offset; i < end; i++ {
+		node := m.visible[i]
+		indent := strings.Repeat("  ", node.depth)
+
+		var icon, name string
+		if node.entry.IsDir() {
+			icon = "▸ "
+			if node.expanded {
+				icon = "▾ "
+			}
+			name = dirStyle.Render(node.entry.Name + "/")
+			if node.loading {
+				name += " " + m.spin.View()
+			}
+		} else {
+			icon = "  "
+			name = fileStyle.Render(node.entry.Name) + " " + metaStyle.Render(formatSize(node.entry.Size))
+		}
+
+		line := indent + icon + name
+		if i == m.cursor {
+			s += selectedStyle.Render("› ") + line + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	s += helpStyle.Render("↑/↓: navigate • enter/→: open • ←: collapse • esc: back • q: quit")
+
+	return s
+}
+
+func (m *fileBrowserModel) viewFile(theme styles.Theme) string {
+	titleStyle := theme.NewStyle().Foreground(theme.Primary).Bold(true)
+	metaStyle := theme.NewStyle().Foreground(theme.Secondary)
+	errorStyle := theme.NewStyle().Foreground(theme.Error)
+	helpStyle := theme.NewStyle().Foreground(theme.Help)
+	frameStyle := theme.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Secondary)
+
+	header := titleStyle.Render("📄 "+m.viewPath) + "  " +
+		metaStyle.Render(fmt.Sprintf("%3.0f%%", m.viewer.ScrollPercent()*100))
+
+	var body string
+	switch {
+	case m.viewLoading:
+		body = fmt.Sprintf("%s Fetching %s...", m.spin.View(), path.Base(m.viewPath))
+	case errors.Is(m.viewErr, services.ErrFileTooLarge):
+		body = errorStyle.Render(fmt.Sprintf("This file is larger than %s, open it on GitHub instead.", formatSize(services.MaxFileSize)))
+	case errors.Is(m.viewErr, services.ErrBinaryFile):
+		body = errorStyle.Render("This looks like a binary file, so there's nothing to show.")
+	case m.viewErr != nil:
+		body = errorStyle.Render(fmt.Sprintf("Error: %v", m.viewErr))
+	default:
+		body = m.viewer.View()
+	}
+
+	help := helpStyle.Render("↑/↓/pgup/pgdn: scroll • g/G: top/bottom • esc: back to tree • q: quit")
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, frameStyle.Render(body), help)
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,449 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/state"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
+)
+
+type guestbookMode int
+
+const (
+	guestbookBrowse guestbookMode = iota
+	guestbookCompose
+	guestbookQueue
+)
+
+var githubUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})$`)
+
+type guestbookModel struct {
+	store *services.Guestbook
+	key   ssh.PublicKey
+	ip    string
+	owner bool
+
+	mode    guestbookMode
+	entries []services.GuestbookEntry
+	queue   []services.GuestbookEntry
+	page    int
+	cursor  int
+
+	message    textinput.Model
+	github     textinput.Model
+	focus      int
+	submitting bool
+	status     string
+	statusErr  bool
+
+	spin   components.SpinnerComponent
+	theme  styles.Theme
+	keymap components.Keymap
+	width  int
+	height int
+}
+
+type guestbookSignedMsg struct {
+	err error
+}
+
+func NewGuestbookModel(store *services.Guestbook, key ssh.PublicKey, ip string, owner bool, theme styles.Theme) *guestbookModel {
+	message := textinput.New()
+	message.Placeholder = "Leave a few kind words..."
+	message.CharLimit = services.MaxGuestbookMessage
+	message.Width = 60
+
+	github := textinput.New()
+	github.Placeholder = "optional, must have this SSH key on GitHub"
+	github.CharLimit = 39
+	github.Width = 40
+
+	m := &guestbookModel{
+		store:   store,
+		key:     key,
+		ip:      ip,
+		owner:   owner,
+		message: message,
+		github:  github,
+		spin:    components.NewSpinner(theme),
+		theme:   theme,
+		keymap:  components.DefaultKeymap(),
+	}
+	m.reload()
+	return m
+}
+
+func (m *guestbookModel) reload() {
+	m.entries = m.store.Entries(services.EntryApproved)
+	if m.owner {
+		m.queue = m.store.Entries(services.EntryPending)
+	}
+	m.page = min(m.page, max(0, m.pages()-1))
+	m.cursor = min(m.cursor, max(0, len(m.queue)-1))
+}
+
+// CapturingInput keeps global shortcuts out of the way while typing.
+func (m *guestbookModel) CapturingInput() bool {
+	return m.mode == guestbookCompose
+}
+
+func (m *guestbookModel) perPage() int {
+	// Each entry takes four lines including its border
+	return max(2, (m.height-16)/4)
+}
+
+func (m *guestbookModel) pages() int {
+	return max(1, (len(m.entries)+m.perPage()-1)/m.perPage())
+}
+
+func signGuestbookCmd(store *services.Guestbook, key ssh.PublicKey, ip, githubUser, message string) tea.Cmd {
+	return func() tea.Msg {
+		if githubUser != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			ok, err := services.VerifyGitHubKey(ctx, githubUser, key)
+			if err != nil {
+				return guestbookSignedMsg{err: fmt.Errorf("couldn't check github.com/%s.keys: %w", githubUser, err)}
+			}
+			if !ok {
+				return guestbookSignedMsg{err: fmt.Errorf("your key isn't listed at github.com/%s.keys", githubUser)}
+			}
+		}
+
+		_, err := store.Sign(services.Fingerprint(key), ip, githubUser, message)
+		return guestbookSignedMsg{err: err}
+	}
+}
+
+func (m *guestbookModel) Init() tea.Cmd {
+	m.reload()
+	return nil
+}
+
+func (m *guestbookModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.message.Width = min(60, max(20, msg.Width-30))
+		m.page = min(m.page, m.pages()-1)
+		return m, nil
+
+	case guestbookSignedMsg:
+		m.submitting = false
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			m.statusErr = true
+			return m, nil
+		}
+		m.status = "Thank you! Your entry will appear once the owner approves it."
+		m.statusErr = false
+		m.message.Reset()
+		m.github.Reset()
+		m.mode = guestbookBrowse
+		m.reload()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case guestbookCompose:
+			return m.updateCompose(msg)
+		case guestbookQueue:
+			return m.updateQueue(msg)
+		}
+
+		switch msg.String() {
+		case m.keymap.Quit, "ctrl+c":
+			return m, tea.Quit
+		case m.keymap.Back, "esc":
+			return m, func() tea.Msg { return state.ScreenMenu }
+		case "left", "h", "p":
+			if m.page > 0 {
+				m.page--
+			}
+		case "right", "l", "n":
+			if m.page < m.pages()-1 {
+				m.page++
+			}
+		case "s":
+			if m.key == nil {
+				m.status = services.ErrNoKey.Error()
+				m.statusErr = true
+				return m, nil
+			}
+			m.mode = guestbookCompose
+			m.status = ""
+			m.focus = 0
+			m.github.Blur()
+			return m, m.message.Focus()
+		case "m":
+			if m.owner {
+				m.reload()
+				m.mode = guestbookQueue
+				m.status = ""
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.spin, cmd = m.spin.Update(msg)
+	return m, cmd
+}
+
+func (m *guestbookModel) updateCompose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.submitting {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.mode = guestbookBrowse
+		m.message.Blur()
+		m.github.Blur()
+		return m, nil
+	case "tab", "shift+tab", "up", "down":
+		m.focus = 1 - m.focus
+		if m.focus == 0 {
+			m.github.Blur()
+			return m, m.message.Focus()
+		}
+		m.message.Blur()
+		return m, m.github.Focus()
+	case "enter":
+		user := strings.TrimPrefix(strings.TrimSpace(m.github.Value()), "@")
+		if user != "" && !githubUsernamePattern.MatchString(user) {
+			m.status = "That doesn't look like a GitHub username."
+			m.statusErr = true
+			return m, nil
+		}
+		if _, err := services.FilterMessage(m.message.Value()); err != nil {
+			m.status = "Sorry, " + err.Error() + "."
+			m.statusErr = true
+			return m, nil
+		}
+		m.submitting = true
+		m.status = ""
+		return m, tea.Batch(m.spin.Init(), signGuestbookCmd(m.store, m.key, m.ip, user, m.message.Value()))
+	}
+
+	var cmd tea.Cmd
+	if m.focus == 0 {
+		m.message, cmd = m.message.Update(msg)
+	} else {
+		m.github, cmd = m.github.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *guestbookModel) updateQueue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case m.keymap.Quit, "ctrl+c":
+		return m, tea.Quit
+	case m.keymap.Back, "esc", "m":
+		m.mode = guestbookBrowse
+		m.reload()
+	case m.keymap.Up, "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case m.keymap.Down, "down", "j":
+		if m.cursor < len(m.queue)-1 {
+			m.cursor++
+		}
+	case "a", "x":
+		if m.cursor < len(m.queue) {
+			entry := m.queue[m.cursor]
+			if err := m.store.Moderate(entry.ID, msg.String() == "a"); err != nil {
+				m.status = err.Error()
+				m.statusErr = true
+			} else if msg.String() == "a" {
+				m.status = "Approved the entry from " + entry.Author() + "."
+				m.statusErr = false
+			} else {
+				m.status = "Rejected the entry from " + entry.Author() + "."
+				m.statusErr = false
+			}
+			m.reload()
+		}
+	}
+	return m, nil
+}
+
+func (m *guestbookModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var sections []string
+
+	title := "GUESTBOOK"
+	if m.mode == guestbookQueue {
+		title = "GUESTBOOK · MODERATION"
+	}
+	sections = append(sections, components.HeaderBox(title, m.theme, m.width-4))
+
+	intro := "Travellers who passed through and left their mark"
+	if m.mode == guestbookQueue {
+		intro = fmt.Sprintf("%d entries awaiting your judgement", len(m.queue))
+	}
+	sections = append(sections, m.theme.NewStyle().
+		Foreground(m.theme.Secondary).
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width).
+		Render(intro))
+
+	sections = append(sections, components.DividerLine(m.theme, m.width-4, "─"))
+
+	switch m.mode {
+	case guestbookCompose:
+		sections = append(sections, m.renderCompose())
+	case guestbookQueue:
+		sections = append(sections, m.renderEntries(m.queue, m.cursor))
+	default:
+		start := m.page * m.perPage()
+		end := min(start+m.perPage(), len(m.entries))
+		sections = append(sections, m.renderEntries(m.entries[start:end], -1))
+		if m.pages() > 1 {
+			sections = append(sections, m.theme.NewStyle().
+				Foreground(m.theme.Secondary).
+				Align(lipgloss.Center).
+				Width(m.width).
+				Render(fmt.Sprintf("Page %d of %d", m.page+1, m.pages())))
+		}
+	}
+
+	if m.status != "" {
+		var color lipgloss.TerminalColor = styles.Success
+		if m.statusErr {
+			color = m.theme.Error
+		}
+		sections = append(sections, m.theme.NewStyle().
+			Foreground(color).
+			Bold(true).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Render(m.status))
+	}
+
+	sections = append(sections, components.RenderKeyBindings(m.keyBindings(), m.theme, m.width))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		content,
+	)
+}
+
+func (m *guestbookModel) keyBindings() []components.KeyBind {
+	switch m.mode {
+	case guestbookCompose:
+		return []components.KeyBind{
+			{Key: "Tab", Desc: "Switch Field"},
+			{Key: "Enter", Desc: "Sign"},
+			{Key: "Esc", Desc: "Cancel"},
+		}
+	case guestbookQueue:
+		return []components.KeyBind{
+			{Key: "↑↓/k/j", Desc: "Navigate"},
+			{Key: "a", Desc: "Approve"},
+			{Key: "x", Desc: "Reject"},
+			{Key: "m/Esc", Desc: "Back"},
+		}
+	}
+
+	binds := []components.KeyBind{
+		{Key: "←→/h/l", Desc: "Page"},
+		{Key: "s", Desc: "Sign"},
+	}
+	if m.owner {
+		binds = append(binds, components.KeyBind{Key: "m", Desc: fmt.Sprintf("Moderate (%d)", len(m.queue))})
+	}
+	return append(binds, components.KeyBind{Key: "b/Esc", Desc: "Retreat"})
+}
+
+func (m *guestbookModel) renderEntries(entries []services.GuestbookEntry, cursor int) string {
+	if len(entries) == 0 {
+		empty := "No entries yet. Press s to be the first!"
+		if m.mode == guestbookQueue {
+			empty = "The queue is empty."
+		}
+		return m.theme.NewStyle().
+			Foreground(m.theme.Secondary).
+			Italic(true).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Padding(1, 0).
+			Render(empty)
+	}
+
+	authorStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	dateStyle := m.theme.NewStyle().Foreground(m.theme.Secondary)
+	messageStyle := m.theme.NewStyle().Foreground(m.theme.Primary)
+
+	width := min(m.width-8, 90)
+	var cards []string
+	for i, e := range entries {
+		border := m.theme.Secondary
+		if i == cursor {
+			border = m.theme.Accent
+		}
+
+		head := authorStyle.Render(e.Author()) + dateStyle.Render(" · "+e.Time.Format("Jan 2, 2006"))
+		card := m.theme.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(border).
+			Padding(0, 1).
+			Width(width).
+			MaxHeight(4).
+			Render(head + "\n" + messageStyle.Render(e.Message))
+		cards = append(cards, card)
+	}
+
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, lipgloss.JoinVertical(lipgloss.Left, cards...))
+}
+
+func (m *guestbookModel) renderCompose() string {
+	labelStyle := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true)
+	hintStyle := m.theme.NewStyle().Foreground(m.theme.Secondary).Italic(true)
+
+	lines := []string{
+		labelStyle.Render("Message"),
+		m.message.View(),
+		hintStyle.Render(fmt.Sprintf("%d/%d", len([]rune(m.message.Value())), services.MaxGuestbookMessage)),
+		"",
+		labelStyle.Render("GitHub username"),
+		m.github.View(),
+		"",
+		hintStyle.Render("Signed as " + services.GuestbookEntry{Fingerprint: services.Fingerprint(m.key)}.Author()),
+	}
+	if m.submitting {
+		lines = append(lines, m.spin.View()+" Signing...")
+	}
+
+	box := m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Accent).
+		Padding(1, 2).
+		Render(strings.Join(lines, "\n"))
+
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, box)
+}
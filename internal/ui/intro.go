@@ -29,7 +29,7 @@ type introModel struct {
 	maxLines  int
 }
 
-func IntroModel() introModel {
+func IntroModel(theme styles.Theme) introModel {
 	introData, err := services.LoadASCII("assets/intro.txt")
 	fullText := ""
 	if err == nil {
@@ -46,8 +46,6 @@ func IntroModel() introModel {
 		ascii = strings.Split(string(data), "\n")
 	}
 
-	theme := styles.NewThemeFromName("default")
-
 	// Calculate total lines for fixed box size
 	maxLines := len(strings.Split(fullText, "\n"))
 
@@ -191,12 +189,12 @@ func (m introModel) renderIntroText() string {
 		contentWidth = 40
 	}
 
-	textStyle := lipgloss.NewStyle().
+	textStyle := m.theme.NewStyle().
 		Foreground(m.theme.Primary).
 		Align(lipgloss.Left).
 		Width(contentWidth)
 
-	highlightStyle := lipgloss.NewStyle().
+	highlightStyle := m.theme.NewStyle().
 		Foreground(m.theme.Accent).
 		Bold(true)
 
@@ -218,7 +216,7 @@ func (m introModel) renderIntroText() string {
 func (m introModel) renderASCII() string {
 	asciiContent := strings.Join(m.ascii, "\n")
 
-	asciiStyle := lipgloss.NewStyle().
+	asciiStyle := m.theme.NewStyle().
 		Foreground(m.theme.Accent).
 		Align(lipgloss.Center).
 		Width(m.width - 16)
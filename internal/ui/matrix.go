@@ -1,8 +1,8 @@
 package ui
 
 import (
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
-	"clifolio/internal/ui/state"
 	"math/rand"
 	"time"
 
@@ -11,6 +11,7 @@ import (
 )
 
 type matrixModel struct {
+	theme   styles.Theme
 	columns []column
 	width   int
 	height  int
@@ -26,8 +27,9 @@ type column struct {
 
 type matrixTickMsg struct{}
 
-func MatrixModel() *matrixModel {
+func MatrixModel(theme styles.Theme) *matrixModel {
 	return &matrixModel{
+		theme:  theme,
 		width:  80,
 		height: 24,
 	}
@@ -110,7 +112,7 @@ func (m *matrixModel) View() string {
 		m.initColumns()
 	}
 
-	theme := styles.NewThemeFromName("default")
+	theme := m.theme
 
 	// 2D grid to hold characters
 	grid := make([][]rune, m.height)
@@ -150,13 +152,13 @@ func (m *matrixModel) View() string {
 
 				var style lipgloss.Style
 				if distFromHead == 0 {
-					style = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+					style = theme.NewStyle().Foreground(lipgloss.Color("#ffffff"))
 				} else if distFromHead < col.length/3 {
-					style = lipgloss.NewStyle().Foreground(theme.Primary)
+					style = theme.NewStyle().Foreground(theme.Primary)
 				} else if distFromHead < col.length*2/3 {
-					style = lipgloss.NewStyle().Foreground(theme.Secondary)
+					style = theme.NewStyle().Foreground(theme.Secondary)
 				} else {
-					style = lipgloss.NewStyle().Foreground(lipgloss.Color("#003300"))
+					style = theme.NewStyle().Foreground(lipgloss.Color("#003300"))
 				}
 
 				output += style.Render(string(char))
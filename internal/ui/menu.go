@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"clifolio/internal/services"
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -12,13 +14,15 @@ import (
 )
 
 type menuModel struct {
-	cursor  int
-	choices []components.ListItem
-	search  textinput.Model
-	theme   styles.Theme
-	width   int
-	height  int
-	open    bool
+	cursor   int
+	choices  []components.ListItem
+	screens  []state.Screen
+	search   textinput.Model
+	theme    styles.Theme
+	presence services.PresenceMsg
+	width    int
+	height   int
+	open     bool
 }
 
 func MenuModel() tea.Model {
@@ -80,9 +84,18 @@ func NewMenuModel(theme styles.Theme) *menuModel {
 	return &menuModel{
 		cursor:  0,
 		choices: choices,
-		search:  ti,
-		theme:   theme,
-		open:    true,
+		screens: []state.Screen{
+			state.ScreenProjects,
+			state.ScreenSkills,
+			state.ScreenExperience,
+			state.ScreenContact,
+			state.ScreenStats,
+			state.ScreenTheme,
+			state.ScreenMatrix,
+		},
+		search: ti,
+		theme:  theme,
+		open:   true,
 	}
 }
 
@@ -100,6 +113,10 @@ func (m *menuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case services.PresenceMsg:
+		m.presence = msg
+		return m, nil
+
 	case tea.KeyMsg:
 		// Handle search input
 		if m.search.Focused() {
@@ -167,7 +184,7 @@ func (m *menuModel) View() string {
 	sections = append(sections, header)
 
 	// Subtitle
-	subtitle := lipgloss.NewStyle().
+	subtitle := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Italic(true).
 		Align(lipgloss.Center).
@@ -180,7 +197,7 @@ func (m *menuModel) View() string {
 
 	// Search box (if focused)
 	if m.search.Focused() {
-		searchBox := lipgloss.NewStyle().
+		searchBox := m.theme.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(m.theme.Accent).
 			Padding(0, 1).
@@ -200,7 +217,7 @@ func (m *menuModel) View() string {
 		HighlightColor: m.theme.Accent.(lipgloss.Color),
 	}
 
-	list := components.RenderList(m.choices, m.cursor, m.theme, listStyle)
+	list := components.RenderList(m.choicesWithPresence(), m.cursor, m.theme, listStyle)
 	listBox := components.SectionBox("", list, m.theme, m.width-8)
 	sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, listBox))
 
@@ -225,19 +242,29 @@ func (m *menuModel) View() string {
 	)
 }
 
-func (m *menuModel) getSelectedScreen() state.Screen {
-	screens := []state.Screen{
-		state.ScreenProjects,
-		state.ScreenSkills,
-		state.ScreenExperience,
-		state.ScreenContact,
-		state.ScreenStats,
-		state.ScreenTheme,
-		state.ScreenMatrix,
+// choicesWithPresence marks each entry with how many visitors are on its
+// screen right now. Whoever is reading the menu is on the menu, so these are
+// always other people.
+func (m *menuModel) choicesWithPresence() []components.ListItem {
+	choices := make([]components.ListItem, len(m.choices))
+	copy(choices, m.choices)
+	for i, screen := range m.screens {
+		if n := m.presence.Screens[screen]; n > 0 {
+			choices[i].Meta = strconv.Itoa(n) + " viewing"
+		}
 	}
+	return choices
+}
+
+// addItem appends an entry that navigates to screen when selected.
+func (m *menuModel) addItem(item components.ListItem, screen state.Screen) {
+	m.choices = append(m.choices, item)
+	m.screens = append(m.screens, screen)
+}
 
-	if m.cursor >= 0 && m.cursor < len(screens) {
-		return screens[m.cursor]
+func (m *menuModel) getSelectedScreen() state.Screen {
+	if m.cursor >= 0 && m.cursor < len(m.screens) {
+		return m.screens[m.cursor]
 	}
 	return state.ScreenMenu
 }
@@ -2,9 +2,11 @@ package ui
 
 import (
 	"clifolio/internal/services"
+	"context"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
 	"fmt"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,6 +18,8 @@ type projectDetailsModel struct {
 	rendered		string
 	loaded 		    bool
 	err				error
+	graphics		services.GraphicsProtocol
+	theme			styles.Theme
 
 	width 			int
 	height 			int
@@ -23,17 +27,28 @@ type projectDetailsModel struct {
 
 type backToProjectsMsg struct{}
 
-func ProjectDetailsModel(r services.Repo, md string) projectDetailsModel {
+func ProjectDetailsModel(r services.Repo, md string, theme styles.Theme, graphics services.GraphicsProtocol) projectDetailsModel {
 	return projectDetailsModel{
 		project: r,
 		rawMD: md,
 		loaded: false,
+		graphics: graphics,
+		theme: theme,
 	}
 }
 
 func (m projectDetailsModel) Init() tea.Cmd {
+	opts := services.ImageOptions{
+		Width:    min(60, max(20, m.width-8)),
+		Height:   15,
+		Protocol: m.graphics,
+		Profile:  m.theme.Renderer.ColorProfile(),
+	}
+	dark := m.theme.Renderer.HasDarkBackground()
 	return func() tea.Msg {
-		out, err := services.GenerateMarkdown(m.rawMD)
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		out, err := services.RenderReadme(ctx, m.rawMD, m.project, opts, dark)
 		if err != nil {
 			return err
 		}
@@ -45,6 +60,9 @@ func (m projectDetailsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	km := components.DefaultKeymap()
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
 	case string:
 		m.rendered = msg
 		m.loaded = true
@@ -56,20 +74,23 @@ func (m projectDetailsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case km.Back, "esc":
 			return m, func() tea.Msg { return backToProjectsMsg{} }
+		case "f":
+			repo := m.project
+			return m, func() tea.Msg { return openFileBrowserMsg{repo: repo} }
 		}
 	}
 	return m, nil
 }
 
 func (m projectDetailsModel) View() string {
-	theme := styles.NewThemeFromName("default")
-	titleStyles := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).MarginBottom(1)
-	metaStyle := lipgloss.NewStyle().Foreground(theme.Secondary)
-	errorStyle := lipgloss.NewStyle().Foreground(theme.Error)
-	loadingStyle := lipgloss.NewStyle().Foreground(theme.Accent)
-	helpStyle := lipgloss.NewStyle().Foreground(theme.Help).MarginTop(1)
-	boxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Primary).Padding(1, 2)
-	starStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700"))
+	theme := m.theme
+	titleStyles := theme.NewStyle().Foreground(theme.Primary).Bold(true).MarginBottom(1)
+	metaStyle := theme.NewStyle().Foreground(theme.Secondary)
+	errorStyle := theme.NewStyle().Foreground(theme.Error)
+	loadingStyle := theme.NewStyle().Foreground(theme.Accent)
+	helpStyle := theme.NewStyle().Foreground(theme.Help).MarginTop(1)
+	boxStyle := theme.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Primary).Padding(1, 2)
+	starStyle := theme.NewStyle().Foreground(lipgloss.Color("#FFD700"))
 
 
 	if m.err != nil {
@@ -92,7 +113,7 @@ func (m projectDetailsModel) View() string {
 	}
 
 	langColor := styles.GetLanguageColor(lang)
-	langStyle := lipgloss.NewStyle().Foreground(langColor).Bold(true)
+	langStyle := theme.NewStyle().Foreground(langColor).Bold(true)
 
 	header := titleStyles.Render("📁 " + m.project.Name) + "\n"
 	header += metaStyle.Render(m.project.Description) + "\n"
@@ -105,6 +126,6 @@ func (m projectDetailsModel) View() string {
 		s += m.rendered + "\n"
 	}
 
-	s += helpStyle.Render("\n↑/↓: navigate • enter: select • q: quit")
+	s += helpStyle.Render("\nf: browse files • esc: back • q: quit")
 	return s
 }
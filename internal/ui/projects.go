@@ -3,12 +3,13 @@ package ui
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"clifolio/internal/services"
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -36,7 +37,8 @@ type projectsModel struct {
 	loading  bool
 	err      error
 
-	spin components.SpinnerComponent
+	spin  components.SpinnerComponent
+	theme styles.Theme
 
 	offset   int
 	pageSize int
@@ -59,11 +61,12 @@ type openProjectMsg struct {
 	err  error
 }
 
-func ProjectsModel(username string) *projectsModel {
+func ProjectsModel(username string, theme styles.Theme) *projectsModel {
 	return &projectsModel{
 		username: username,
 		loading:  true,
-		spin:     components.NewSpinner(),
+		spin:     components.NewSpinner(theme),
+		theme:    theme,
 		cursor:   0,
 		offset:   0,
 		pageSize: 10,
@@ -222,21 +225,16 @@ func (m *projectsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *projectsModel) View() string {
-	theme := styles.NewThemeFromName("default")
-	titleStyles := lipgloss.NewStyle().Foreground(theme.Primary).Bold(true).MarginBottom(1)
-	subtitleStyle := lipgloss.NewStyle().Foreground(theme.Secondary)
-	selectedCardStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Accent).Padding(0, 1).MarginBottom(1)
-	normalCardStyle := lipgloss.NewStyle().Border(lipgloss.HiddenBorder()).Padding(0, 1).MarginBottom(1)
-	errorStyle := lipgloss.NewStyle().Foreground(theme.Error)
-	helpStyle := lipgloss.NewStyle().Foreground(theme.Help).MarginTop(1)
-	starStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD700"))
+	theme := m.theme
+	errorStyle := theme.NewStyle().Foreground(theme.Error)
+	helpStyle := theme.NewStyle().Foreground(theme.Help).MarginTop(1)
 
 	if m.err != nil {
 		return errorStyle.Render(fmt.Sprintf("\n\n Error: %s", m.err))
 	}
 
 	if m.loading {
-		loadingBox := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Primary).Padding(2, 4).Render(fmt.Sprintf("%s Loading GitHub repos...", m.spin.View()))
+		loadingBox := theme.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Primary).Padding(2, 4).Render(fmt.Sprintf("%s Loading GitHub repos...", m.spin.View()))
 
 		if m.width > 0 && m.height > 0 {
 			return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, loadingBox)
@@ -257,6 +255,34 @@ func (m *projectsModel) View() string {
 		end = len(m.projects)
 	}
 
+	s := m.renderCards(start, end)
+	s += helpStyle.Render("\n↑/↓: navigate • enter: select • q: quit")
+
+	return s
+}
+
+// renderPage draws every project on one page, with none selected, for
+// `curl host/projects`.
+func (m *projectsModel) renderPage() string {
+	if len(m.projects) == 0 {
+		return "\n\n No repositories found."
+	}
+	m.cursor = -1
+	m.offset = 0
+	m.pageSize = len(m.projects)
+	return strings.TrimRight(m.renderCards(0, len(m.projects)), "\n")
+}
+
+// renderCards draws the title and the cards of the projects from start up
+// to end.
+func (m *projectsModel) renderCards(start, end int) string {
+	theme := m.theme
+	titleStyles := theme.NewStyle().Foreground(theme.Primary).Bold(true).MarginBottom(1)
+	subtitleStyle := theme.NewStyle().Foreground(theme.Secondary)
+	selectedCardStyle := theme.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(theme.Accent).Padding(0, 1).MarginBottom(1)
+	normalCardStyle := theme.NewStyle().Border(lipgloss.HiddenBorder()).Padding(0, 1).MarginBottom(1)
+	starStyle := theme.NewStyle().Foreground(lipgloss.Color("#FFD700"))
+
 	totalPages := (len(m.projects) + m.pageSize - 1) / m.pageSize
 	currentPage := (start / m.pageSize) + 1
 
@@ -272,7 +298,7 @@ func (m *projectsModel) View() string {
 			lang = "Unknown"
 		}
 		langColor := styles.GetLanguageColor(lang)
-		langStyle := lipgloss.NewStyle().Foreground(langColor).Bold(true)
+		langStyle := theme.NewStyle().Foreground(langColor).Bold(true)
 		langIndicator := langStyle.Render("●") + " " + langStyle.Render(lang)
 
 		desc := repo.Description
@@ -299,7 +325,5 @@ func (m *projectsModel) View() string {
 		}
 	}
 
-	s += helpStyle.Render("\n↑/↓: navigate • enter: select • q: quit")
-
 	return s
 }
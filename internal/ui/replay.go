@@ -0,0 +1,240 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"clifolio/internal/services"
+	"clifolio/internal/styles"
+	"clifolio/internal/ui/components"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// replaySpeeds are the playback rates + and - step through.
+var replaySpeeds = []float64{0.5, 1, 2, 4, 8}
+
+const (
+	replayFrame = 50 * time.Millisecond
+	replaySeek  = 5 * time.Second
+	// replayIdleLimit shortens long pauses, while the visitor was reading
+	replayIdleLimit = 2 * time.Second
+)
+
+// replayModel plays a session recording back inside the admin console.
+type replayModel struct {
+	cast    *services.Cast
+	vt      *services.VTerm
+	next    int // the next event to apply
+	clock   time.Duration
+	total   time.Duration
+	playing bool
+	speed   int
+	tickGen int
+
+	theme  styles.Theme
+	width  int
+	height int
+}
+
+// replayTickMsg advances playback. gen drops ticks from before a pause.
+type replayTickMsg struct {
+	gen int
+}
+
+// closeReplayMsg takes the admin console back to the recordings list.
+type closeReplayMsg struct{}
+
+func newReplayModel(cast *services.Cast, theme styles.Theme) *replayModel {
+	m := &replayModel{
+		cast:  cast,
+		vt:    services.NewVTerm(cast.Width, cast.Height),
+		speed: 1,
+		theme: theme,
+	}
+	if n := len(cast.Events); n > 0 {
+		m.total = cast.Events[n-1].Time
+	}
+	return m
+}
+
+func (m *replayModel) Init() tea.Cmd {
+	return m.play()
+}
+
+func (m *replayModel) play() tea.Cmd {
+	if m.next >= len(m.cast.Events) {
+		m.seek(0)
+	}
+	m.playing = true
+	m.tickGen++
+	return replayTick(m.tickGen)
+}
+
+func replayTick(gen int) tea.Cmd {
+	return tea.Tick(replayFrame, func(time.Time) tea.Msg { return replayTickMsg{gen: gen} })
+}
+
+// apply plays every event up to the clock.
+func (m *replayModel) apply() {
+	for ; m.next < len(m.cast.Events) && m.cast.Events[m.next].Time <= m.clock; m.next++ {
+		ev := m.cast.Events[m.next]
+		switch ev.Type {
+		case "o":
+			_, _ = m.vt.Write([]byte(ev.Data))
+		case "r":
+			var w, h int
+			if _, err := fmt.Sscanf(ev.Data, "%dx%d", &w, &h); err == nil {
+				m.vt.Resize(w, h)
+			}
+		}
+	}
+}
+
+// seek moves the clock to t. Going back replays from the start, since a
+// terminal can't be rewound.
+func (m *replayModel) seek(t time.Duration) {
+	t = clampDuration(t, 0, m.total)
+	if t < m.clock {
+		m.vt = services.NewVTerm(m.cast.Width, m.cast.Height)
+		m.next = 0
+	}
+	m.clock = t
+	m.apply()
+}
+
+func (m *replayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case replayTickMsg:
+		if msg.gen != m.tickGen || !m.playing {
+			return m, nil
+		}
+		if m.next < len(m.cast.Events) {
+			if gap := m.cast.Events[m.next].Time - m.clock; gap > replayIdleLimit {
+				m.clock += gap - replayIdleLimit
+			}
+		}
+		m.clock = clampDuration(m.clock+time.Duration(float64(replayFrame)*replaySpeeds[m.speed]), 0, m.total)
+		m.apply()
+		if m.next >= len(m.cast.Events) {
+			m.playing = false
+			return m, nil
+		}
+		return m, replayTick(m.tickGen)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc", "b":
+			return m, func() tea.Msg { return closeReplayMsg{} }
+		case " ", "p":
+			if m.playing {
+				m.playing = false
+				return m, nil
+			}
+			return m, m.play()
+		case "left", "h":
+			m.seek(m.clock - replaySeek)
+		case "right", "l":
+			m.seek(m.clock + replaySeek)
+		case "0", "home":
+			m.seek(0)
+		case "end":
+			m.seek(m.total)
+		case "+", "=":
+			m.speed = min(m.speed+1, len(replaySpeeds)-1)
+		case "-":
+			m.speed = max(m.speed-1, 0)
+		}
+	}
+	return m, nil
+}
+
+func (m *replayModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	title := m.theme.NewStyle().Foreground(m.theme.Accent).Bold(true).
+		Render("Replaying " + m.cast.Start.Local().Format("Jan 2 15:04"))
+	visitor := m.cast.Visitor
+	if visitor == "" {
+		visitor = "unknown visitor"
+	}
+	meta := m.theme.NewStyle().Foreground(m.theme.Secondary).
+		Render(fmt.Sprintf(" · %s · %s", strings.TrimPrefix(visitor, "SHA256:"), m.cast.Term))
+	heading := lipgloss.PlaceHorizontal(m.width, lipgloss.Center, ansi.Truncate(title+meta, m.width-4, "…"))
+
+	// The recorded screen, cropped when it's bigger than ours
+	maxW, maxH := m.width-4, m.height-8
+	lines := m.vt.Lines()
+	if len(lines) > maxH {
+		lines = lines[:max(0, maxH)]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, maxW, "")
+	}
+	frame := m.theme.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.theme.Secondary).
+		Render(strings.Join(lines, "\n"))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		heading,
+		lipgloss.PlaceHorizontal(m.width, lipgloss.Center, frame),
+		m.renderProgress(),
+		components.RenderKeyBindings([]components.KeyBind{
+			{Key: "Space", Desc: "Play/Pause"},
+			{Key: "←→", Desc: "Seek 5s"},
+			{Key: "+/-", Desc: "Speed"},
+			{Key: "0", Desc: "Restart"},
+			{Key: "b/Esc", Desc: "Close"},
+		}, m.theme, m.width),
+	)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+func (m *replayModel) renderProgress() string {
+	icon := "⏸"
+	if m.playing {
+		icon = "▶"
+	}
+	label := fmt.Sprintf(" %s / %s · %gx", clockTime(m.clock), clockTime(m.total), replaySpeeds[m.speed])
+
+	barWidth := max(10, min(60, m.width-lipgloss.Width(label)-10))
+	filled := barWidth
+	if m.total > 0 {
+		filled = int(float64(barWidth) * float64(m.clock) / float64(m.total))
+	}
+	bar := m.theme.NewStyle().Foreground(m.theme.Accent).Render(strings.Repeat("━", filled)) +
+		m.theme.NewStyle().Foreground(m.theme.Secondary).Render(strings.Repeat("─", barWidth-filled))
+
+	line := icon + " " + bar + m.theme.NewStyle().Foreground(m.theme.Secondary).Render(label)
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Center, line)
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
+	}
+	return d
+}
+
+// clockTime formats a playback position as m:ss.
+func clockTime(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -1,9 +1,9 @@
 package ui
 
 import (
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,20 +11,20 @@ import (
 )
 
 type Skill struct {
-	Name     string
-	Level    int
-	Category string
-	Years    int
-	Icon     string
-	Projects int
-	Color    lipgloss.Color
+	Name     string         `json:"name"`
+	Level    int            `json:"level"`
+	Category string         `json:"category"`
+	Years    int            `json:"years"`
+	Icon     string         `json:"-"`
+	Projects int            `json:"projects"`
+	Color    lipgloss.Color `json:"-"`
 }
 
 type CategoryInfo struct {
-	ID          string
-	DisplayName string
-	Icon        string
-	Description string
+	ID          string `json:"id"`
+	DisplayName string `json:"name"`
+	Icon        string `json:"-"`
+	Description string `json:"description"`
 }
 
 type skillsModel struct {
@@ -38,8 +38,9 @@ type skillsModel struct {
 	category   string
 }
 
-func NewSkillsModel(theme styles.Theme) *skillsModel {
-	categories := []CategoryInfo{
+// skillCategories groups the skills screen's tabs.
+func skillCategories() []CategoryInfo {
+	return []CategoryInfo{
 		{
 			ID:          "frontend",
 			DisplayName: "UI Mastery",
@@ -77,8 +78,11 @@ func NewSkillsModel(theme styles.Theme) *skillsModel {
 			Description: "Ancient programming languages",
 		},
 	}
+}
 
-	skills := []Skill{
+// portfolioSkills is shared by the skills screen and the skills command.
+func portfolioSkills() []Skill {
+	return []Skill{
 		// Frontend
 		{Name: "React", Level: 5, Category: "frontend", Years: 3, Icon: "⚡", Projects: 20, Color: lipgloss.Color("#61DAFB")},
 		{Name: "Vue", Level: 1, Category: "frontend", Years: 1, Icon: "⚡", Projects: 20, Color: lipgloss.Color("#61DAFB")},
@@ -115,6 +119,12 @@ func NewSkillsModel(theme styles.Theme) *skillsModel {
 		{Name: "Python", Level: 3, Category: "languages", Years: 2, Icon: "🐍", Projects: 10, Color: lipgloss.Color("#3776AB")},
 		{Name: "SQL", Level: 4, Category: "languages", Years: 3, Icon: "📊", Projects: 18, Color: lipgloss.Color("#CC2927")},
 	}
+}
+
+func NewSkillsModel(theme styles.Theme) *skillsModel {
+	categories := skillCategories()
+
+	skills := portfolioSkills()
 
 	return &skillsModel{
 		skills:     skills,
@@ -196,25 +206,7 @@ func (m *skillsModel) View() string {
 	sections = append(sections, categorySelector)
 
 	sections = append(sections, components.DividerLine(m.theme, m.width-4, "─"))
-
-	// Current category info
-	currentCat := m.getCurrentCategoryInfo()
-	if currentCat != nil {
-		catInfo := lipgloss.NewStyle().
-			Foreground(m.theme.Secondary).
-			Italic(true).
-			Align(lipgloss.Center).
-			Width(m.width).
-			Render(fmt.Sprintf("%s", currentCat.Description))
-		sections = append(sections, catInfo)
-	}
-
-	// Stats overview
-	stats := m.renderStatsOverview()
-	sections = append(sections, stats)
-
-	skillsGrid := m.renderSkillsCompactGrid()
-	sections = append(sections, skillsGrid)
+	sections = append(sections, m.renderCategory()...)
 
 	// Key bindings
 	keyBindings := []components.KeyBind{
@@ -237,29 +229,61 @@ func (m *skillsModel) View() string {
 	)
 }
 
-func (m *skillsModel) renderCategorySelector() string {
-	var tabs []string
+// renderPage draws every category one after another, each under its tab,
+// for `curl host/skills`.
+func (m *skillsModel) renderPage() string {
+	sections := []string{components.HeaderBox("WARRIOR'S ABILITIES", m.theme, m.width-4)}
+	for _, cat := range m.categories {
+		m.category = cat.ID
+		sections = append(sections, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, m.renderTab(cat)))
+		sections = append(sections, m.renderCategory()...)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
 
-	activeStyle := lipgloss.NewStyle().
-		Foreground(m.theme.Accent).
-		Bold(true).
-		Padding(0, 2).
-		Border(lipgloss.NormalBorder()).
-		BorderForeground(m.theme.Accent)
+// renderCategory draws the current category's description, stats and
+// skills.
+func (m *skillsModel) renderCategory() []string {
+	var sections []string
+	currentCat := m.getCurrentCategoryInfo()
+	if currentCat != nil {
+		catInfo := m.theme.NewStyle().
+			Foreground(m.theme.Secondary).
+			Italic(true).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Render(fmt.Sprintf("%s", currentCat.Description))
+		sections = append(sections, catInfo)
+	}
 
-	inactiveStyle := lipgloss.NewStyle().
+	// Stats overview
+	stats := m.renderStatsOverview()
+	sections = append(sections, stats)
+
+	skillsGrid := m.renderSkillsCompactGrid()
+	return append(sections, skillsGrid)
+}
+
+// renderTab draws a category's tab, highlighted when it's the current one.
+func (m *skillsModel) renderTab(cat CategoryInfo) string {
+	style := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Padding(0, 2).
 		Border(lipgloss.NormalBorder()).
 		BorderForeground(lipgloss.Color("transparent"))
+	if cat.ID == m.category {
+		style = style.
+			Foreground(m.theme.Accent).
+			Bold(true).
+			BorderForeground(m.theme.Accent)
+	}
+	return style.Render(cat.Icon + " " + cat.DisplayName)
+}
 
+func (m *skillsModel) renderCategorySelector() string {
+	var tabs []string
 	for _, cat := range m.categories {
-		label := cat.Icon + " " + cat.DisplayName
-		if cat.ID == m.category {
-			tabs = append(tabs, activeStyle.Render(label))
-		} else {
-			tabs = append(tabs, inactiveStyle.Render(label))
-		}
+		tabs = append(tabs, m.renderTab(cat))
 	}
 
 	tabsRow := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
@@ -298,7 +322,7 @@ func (m *skillsModel) renderStatsOverview() string {
 		fmt.Sprintf("Years: %d+", maxYears),
 	}
 
-	statStyle := lipgloss.NewStyle().
+	statStyle := m.theme.NewStyle().
 		Foreground(m.theme.Primary).
 		Bold(true).
 		Padding(0, 3)
@@ -310,7 +334,7 @@ func (m *skillsModel) renderStatsOverview() string {
 
 	statsRow := lipgloss.JoinHorizontal(lipgloss.Top, statBoxes...)
 
-	statsContainer := lipgloss.NewStyle().
+	statsContainer := m.theme.NewStyle().
 		Border(lipgloss.NormalBorder()).
 		BorderForeground(m.theme.Secondary).
 		Padding(0, 1).
@@ -327,7 +351,7 @@ func (m *skillsModel) renderSkillsCompactGrid() string {
 	filteredSkills := m.getFilteredSkills()
 
 	if len(filteredSkills) == 0 {
-		emptyStyle := lipgloss.NewStyle().
+		emptyStyle := m.theme.NewStyle().
 			Foreground(m.theme.Secondary).
 			Italic(true).
 			Align(lipgloss.Center).
@@ -379,7 +403,7 @@ func (m *skillsModel) renderSkillsCompactGrid() string {
 
 func (m *skillsModel) renderSkillCard(skill Skill, width int) string {
 	// Skill name
-	nameStyle := lipgloss.NewStyle().
+	nameStyle := m.theme.NewStyle().
 		Foreground(m.theme.Primary).
 		Bold(true).
 		Width(width).
@@ -398,13 +422,13 @@ func (m *skillsModel) renderSkillCard(skill Skill, width int) string {
 		levelBar += "░"
 	}
 
-	levelStyle := lipgloss.NewStyle().
+	levelStyle := m.theme.NewStyle().
 		Foreground(skill.Color).
 		Width(width).
 		Align(lipgloss.Center)
 
 	// Years indicator
-	yearsStyle := lipgloss.NewStyle().
+	yearsStyle := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Width(width).
 		Align(lipgloss.Center)
@@ -417,7 +441,7 @@ func (m *skillsModel) renderSkillCard(skill Skill, width int) string {
 		yearsStyle.Render(fmt.Sprintf("%dyr", skill.Years)),
 	)
 
-	cardStyle := lipgloss.NewStyle().
+	cardStyle := m.theme.NewStyle().
 		Width(width).
 		Height(5).
 		Margin(0, 1, 1, 0).
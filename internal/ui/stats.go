@@ -2,11 +2,12 @@ package ui
 
 import (
 	"clifolio/internal/services"
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
 	"context"
 	"fmt"
+	"image"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -18,9 +19,12 @@ type statsModel struct {
 	loading  bool
 	err      error
 	spin     components.SpinnerComponent
+	theme    styles.Theme
 	width    int
 	height   int
 	username string
+	graphics services.GraphicsProtocol
+	avatar   string
 }
 
 type statsLoadedMsg struct {
@@ -33,11 +37,17 @@ type statsErrorMsg struct {
 
 type statsTickMsg struct{}
 
-func StatsModel(username string) *statsModel {
+type avatarLoadedMsg struct {
+	img image.Image
+}
+
+func StatsModel(username string, theme styles.Theme, graphics services.GraphicsProtocol) *statsModel {
 	return &statsModel{
 		loading:  true,
-		spin:     components.NewSpinner(),
+		spin:     components.NewSpinner(theme),
+		theme:    theme,
 		username: username,
+		graphics: graphics,
 	}
 }
 
@@ -62,6 +72,20 @@ func fetchStatsCmd(username string) tea.Cmd {
 	}
 }
 
+func fetchAvatarCmd(url string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		img, err := services.FetchImage(ctx, url)
+		if err != nil {
+			// The avatar is decoration, the stats are still worth showing
+			return nil
+		}
+		return avatarLoadedMsg{img}
+	}
+}
+
 func tickStats() tea.Cmd {
 	return tea.Tick(30*time.Second, func(t time.Time) tea.Msg {
 		return statsTickMsg{}
@@ -85,6 +109,17 @@ func (m *statsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case statsLoadedMsg:
 		m.stats = msg.stats
 		m.loading = false
+		if m.avatar == "" && msg.stats.AvatarURL != "" {
+			cmds = append(cmds, fetchAvatarCmd(msg.stats.AvatarURL))
+		}
+
+	case avatarLoadedMsg:
+		m.avatar = services.RenderImage(msg.img, services.ImageOptions{
+			Width:    24,
+			Height:   12,
+			Protocol: m.graphics,
+			Profile:  m.theme.Renderer.ColorProfile(),
+		})
 
 	case statsErrorMsg:
 		m.err = msg.err
@@ -111,29 +146,29 @@ func (m *statsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *statsModel) View() string {
-	theme := styles.NewThemeFromName("default")
+	theme := m.theme
 
-	titleStyle := lipgloss.NewStyle().
+	titleStyle := theme.NewStyle().
 		Foreground(theme.Primary).
 		Bold(true).
 		MarginBottom(2)
 
-	statBoxStyle := lipgloss.NewStyle().
+	statBoxStyle := theme.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(theme.Accent).
 		Padding(1, 2).
 		Margin(0, 1)
 
-	labelStyle := lipgloss.NewStyle().
+	labelStyle := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Bold(true)
 
-	valueStyle := lipgloss.NewStyle().
+	valueStyle := theme.NewStyle().
 		Foreground(theme.Primary).
 		Bold(true).
 		Align(lipgloss.Center)
 
-	helpStyle := lipgloss.NewStyle().
+	helpStyle := theme.NewStyle().
 		Foreground(theme.Help).
 		MarginTop(2)
 
@@ -174,10 +209,13 @@ func (m *statsModel) View() string {
 	row1 := lipgloss.JoinHorizontal(lipgloss.Top, reposStat, starsStat)
 	row2 := lipgloss.JoinHorizontal(lipgloss.Top, followersStat, gistsStat)
 	statsGrid := lipgloss.JoinVertical(lipgloss.Left, row1, row2)
+	if m.avatar != "" {
+		statsGrid = lipgloss.JoinHorizontal(lipgloss.Center, m.avatar, "  ", statsGrid)
+	}
 
 	help := helpStyle.Render("Press 'r' to refresh • ESC to go back • q to quit")
 
-	lastUpdate := lipgloss.NewStyle().
+	lastUpdate := theme.NewStyle().
 		Foreground(theme.Secondary).
 		Italic(true).
 		Render(fmt.Sprintf("Last updated: %s", m.stats.UpdatedAt.Format("15:04:05")))
@@ -1,9 +1,9 @@
 package ui
 
 import (
+	"clifolio/internal/state"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui/components"
-	"clifolio/internal/ui/state"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -133,7 +133,7 @@ func (m *themePickerModel) View() string {
 	header := components.HeaderBox("THEME SELECTOR", m.theme, m.width-4)
 	sections = append(sections, header)
 
-	intro := lipgloss.NewStyle().
+	intro := m.theme.NewStyle().
 		Foreground(m.theme.Secondary).
 		Italic(true).
 		Align(lipgloss.Center).
@@ -194,19 +194,19 @@ func (m *themePickerModel) renderThemeList() string {
 }
 
 func (m *themePickerModel) renderPreview() string {
-	previewTheme := styles.NewThemeFromName(m.previewTheme)
+	previewTheme := styles.NewTheme(m.previewTheme, m.theme.Renderer)
 
-	titleStyle := lipgloss.NewStyle().
+	titleStyle := m.theme.NewStyle().
 		Foreground(previewTheme.Primary).
 		Bold(true).
 		Align(lipgloss.Center)
 
-	accentStyle := lipgloss.NewStyle().
+	accentStyle := m.theme.NewStyle().
 		Foreground(previewTheme.Accent).
 		Bold(true).
 		Align(lipgloss.Center)
 
-	secondaryStyle := lipgloss.NewStyle().
+	secondaryStyle := m.theme.NewStyle().
 		Foreground(previewTheme.Secondary).
 		Align(lipgloss.Center)
 
@@ -217,7 +217,7 @@ func (m *themePickerModel) renderPreview() string {
 		secondaryStyle.Render("● Secondary Color"),
 	)
 
-	preview := lipgloss.NewStyle().
+	preview := m.theme.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(previewTheme.Primary).
 		Padding(1, 2).
@@ -1,38 +1,307 @@
 package main
 
 import (
+	"clifolio/internal/config"
 	"clifolio/internal/services"
 	"clifolio/internal/styles"
 	"clifolio/internal/ui"
+	"context"
 	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/ssh"
 	"github.com/joho/godotenv"
 )
 
-
-
 func main() {
 	themeName := flag.String("theme", "default", "theme name (hacker|dracula|default)")
 	sshMode := flag.Bool("ssh-mode", false, "run as SSH server instead of local TUI")
+	webMode := flag.Bool("web-mode", false, "serve the TUI to browsers, alongside the SSH server when both are set")
+	gopherMode := flag.Bool("gopher-mode", false, "publish the portfolio over Gopher")
+	geminiMode := flag.Bool("gemini-mode", false, "publish the portfolio over Gemini")
+	configPath := flag.String("config", os.Getenv("CLIFOLIO_CONFIG"), "path to a JSON config file")
+
+	// Server settings, these override the config file and environment
+	var flags config.Config
+	flag.StringVar(&flags.DataDir, "data-dir", "", "directory for generated state such as host keys")
+	flag.StringVar(&flags.SSH.Address, "ssh-addr", "", "SSH listen address (default 0.0.0.0:23234)")
+	flag.StringVar(&flags.Web.Address, "web-addr", "", "web gateway listen address (default 0.0.0.0:8080)")
+	flag.StringVar(&flags.Gopher.Address, "gopher-addr", "", "Gopher listen address (default 0.0.0.0:70)")
+	flag.StringVar(&flags.Gemini.Address, "gemini-addr", "", "Gemini listen address (default 0.0.0.0:1965)")
+	flag.StringVar(&flags.Metrics.Address, "metrics-addr", "", "metrics and health check listen address, e.g. 127.0.0.1:9100 (disabled by default)")
+	flag.StringVar(&flags.Finger.Address, "finger-addr", "", "finger server listen address, e.g. :79 (disabled by default)")
+	trustedProxies := flag.String("trusted-proxies", "", "comma-separated addresses or CIDRs allowed to send PROXY protocol headers")
+	hostKeys := flag.String("host-key", "", "comma-separated SSH host key paths, generated if missing")
+	flag.Var(&flags.SSH.IdleTimeout, "idle-timeout", "disconnect idle SSH connections after this long (0 disables)")
+	flag.Var(&flags.SSH.MaxSessionDuration, "max-session", "maximum SSH connection length (0 disables)")
+	flag.StringVar(&flags.SSH.Banner, "banner", "", "banner shown to SSH clients before authentication")
+	flag.Var(&flags.SSH.ShutdownGrace, "shutdown-grace", "countdown visitors get before a stop ends their sessions (0 disables)")
+	flag.IntVar(&flags.Limits.MaxSessions, "max-sessions", 0, "maximum concurrent SSH sessions (0 disables)")
+	flag.IntVar(&flags.Limits.RatePerIP, "rate-per-ip", 0, "new sessions allowed per address per rate window (0 disables)")
+	flag.Var(&flags.Limits.InputIdleTimeout, "input-idle-timeout", "end sessions without input for this long (0 disables)")
 	flag.Parse()
 
 	_ = styles.NewThemeFromName(*themeName)
 
 	err := godotenv.Load(".env")
 	if err != nil {
-		fmt.Println("Oh no! env file not found.")
+		fmt.Fprintln(os.Stderr, "Oh no! env file not found.")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	if *sshMode {
-		fmt.Println("Starting SSH server mode...")
-		services.StartSSHServer(func() tea.Model {
-			return ui.AppModel()
-		})
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "data-dir":
+			cfg.DataDir = flags.DataDir
+		case "ssh-addr":
+			cfg.SSH.Address = flags.SSH.Address
+		case "web-addr":
+			cfg.Web.Address = flags.Web.Address
+		case "gopher-addr":
+			cfg.Gopher.Address = flags.Gopher.Address
+		case "gemini-addr":
+			cfg.Gemini.Address = flags.Gemini.Address
+		case "metrics-addr":
+			cfg.Metrics.Address = flags.Metrics.Address
+		case "finger-addr":
+			cfg.Finger.Address = flags.Finger.Address
+		case "trusted-proxies":
+			cfg.Proxy.TrustedProxies = config.SplitList(*trustedProxies)
+		case "host-key":
+			cfg.SSH.HostKeyPaths = config.SplitList(*hostKeys)
+		case "idle-timeout":
+			cfg.SSH.IdleTimeout = flags.SSH.IdleTimeout
+		case "max-session":
+			cfg.SSH.MaxSessionDuration = flags.SSH.MaxSessionDuration
+		case "banner":
+			cfg.SSH.Banner = flags.SSH.Banner
+		case "shutdown-grace":
+			cfg.SSH.ShutdownGrace = flags.SSH.ShutdownGrace
+		case "max-sessions":
+			cfg.Limits.MaxSessions = flags.Limits.MaxSessions
+		case "rate-per-ip":
+			cfg.Limits.RatePerIP = flags.Limits.RatePerIP
+		case "input-idle-timeout":
+			cfg.Limits.InputIdleTimeout = flags.Limits.InputIdleTimeout
+		}
+	})
+
+	services.SetGitHubCacheTTL(cfg.GitHub.CacheTTL.Std())
+
+	var analytics *services.Analytics
+	if cfg.Analytics.Enabled {
+		analytics, err = services.OpenAnalytics(cfg.AnalyticsPath())
+		if err != nil {
+			log.Printf("Analytics disabled: %v", err)
+		}
+	}
+
+	var guestbook *services.Guestbook
+	if cfg.Guestbook.Enabled {
+		guestbook, err = services.OpenGuestbook(cfg.GuestbookPath())
+		if err != nil {
+			log.Printf("Guestbook disabled: %v", err)
+		}
+	}
+
+	var inbox *services.Inbox
+	if cfg.Contact.Enabled {
+		inbox, err = services.OpenInbox(cfg.ContactInboxPath(), cfg.Contact)
+		if err != nil {
+			log.Printf("Contact form disabled: %v", err)
+		}
+	}
+
+	var recorder *services.Recorder
+	if cfg.Recording.Enabled {
+		recorder, err = services.OpenRecorder(cfg.RecordingDir(), cfg.Recording)
+		if err != nil {
+			log.Printf("Session recording disabled: %v", err)
+		}
+	}
+
+	if *sshMode || *webMode || *gopherMode || *geminiMode {
+		hub := services.NewHub()
+		// Every frontend counts towards the same session limits
+		limiter := services.NewLimiter(cfg.Limits)
+
+		// Letting everyone in because the lists are broken would be worse
+		// than not starting
+		var access *services.Access
+		if *sshMode || *webMode {
+			access, err = services.OpenAccess(cfg)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+
+		if cfg.Metrics.Address != "" {
+			// Ready once every server asked for is listening
+			var servers []string
+			for _, mode := range []struct {
+				name string
+				on   bool
+			}{{"ssh", *sshMode}, {"web", *webMode}, {"gopher", *gopherMode}, {"gemini", *geminiMode}} {
+				if mode.on {
+					servers = append(servers, mode.name)
+				}
+			}
+			go services.StartMetricsServer(cfg, services.MetricsServerOptions{Servers: servers})
+		}
+
+		// Every server runs until the process is told to stop, then they
+		// wind down together
+		var servers []func(ctx context.Context)
+		if cfg.Finger.Address != "" {
+			profile := services.GetProfileData()
+			login := cfg.Finger.User
+			if names := strings.Fields(profile.Name); login == "" && len(names) > 0 {
+				login = strings.ToLower(names[0])
+			}
+			if login == "" {
+				login = "me"
+			}
+			servers = append(servers, func(ctx context.Context) {
+				services.StartFingerServer(ctx, cfg, services.FingerServerOptions{
+					Users:   []services.FingerUser{{Login: login, Profile: profile, Availability: cfg.Finger.Availability}},
+					Hub:     hub,
+					Limiter: limiter,
+				})
+			})
+		}
+
+		// Gopher and Gemini share the content the sections are built from
+		if *gopherMode {
+			servers = append(servers, func(ctx context.Context) {
+				services.StartGopherServer(ctx, cfg, services.GopherServerOptions{Pages: ui.ContentPage, Limiter: limiter})
+			})
+		}
+		if *geminiMode {
+			servers = append(servers, func(ctx context.Context) {
+				services.StartGeminiServer(ctx, cfg, services.GeminiServerOptions{Pages: ui.ContentPage, Limiter: limiter})
+			})
+		}
+		if *webMode {
+			fmt.Println("Starting web gateway...")
+			servers = append(servers, func(ctx context.Context) {
+				services.StartWebServer(ctx, cfg, services.WebServerOptions{
+					Hub:       hub,
+					Analytics: analytics,
+					Recorder:  recorder,
+					Limiter:   limiter,
+					Access:    access,
+					App: func(w *services.WebSession) tea.Model {
+						// Browsers have no key, so they're never the owner
+						return ui.NewAppModel(ui.Options{
+							Renderer:  w.Renderer,
+							Tracker:   w.Info.Tracker,
+							Analytics: analytics,
+							Guestbook: guestbook,
+							Live:      w.Info.Live,
+							Hub:       hub,
+							Inbox:     inbox,
+							Recorder:  recorder,
+							Clipboard: services.OSC52Clipboard{W: w.Output, Term: services.WebTerm},
+						})
+					},
+					Sections: ui.Sections,
+					Render: func(ctx context.Context, section, host string, r *lipgloss.Renderer, width int) (string, error) {
+						return ui.RenderPage(ctx, section, ui.CommandOptions{Renderer: r, Width: width, Host: host})
+					},
+					APIs: ui.APIs,
+					Data: ui.APIData,
+				})
+			})
+		}
+		if *sshMode {
+			fmt.Println("Starting SSH server mode...")
+			servers = append(servers, func(ctx context.Context) {
+				services.StartSSHServer(ctx, cfg, services.SSHServerOptions{
+					Hub:       hub,
+					Analytics: analytics,
+					Recorder:  recorder,
+					Limiter:   limiter,
+					Access:    access,
+					App: func(s ssh.Session) tea.Model {
+						info := services.SessionInfoFrom(s)
+						pty, _, _ := s.Pty()
+						return ui.NewAppModel(ui.Options{
+							Graphics:  services.DetectGraphics(services.SessionEnviron(s)),
+							Renderer:  services.SessionRenderer(s),
+							Tracker:   info.Tracker,
+							Owner:     info.Owner,
+							Analytics: analytics,
+							Key:       s.PublicKey(),
+							Guestbook: guestbook,
+							Live:      info.Live,
+							Hub:       hub,
+							Inbox:     inbox,
+							Recorder:  recorder,
+							Access:    access,
+							// The server's clipboard is no use to a visitor
+							Clipboard: services.OSC52Clipboard{W: info.Output, Term: pty.Term},
+						})
+					},
+					Files: ui.DownloadFS(),
+					Command: func(s ssh.Session) int {
+						// Without a PTY the renderer is plain text, which is what
+						// scripts piping the output want
+						width := 80
+						errOut := io.Writer(s.Stderr())
+						if pty, _, ok := s.Pty(); ok {
+							width = pty.Window.Width
+							errOut = ssh.NewPtyWriter(errOut)
+						}
+						ctx, cancel := context.WithTimeout(s.Context(), 15*time.Second)
+						defer cancel()
+						return ui.RunCommand(ctx, s.Command(), s, errOut, ui.CommandOptions{
+							Renderer: services.SessionRenderer(s),
+							Width:    width,
+						})
+					},
+				})
+			})
+		}
+		services.Serve(hub, limiter, cfg.SSH.ShutdownGrace.Std(), servers...)
+
+		// Sessions that outlasted the drain end with the process, so their
+		// recordings are closed here and nothing half-written is left behind
+		if err := inbox.Close(); err != nil {
+			log.Printf("Closing the contact inbox: %v", err)
+		}
+		if err := recorder.Close(); err != nil {
+			log.Printf("Closing the recordings: %v", err)
+		}
+		if err := analytics.Close(); err != nil {
+			log.Printf("Closing analytics: %v", err)
+		}
+	} else if flag.NArg() > 0 {
+		// Same sections as `ssh host skills`, for `clifolio skills`
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		code := ui.RunCommand(ctx, flag.Args(), os.Stdout, os.Stderr, ui.CommandOptions{Width: 80})
+		cancel()
+		os.Exit(code)
 	} else {
-		p := tea.NewProgram(ui.AppModel(), tea.WithAltScreen())
+		// Whoever runs the app locally owns it
+		opts := ui.LocalOptions()
+		opts.Owner = true
+		opts.Analytics = analytics
+		opts.Guestbook = guestbook
+		opts.Inbox = inbox
+		opts.Recorder = recorder
+
+		p := tea.NewProgram(ui.NewAppModel(opts), tea.WithAltScreen())
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 			os.Exit(1)